@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader carries the ID of a request, taken from the client or
+// reverse proxy when it sent a valid one
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest request ID accepted from clients
+const maxRequestIDLength = 128
+
+// requestLogHandler assigns every request an ID, returned in the
+// X-Request-ID header and quoted in error responses, and logs the method,
+// path, status, latency and client of every request when logRequests is set
+func requestLogHandler(next http.Handler, proxies *TrustedProxies, logRequests bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		// Handlers behind this one read the ID back with requestID
+		w.Header().Set(requestIDHeader, id)
+		if !logRequests {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		sw := &statusResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		log.Printf("%s %s %d %v %s request_id=%s", r.Method, r.URL.Path, sw.status, time.Since(start).Round(time.Microsecond), clientIP(r, proxies), id)
+	})
+}
+
+// requestID returns the ID assigned to the request a response belongs to,
+// empty outside of requestLogHandler
+func requestID(w http.ResponseWriter) string {
+	return w.Header().Get(requestIDHeader)
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether a request ID sent by a client is safe to log
+// and echo: not too long, letters, digits and a few separators only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// statusResponseWriter records the status of a response for the access log
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusResponseWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusResponseWriter) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+// Flush passes flushes through, the event stream relies on them
+func (s *statusResponseWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		if s.status == 0 {
+			s.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// httpError replies with a plain text error like http.Error, quoting the
+// request ID
+func httpError(w http.ResponseWriter, message string, status int) {
+	if id := requestID(w); id != "" {
+		message += " (request ID " + id + ")"
+	}
+	http.Error(w, message, status)
+}
+
+// errorPayload is the JSON body of an error response without a response type
+// of its own
+func errorPayload(w http.ResponseWriter, code, message string) map[string]interface{} {
+	payload := map[string]interface{}{
+		"success":        false,
+		"schema_version": apiSchemaVersion,
+		"code":           code,
+		"message":        message,
+	}
+	if id := requestID(w); id != "" {
+		payload["request_id"] = id
+	}
+	return payload
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// metaAdminTargets keys the notification targets managed from /admin in the
+// state database
+const metaAdminTargets = "admin_targets"
+
+// AdminTarget is a notification channel added at runtime from /admin
+type AdminTarget struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"` // Channel name in the logs and the delivery log
+	URL       string     `json:"url"`  // Apprise-style notification URL
+	Filter    GameFilter `json:"filter"`
+	Schedule  string     `json:"schedule,omitempty"` // Cron expression with seconds, empty to follow the server's checks
+	Enabled   bool       `json:"enabled"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	NextRun   *time.Time `json:"next_run,omitempty"` // Of scheduled targets, set in responses
+}
+
+// AdminTargets are the notification channels managed from /admin, persisted
+// in the state database. Enabled targets without a schedule are notified with
+// the configured channels, by the scheduled checks and /v1/notify; the others
+// check for games on their own schedule.
+type AdminTargets struct {
+	mu        sync.Mutex
+	targets   []AdminTarget
+	notifiers map[string]Notifier     // Of the enabled targets, by ID
+	entries   map[string]cron.EntryID // Of the scheduled targets, by ID
+
+	scheduler  *cron.Cron
+	fetchGames func(context.Context) ([]Game, error)
+	offerKinds []string        // Default offer kinds, as for the configured channels
+	reserved   map[string]bool // Lowercase IDs of the configured channels
+}
+
+// NewAdminTargets loads the targets from the state database. Scheduled
+// targets are added to scheduler, and fetch the games with fetchGames.
+func NewAdminTargets(scheduler *cron.Cron, fetchGames func(context.Context) ([]Game, error), offerKinds []string, configured []Notifier) *AdminTargets {
+	a := &AdminTargets{
+		notifiers:  map[string]Notifier{},
+		entries:    map[string]cron.EntryID{},
+		scheduler:  scheduler,
+		fetchGames: fetchGames,
+		offerKinds: offerKinds,
+		reserved:   map[string]bool{},
+	}
+	for _, n := range configured {
+		a.reserved[strings.ToLower(channelID(n))] = true
+	}
+	if stateDB == nil {
+		return a
+	}
+
+	value, err := stateDB.Meta(metaAdminTargets)
+	if err != nil {
+		log.Printf("Warning: Error loading notification targets: %v", err)
+		return a
+	}
+	if value == "" {
+		return a
+	}
+	if err := json.Unmarshal([]byte(value), &a.targets); err != nil {
+		log.Printf("Warning: Error parsing notification targets: %v", err)
+		return a
+	}
+	for _, target := range a.targets {
+		if err := a.activate(target); err != nil {
+			log.Printf("Warning: Notification target %s disabled: %v", target.Name, err)
+		}
+	}
+	return a
+}
+
+// Persistent reports whether changes survive a restart
+func (a *AdminTargets) Persistent() bool {
+	return stateDB != nil
+}
+
+// List returns the targets, with the next run of the scheduled ones
+func (a *AdminTargets) List() []AdminTarget {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	targets := slices.Clone(a.targets)
+	for i := range targets {
+		if id, ok := a.entries[targets[i].ID]; ok {
+			next := a.scheduler.Entry(id).Next
+			if !next.IsZero() {
+				targets[i].NextRun = &next
+			}
+		}
+	}
+	return targets
+}
+
+// Notifiers returns the enabled targets without a schedule of their own
+func (a *AdminTargets) Notifiers() []Notifier {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var notifiers []Notifier
+	for _, target := range a.targets {
+		if n, ok := a.notifiers[target.ID]; ok && target.Schedule == "" {
+			notifiers = append(notifiers, n)
+		}
+	}
+	return notifiers
+}
+
+// All returns the notifiers of every enabled target, scheduled or not, to
+// retry their deliveries
+func (a *AdminTargets) All() []Notifier {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var notifiers []Notifier
+	for _, target := range a.targets {
+		if n, ok := a.notifiers[target.ID]; ok {
+			notifiers = append(notifiers, n)
+		}
+	}
+	return notifiers
+}
+
+// Put adds a target without an ID, or replaces the target of its ID
+func (a *AdminTargets) Put(target AdminTarget) (AdminTarget, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	target.Name = strings.TrimSpace(target.Name)
+	target.URL = strings.TrimSpace(target.URL)
+	target.Schedule = strings.TrimSpace(target.Schedule)
+	target.NextRun = nil
+	index := -1
+	if target.ID != "" {
+		index = slices.IndexFunc(a.targets, func(t AdminTarget) bool { return t.ID == target.ID })
+		if index < 0 {
+			return target, errorf(ErrNotFound, "Unknown notification target %q", target.ID)
+		}
+	}
+	if err := a.validate(target); err != nil {
+		return target, withKind(ErrBadParameter, err)
+	}
+
+	if index < 0 {
+		id := make([]byte, 8)
+		rand.Read(id)
+		target.ID = hex.EncodeToString(id)
+	} else {
+		a.deactivate(target.ID)
+	}
+	target.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	if err := a.activate(target); err != nil {
+		return target, withKind(ErrBadParameter, err)
+	}
+
+	targets := slices.Clone(a.targets)
+	if index < 0 {
+		targets = append(targets, target)
+	} else {
+		targets[index] = target
+	}
+	if err := a.save(targets); err != nil {
+		return target, err
+	}
+	a.targets = targets
+	return target, nil
+}
+
+// Delete removes a target
+func (a *AdminTargets) Delete(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	targets := slices.DeleteFunc(slices.Clone(a.targets), func(t AdminTarget) bool { return t.ID == id })
+	if len(targets) == len(a.targets) {
+		return errorf(ErrNotFound, "Unknown notification target %q", id)
+	}
+	if err := a.save(targets); err != nil {
+		return err
+	}
+	a.deactivate(id)
+	a.targets = targets
+	return nil
+}
+
+// Test sends the games matching the filter of a target to it, whether they
+// were announced already or not. Nothing is recorded.
+func (a *AdminTargets) Test(ctx context.Context, id string, games []Game) (int, error) {
+	a.mu.Lock()
+	index := slices.IndexFunc(a.targets, func(t AdminTarget) bool { return t.ID == id })
+	var target AdminTarget
+	if index >= 0 {
+		target = a.targets[index]
+	}
+	a.mu.Unlock()
+	if index < 0 {
+		return 0, errorf(ErrNotFound, "Unknown notification target %q", id)
+	}
+
+	// A notifier of its own, which hasn't seen any game
+	n, err := a.build(target, "")
+	if err != nil {
+		return 0, withKind(ErrBadParameter, err)
+	}
+	matched := target.Filter.Apply(games)
+	if err := notifyChannel(ctx, n, matched); err != nil {
+		return 0, withKind(ErrNotifierFailed, err)
+	}
+	return len(matched), nil
+}
+
+// validate checks a target before it's saved. The caller must hold the lock.
+func (a *AdminTargets) validate(target AdminTarget) error {
+	if target.Name == "" || len(target.Name) > 64 {
+		return fmt.Errorf("the name must have 1 to 64 characters")
+	}
+	if a.reserved[strings.ToLower(target.Name)] {
+		return fmt.Errorf("the name %q is taken by a configured channel", target.Name)
+	}
+	for _, other := range a.targets {
+		if other.ID != target.ID && strings.EqualFold(other.Name, target.Name) {
+			return fmt.Errorf("the name %q is taken by another target", target.Name)
+		}
+	}
+	if err := target.Filter.validate(); err != nil {
+		return fmt.Errorf("invalid filter: %v", err)
+	}
+	if target.Schedule != "" {
+		if _, err := cronParser.Parse(target.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule %q: %v", target.Schedule, err)
+		}
+	}
+	_, err := a.build(target, "")
+	return err
+}
+
+// build creates the notifier of a target, remembering the games it announced
+// under channel
+func (a *AdminTargets) build(target AdminTarget, channel string) (Notifier, error) {
+	// Don't echo the URL, it usually contains credentials
+	scheme, _, _ := strings.Cut(target.URL, "://")
+	n, err := parseNotifyURL(target.URL, NewSeenTracker(channel, ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s:// notification URL: %v", scheme, err)
+	}
+	if filter, ok, err := parseURLFilter(queryOf(target.URL)); err != nil {
+		return nil, fmt.Errorf("invalid %s:// notification URL: %v", scheme, err)
+	} else if ok {
+		n = &FilteredNotifier{Notifier: n, Filter: filter}
+	}
+
+	filter := target.Filter
+	if len(filter.OfferKinds) == 0 {
+		filter.OfferKinds = a.offerKinds
+	}
+	return &namedNotifier{Notifier: &FilteredNotifier{Notifier: n, Filter: filter}, name: target.Name}, nil
+}
+
+// activate sets up the notifier and the schedule of an enabled target. The
+// caller must hold the lock, or be the constructor.
+func (a *AdminTargets) activate(target AdminTarget) error {
+	if !target.Enabled {
+		return nil
+	}
+	n, err := a.build(target, "admin:"+target.ID)
+	if err != nil {
+		return err
+	}
+	if circuitThreshold > 0 {
+		n = &CircuitNotifier{Notifier: n, breaker: NewCircuitBreaker(target.Name)}
+	}
+
+	if target.Schedule != "" {
+		id, err := a.scheduler.AddFunc(target.Schedule, func() {
+			a.runScheduled(n)
+		})
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q: %v", target.Schedule, err)
+		}
+		a.entries[target.ID] = id
+		a.scheduler.Start()
+	}
+	a.notifiers[target.ID] = n
+	return nil
+}
+
+// deactivate removes the notifier and the schedule of a target. The caller
+// must hold the lock.
+func (a *AdminTargets) deactivate(id string) {
+	if entry, ok := a.entries[id]; ok {
+		a.scheduler.Remove(entry)
+		delete(a.entries, id)
+	}
+	delete(a.notifiers, id)
+}
+
+// runScheduled is the scheduled check of a target with its own schedule
+func (a *AdminTargets) runScheduled(n Notifier) {
+	log.Printf("Running scheduled check of %s...", n.Name())
+	games, err := a.fetchGames(context.Background())
+	if err != nil {
+		log.Printf("Error fetching free games for %s: %v", n.Name(), err)
+		return
+	}
+	notifyAll(context.Background(), []Notifier{n}, games)
+}
+
+// save stores targets in the state database, when there's one. The caller
+// must hold the lock.
+func (a *AdminTargets) save(targets []AdminTarget) error {
+	if stateDB == nil {
+		return nil
+	}
+	data, err := json.Marshal(targets)
+	if err != nil {
+		return fmt.Errorf("error marshaling notification targets: %v", err)
+	}
+	if err := stateDB.SetMeta(metaAdminTargets, string(data)); err != nil {
+		return fmt.Errorf("error saving notification targets: %v", err)
+	}
+	return nil
+}
+
+// namedNotifier is a notifier logged and recorded under another name
+type namedNotifier struct {
+	Notifier
+	name string
+}
+
+func (n *namedNotifier) Name() string {
+	return n.name
+}
+
+// AdminTargetsResponse is returned by the notification target endpoints
+type AdminTargetsResponse struct {
+	Success       bool          `json:"success"`
+	SchemaVersion int           `json:"schema_version"`
+	Code          string        `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string        `json:"message,omitempty"`
+	RequestID     string        `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	Persistent    bool          `json:"persistent"`           // Whether changes survive a restart
+	Count         int           `json:"count"`
+	Data          []AdminTarget `json:"data"`
+}
+
+// writeAdminTargets writes targets, or the error
+func writeAdminTargets(w http.ResponseWriter, targets *AdminTargets, data []AdminTarget, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status, code := errorStatus(err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(AdminTargetsResponse{SchemaVersion: apiSchemaVersion, Code: code, RequestID: requestID(w), Message: err.Error()})
+		return
+	}
+	if data == nil {
+		data = []AdminTarget{}
+	}
+	json.NewEncoder(w).Encode(AdminTargetsResponse{
+		Success:       true,
+		SchemaVersion: apiSchemaVersion,
+		Persistent:    targets.Persistent(),
+		Count:         len(data),
+		Data:          data,
+	})
+}
+
+// adminTargetsHandler serves GET and POST /v1/admin/targets, listing and
+// adding notification targets
+func adminTargetsHandler(w http.ResponseWriter, r *http.Request, targets *AdminTargets) {
+	switch r.Method {
+	case http.MethodGet:
+		writeAdminTargets(w, targets, targets.List(), nil)
+	case http.MethodPost:
+		var target AdminTarget
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&target); err != nil {
+			writeAdminTargets(w, targets, nil, errorf(ErrBadParameter, "Invalid notification target: %v", err))
+			return
+		}
+		target.ID = ""
+		target, err := targets.Put(target)
+		if err == nil {
+			log.Printf("Notification target %s added", target.Name)
+			w.WriteHeader(http.StatusCreated)
+		}
+		writeAdminTargets(w, targets, []AdminTarget{target}, err)
+	default:
+		writeAdminTargets(w, targets, nil, errorf(ErrMethodNotAllowed, "Expected GET or POST"))
+	}
+}
+
+// adminTargetHandler serves PUT and DELETE /v1/admin/targets/{id}, and POST
+// /v1/admin/targets/{id}/test sending the current games to a target
+func adminTargetHandler(w http.ResponseWriter, r *http.Request, targets *AdminTargets, fetchGames func(context.Context) ([]Game, error)) {
+	_, rest, _ := strings.Cut(r.URL.Path, "/targets/")
+	id, action, _ := strings.Cut(rest, "/")
+	if id == "" || (action != "" && action != "test") {
+		writeAdminTargets(w, targets, nil, errorf(ErrNotFound, "Unknown path, expected /v1/admin/targets/{id} or /v1/admin/targets/{id}/test"))
+		return
+	}
+
+	switch {
+	case action == "test" && r.Method == http.MethodPost:
+		games, err := fetchGames(r.Context())
+		if err != nil {
+			writeError(w, fmt.Errorf("Error fetching games: %w", err))
+			return
+		}
+		sent, err := targets.Test(r.Context(), id, games)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":        true,
+			"schema_version": apiSchemaVersion,
+			"message":        fmt.Sprintf("Test notification sent with %d games", sent),
+		})
+	case action == "" && r.Method == http.MethodPut:
+		var target AdminTarget
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&target); err != nil {
+			writeAdminTargets(w, targets, nil, errorf(ErrBadParameter, "Invalid notification target: %v", err))
+			return
+		}
+		target.ID = id
+		target, err := targets.Put(target)
+		if err == nil {
+			log.Printf("Notification target %s updated", target.Name)
+		}
+		writeAdminTargets(w, targets, []AdminTarget{target}, err)
+	case action == "" && r.Method == http.MethodDelete:
+		err := targets.Delete(id)
+		if err == nil {
+			log.Printf("Notification target %s deleted", id)
+		}
+		writeAdminTargets(w, targets, nil, err)
+	default:
+		writeAdminTargets(w, targets, nil, errorf(ErrMethodNotAllowed, "Expected PUT or DELETE, or POST to /test"))
+	}
+}
+
+// adminPageHandler serves the admin UI at /admin. The page holds no data, it
+// asks for an API key and calls the admin endpoints with it.
+func adminPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin" && r.URL.Path != "/admin/" {
+		http.NotFound(w, r)
+		return
+	}
+	serveDashboardFile(w, r, "admin.html")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorKind is a class of API errors, answered with its HTTP status and a
+// code clients can match on instead of parsing the message
+type ErrorKind struct {
+	Status int
+	Code   string
+}
+
+func (k *ErrorKind) Error() string {
+	return k.Code
+}
+
+// Kinds of API errors. Errors without a kind are internal errors, answered
+// with 500 and the code "internal".
+var (
+	ErrBadParameter        = &ErrorKind{http.StatusBadRequest, "bad_parameter"}
+	ErrNotFound            = &ErrorKind{http.StatusNotFound, "not_found"}
+	ErrMethodNotAllowed    = &ErrorKind{http.StatusMethodNotAllowed, "method_not_allowed"}
+	ErrConflict            = &ErrorKind{http.StatusConflict, "conflict"}
+	ErrUpstreamUnavailable = &ErrorKind{http.StatusBadGateway, "upstream_unavailable"} // Epic or another store failed
+	ErrNotifierFailed      = &ErrorKind{http.StatusBadGateway, "notifier_failed"}      // A notification channel failed
+	ErrNotConfigured       = &ErrorKind{http.StatusServiceUnavailable, "not_configured"}
+)
+
+// Codes of the errors answered without an error value
+const (
+	errorCodeInternal     = "internal"
+	errorCodeUnauthorized = "unauthorized"
+	errorCodeRateLimited  = "rate_limited"
+)
+
+// kindError is an error of a kind, keeping the message of the error
+type kindError struct {
+	kind *ErrorKind
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// withKind marks err as an error of kind, nil stays nil
+func withKind(kind *ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// errorf formats an error of kind
+func errorf(kind *ErrorKind, format string, args ...interface{}) error {
+	return withKind(kind, fmt.Errorf(format, args...))
+}
+
+// writeError writes the JSON error response of err, for endpoints without a
+// response type of their own
+func writeError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorPayload(w, code, err.Error()))
+}
+
+// errorStatus returns the HTTP status and code err is answered with
+func errorStatus(err error) (int, string) {
+	var kind *ErrorKind
+	if errors.As(err, &kind) {
+		return kind.Status, kind.Code
+	}
+	return http.StatusInternalServerError, errorCodeInternal
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// APIKeys protects the endpoints that post notifications (and other admin
+// endpoints). Keys are stored hashed so comparisons take constant time
+// regardless of their length.
+type APIKeys struct {
+	hashes [][sha256.Size]byte
+}
+
+// LoadAPIKeys reads keys from a comma-separated list and, if set, a file with
+// one key per line (lines starting with # are ignored)
+func LoadAPIKeys(list, path string) (*APIKeys, error) {
+	keys := &APIKeys{}
+	for _, key := range strings.Split(list, ",") {
+		keys.add(key)
+	}
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening API keys file: %v", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); !strings.HasPrefix(line, "#") {
+				keys.add(line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("error reading API keys file: %v", err)
+		}
+	}
+	return keys, nil
+}
+
+func (k *APIKeys) add(key string) {
+	if key = strings.TrimSpace(key); key != "" {
+		k.hashes = append(k.hashes, sha256.Sum256([]byte(key)))
+	}
+}
+
+// Enabled reports whether any keys are configured. Without keys every
+// request is allowed, as before authentication was added.
+func (k *APIKeys) Enabled() bool {
+	return k != nil && len(k.hashes) > 0
+}
+
+// Allowed reports whether the request carries a valid key in the X-API-Key
+// header or as a bearer token
+func (k *APIKeys) Allowed(r *http.Request) bool {
+	if !k.Enabled() {
+		return true
+	}
+
+	provided := r.Header.Get("X-API-Key")
+	if provided == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			provided = strings.TrimSpace(token)
+		}
+	}
+	return k.allowedKey(provided)
+}
+
+// allowedKey reports whether provided is one of the keys
+func (k *APIKeys) allowedKey(provided string) bool {
+	if !k.Enabled() {
+		return true
+	}
+	if provided == "" {
+		return false
+	}
+
+	hash := sha256.Sum256([]byte(provided))
+	match := 0
+	for _, key := range k.hashes {
+		match |= subtle.ConstantTimeCompare(hash[:], key[:])
+	}
+	return match == 1
+}
+
+// Require wraps a handler so it's only reachable with a valid key
+func (k *APIKeys) Require(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !k.Allowed(r) {
+			writeUnauthorized(w)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// writeUnauthorized rejects a request without a valid API key
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="epic-games-api"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(errorPayload(w, errorCodeUnauthorized, "A valid API key is required, send it in the X-API-Key header or as a bearer token"))
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseNotifyURLs builds notifiers from a list of Apprise-style notification
+// URLs separated by spaces or commas, e.g.
+//
+//	discord://{webhook_id}/{webhook_token}[?mention={mention}&mention_upcoming={mention}&thread_id={id}&forum=yes]
+//	tgram://{bot_token}/{chat_id}[/{chat_id}...]
+//	mailto://{user}:{password}@{smtp_host}[:{port}]?to={address}&from={address}
+//	mastodons://{access_token}@{host}
+//	bluesky://{handle}:{app_password}@[{pds_host}]
+//	twitter://{consumer_key}/{consumer_secret}/{access_token}/{access_secret}
+//	jsons://{host}/{path}?+{Header}={value}
+//
+// Any URL can restrict the games it receives with the status, exclude_dlc,
+// min_price and genres parameters, see parseURLFilter. The games announced
+// through each URL are remembered by its tracker in trackers, keyed by
+// notifyURLChannel, which is also the ID of its channel.
+func parseNotifyURLs(value string, trackers map[string]*SeenTracker) ([]Notifier, error) {
+	var notifiers []Notifier
+	for _, field := range splitNotifyURLs(value) {
+		// Don't echo the URL, it usually contains credentials
+		scheme, _, _ := strings.Cut(field, "://")
+		tracker, ok := trackers[notifyURLChannel(field)]
+		if !ok {
+			tracker = NewSeenTracker("", "")
+		}
+		notifier, err := parseNotifyURL(field, tracker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s:// notification URL: %v", scheme, err)
+		}
+		notifier = &channelNotifier{Notifier: notifier, id: notifyURLChannel(field)}
+		filter, ok, err := parseURLFilter(queryOf(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s:// notification URL: %v", scheme, err)
+		}
+		if ok {
+			notifier = &FilteredNotifier{Notifier: notifier, Filter: filter}
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
+
+// splitNotifyURLs splits a list of notification URLs separated by spaces or
+// commas
+func splitNotifyURLs(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
+
+// notifyURLChannel returns the channel the games announced through a
+// notification URL are remembered under: its scheme and a hash of the URL,
+// which can't be stored as is since it usually contains credentials
+func notifyURLChannel(rawURL string) string {
+	scheme, _, _ := strings.Cut(rawURL, "://")
+	sum := sha256.Sum256([]byte(rawURL))
+	return strings.ToLower(scheme) + "-" + hex.EncodeToString(sum[:6])
+}
+
+// parseNotifyURL builds the notifier for a single Apprise-style URL. The games
+// announced are remembered by tracker.
+func parseNotifyURL(rawURL string, tracker *SeenTracker) (Notifier, error) {
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return nil, fmt.Errorf("missing scheme")
+	}
+
+	// Path-style URLs whose tokens may contain characters url.Parse rejects
+	// in the host part (e.g. the colon in Telegram bot tokens)
+	rest, _, _ = strings.Cut(rest, "?")
+	parts := splitPath(rest)
+
+	switch strings.ToLower(scheme) {
+	case "discord":
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("expected discord://{webhook_id}/{webhook_token}")
+		}
+		webhookURL := fmt.Sprintf("https://discord.com/api/webhooks/%s/%s", parts[0], parts[1])
+		query := queryOf(rawURL)
+		options := DiscordOptions{
+			MentionFree:     query.Get("mention"),
+			MentionUpcoming: query.Get("mention_upcoming"),
+			ThreadID:        query.Get("thread_id"),
+			ForumPosts:      parseBool(query.Get("forum")),
+		}
+		if err := options.validate(); err != nil {
+			return nil, err
+		}
+		return NewDiscordNotifier(webhookURL, options, tracker), nil
+
+	case "tgram":
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("expected tgram://{bot_token}/{chat_id}")
+		}
+		return NewTelegramNotifier(parts[0], parts[1:], tracker), nil
+
+	case "twitter":
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("expected twitter://{consumer_key}/{consumer_secret}/{access_token}/{access_secret}")
+		}
+		credentials := TwitterCredentials{
+			ConsumerKey:       parts[0],
+			ConsumerSecret:    parts[1],
+			AccessToken:       parts[2],
+			AccessTokenSecret: parts[3],
+		}
+		return NewTwitterNotifier(credentials, true, 3, tracker), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing URL")
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "mailto", "mailtos":
+		return parseMailtoURL(u, tracker)
+
+	case "mastodon", "mastodons":
+		if u.User == nil || u.Host == "" {
+			return nil, fmt.Errorf("expected %s://{access_token}@{host}", u.Scheme)
+		}
+		protocol := "http"
+		if u.Scheme == "mastodons" {
+			protocol = "https"
+		}
+		visibility := u.Query().Get("visibility")
+		return NewMastodonNotifier(protocol+"://"+u.Host, u.User.Username(), visibility, tracker), nil
+
+	case "bluesky", "bsky":
+		if u.User == nil {
+			return nil, fmt.Errorf("expected bluesky://{handle}:{app_password}@{host}")
+		}
+		password, _ := u.User.Password()
+		service := ""
+		if u.Host != "" {
+			service = "https://" + u.Host
+		}
+		return NewBlueskyNotifier(service, u.User.Username(), password, tracker), nil
+
+	case "json", "jsons":
+		protocol := "http"
+		if u.Scheme == "jsons" {
+			protocol = "https"
+		}
+		target := &url.URL{Scheme: protocol, User: u.User, Host: u.Host, Path: u.Path}
+
+		// Apprise passes extra headers as +Name=value query parameters
+		headers := map[string]string{}
+		method := "POST"
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			value, _ = url.QueryUnescape(value)
+			if strings.HasPrefix(key, "+") {
+				headers[key[1:]] = value
+			} else if key == "method" {
+				method = value
+			}
+		}
+		headersJSON, _ := json.Marshal(headers)
+		return NewWebhookNotifier(target.String(), method, "application/json", string(headersJSON), "", tracker)
+	}
+
+	return nil, fmt.Errorf("unsupported scheme")
+}
+
+// parseMailtoURL builds an email notifier remembering the games it sent with
+// tracker. The host is used as the SMTP server unless overridden with ?smtp=;
+// the recipient defaults to the sender.
+func parseMailtoURL(u *url.URL, tracker *SeenTracker) (Notifier, error) {
+	query := u.Query()
+
+	e := &EmailNotifier{
+		Host:        u.Hostname(),
+		Port:        u.Port(),
+		ImplicitTLS: u.Scheme == "mailtos",
+		tracker:     tracker,
+	}
+	if u.User != nil {
+		e.Username = u.User.Username()
+		e.Password, _ = u.User.Password()
+	}
+	if smtpHost := query.Get("smtp"); smtpHost != "" {
+		e.Host = smtpHost
+	}
+	if e.Host == "" {
+		return nil, fmt.Errorf("expected %s://{user}:{password}@{smtp_host}", u.Scheme)
+	}
+	if e.Port == "" {
+		e.Port = "587"
+		if e.ImplicitTLS {
+			e.Port = "465"
+		}
+	}
+
+	e.From = query.Get("from")
+	if e.From == "" {
+		if strings.Contains(e.Username, "@") {
+			e.From = e.Username
+		} else {
+			e.From = e.Username + "@" + u.Hostname()
+		}
+	}
+
+	for _, to := range strings.Split(query.Get("to"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			e.To = append(e.To, to)
+		}
+	}
+	if len(e.To) == 0 {
+		e.To = []string{e.From}
+	}
+
+	return e, nil
+}
+
+// queryOf returns the query parameters of a raw URL that may not be parseable
+// by url.Parse as a whole
+func queryOf(rawURL string) url.Values {
+	_, rawQuery, _ := strings.Cut(rawURL, "?")
+	query, _ := url.ParseQuery(rawQuery)
+	return query
+}
+
+// parseBool reads an Apprise-style boolean parameter such as yes, true or 1
+func parseBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "yes", "y", "true", "on", "1":
+		return true
+	}
+	return false
+}
+
+// splitPath splits a URL path into its non-empty segments
+func splitPath(p string) []string {
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"epic-games-api/pkg/epicgames"
+	"epic-games-api/storage"
+)
+
+// rawArchive keeps the catalog payload of every detected giveaway, set by
+// -archive-raw. It's the state database when there's one, otherwise memory.
+var rawArchive storage.Store
+
+// rawRewriteInterval is how often an unchanged payload is written again,
+// which keeps its fetch time current for the retention policy
+const rawRewriteInterval = 24 * time.Hour
+
+// archivedPayload is the last payload written for an offer
+type archivedPayload struct {
+	sum       [sha256.Size]byte
+	writtenAt time.Time
+}
+
+var (
+	rawArchivedMu sync.Mutex
+	rawArchived   = map[string]archivedPayload{} // By offer ID
+)
+
+// newGraphQLResponse wraps a decoded catalog, keeping its elements as Epic
+// returned them in body when raw payloads are archived
+func newGraphQLResponse(catalog *epicgames.CatalogResponse, body []byte) (*GraphQLResponse, error) {
+	resp := &GraphQLResponse{CatalogResponse: *catalog}
+	if rawArchive == nil {
+		return resp, nil
+	}
+
+	var raw struct {
+		Data struct {
+			Catalog struct {
+				SearchStore struct {
+					Elements []json.RawMessage `json:"elements"`
+				} `json:"searchStore"`
+			} `json:"Catalog"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	// The elements are matched by ID, add-ons may have been dropped
+	resp.rawElements = make(map[string]json.RawMessage, len(raw.Data.Catalog.SearchStore.Elements))
+	for _, element := range raw.Data.Catalog.SearchStore.Elements {
+		var offer struct {
+			Namespace string `json:"namespace"`
+			ID        string `json:"id"`
+		}
+		if json.Unmarshal(element, &offer) == nil {
+			resp.rawElements[gameID(offer.Namespace, offer.ID)] = element
+		}
+	}
+	return resp, nil
+}
+
+// archiveRawOffer stores the compressed payload of a giveaway, skipping the
+// write when it didn't change lately
+func archiveRawOffer(offerID string, payload json.RawMessage) {
+	if rawArchive == nil || offerID == "" || len(payload) == 0 {
+		return
+	}
+
+	sum := sha256.Sum256(payload)
+	rawArchivedMu.Lock()
+	defer rawArchivedMu.Unlock()
+	if last, ok := rawArchived[offerID]; ok && last.sum == sum && time.Since(last.writtenAt) < rawRewriteInterval {
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(payload)
+	if err := zw.Close(); err != nil {
+		log.Printf("Warning: Error compressing raw offer %s: %v", offerID, err)
+		return
+	}
+	if err := rawArchive.PutRawOffer(storage.RawOffer{OfferID: offerID, Payload: buf.Bytes(), FetchedAt: time.Now()}); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	rawArchived[offerID] = archivedPayload{sum: sum, writtenAt: time.Now()}
+}
+
+// rawOfferHandler serves GET /v1/admin/raw/{offerID}, the archived payload
+// of a giveaway as Epic returned it
+func rawOfferHandler(w http.ResponseWriter, r *http.Request) {
+	_, offerID, _ := strings.Cut(r.URL.Path, "/admin/raw/")
+	// Game IDs are namespace:offerID, accept those too
+	if i := strings.LastIndex(offerID, ":"); i >= 0 {
+		offerID = offerID[i+1:]
+	}
+	if offerID == "" {
+		httpError(w, "Missing offer ID, expected /v1/admin/raw/{offerID}", http.StatusNotFound)
+		return
+	}
+	if rawArchive == nil {
+		httpError(w, "Raw payloads aren't archived, set ARCHIVE_RAW=true", http.StatusNotFound)
+		return
+	}
+
+	offer, err := rawArchive.RawOffer(offerID)
+	if err != nil {
+		httpError(w, fmt.Sprintf("Error reading raw offer: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if offer == nil {
+		httpError(w, fmt.Sprintf("No archived payload for offer %s", offerID), http.StatusNotFound)
+		return
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(offer.Payload))
+	if err != nil {
+		httpError(w, fmt.Sprintf("Error decompressing raw offer: %v", err), http.StatusInternalServerError)
+		return
+	}
+	payload, err := io.ReadAll(zr)
+	if err != nil {
+		httpError(w, fmt.Sprintf("Error decompressing raw offer: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", offer.FetchedAt.UTC().Format(http.TimeFormat))
+	w.Write(payload)
+}
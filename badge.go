@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// badgeMaxAge is how long caches such as GitHub's image proxy keep a badge
+const badgeMaxAge = 300
+
+// Badge colors, as used by shields.io
+const (
+	badgeColorGreen = "#4c1"
+	badgeColorGrey  = "#9f9f9f"
+	badgeColorRed   = "#e05d44"
+)
+
+// badgeHandler serves GET /badge.svg, a shields.io-style badge with the number
+// of currently free games. The label query parameter replaces "Epic Free Games".
+func badgeHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	label := r.URL.Query().Get("label")
+	if label == "" {
+		label = "Epic Free Games"
+	}
+
+	value, color := "", badgeColorGreen
+	games, err := opts.freeGames(r.Context(), false)
+	if err != nil {
+		log.Printf("Warning: Error fetching games for the badge: %v", err)
+		value, color = "unavailable", badgeColorRed
+	} else {
+		free := 0
+		for _, game := range games {
+			if game.Status == "free" {
+				free++
+			}
+		}
+		value = strconv.Itoa(free)
+		if free == 0 {
+			color = badgeColorGrey
+		}
+	}
+
+	svg := renderBadge(label, value, color)
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err != nil {
+		// Don't let caches hold on to the error for long
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", badgeMaxAge))
+	}
+	writeConditional(w, r, []byte(svg), []byte(svg))
+}
+
+// badgeTextWidth estimates the width in pixels of text in 11px Verdana
+func badgeTextWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case r == 'i' || r == 'l' || r == 'j' || r == '!' || r == '.' || r == ',' || r == ':' || r == '|' || r == '\'':
+			width += 3.5
+		case r == ' ' || r == 'f' || r == 't' || r == 'r' || r == 'I':
+			width += 4.5
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 10
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(width + 0.5)
+}
+
+// renderBadge draws a flat two-part badge with label on the left and value on
+// the right
+func renderBadge(label, value, color string) string {
+	labelWidth := badgeTextWidth(label) + 10
+	valueWidth := badgeTextWidth(value) + 10
+	width := labelWidth + valueWidth
+	label, value = html.EscapeString(label), html.EscapeString(value)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[7]d" y="14">%[4]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[8]d" y="14">%[5]s</text>
+</g>
+</svg>
+`, width, labelWidth, valueWidth, label, value, color, labelWidth/2, labelWidth+valueWidth/2)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Bluesky rejects blobs larger than this for external embed thumbnails
+const blueskyMaxThumbSize = 1000000
+
+// BlueskyNotifier posts new free games to Bluesky via the AT Protocol
+type BlueskyNotifier struct {
+	Service    string // PDS URL, e.g. https://bsky.social
+	Identifier string // Handle or DID
+	Password   string // App password
+	tracker    *SeenTracker
+	client     *http.Client
+}
+
+// blueskySession is the result of com.atproto.server.createSession
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	DID       string `json:"did"`
+}
+
+// NewBlueskyNotifier creates a Bluesky notifier. Games already posted are
+// remembered by tracker.
+func NewBlueskyNotifier(service, identifier, password string, tracker *SeenTracker) *BlueskyNotifier {
+	if service == "" {
+		service = "https://bsky.social"
+	}
+	return &BlueskyNotifier{
+		Service:    strings.TrimRight(service, "/"),
+		Identifier: identifier,
+		Password:   password,
+		tracker:    tracker,
+		client:     newHTTPClient(30 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (b *BlueskyNotifier) Name() string {
+	return "bluesky"
+}
+
+// pendingGames returns the games not announced yet
+func (b *BlueskyNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return b.tracker.Unseen(games), b.tracker
+}
+
+// Notify posts each game that hasn't been posted before
+func (b *BlueskyNotifier) Notify(ctx context.Context, games []Game) error {
+	unseen := b.tracker.Unseen(games)
+	if len(unseen) == 0 {
+		return nil
+	}
+
+	session, err := b.createSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, game := range unseen {
+		if err := b.createPost(ctx, session, game); err != nil {
+			return fmt.Errorf("error posting %s: %v", game.Title, err)
+		}
+		if err := b.tracker.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+// xrpc calls an XRPC procedure and decodes the JSON result into out
+func (b *BlueskyNotifier) xrpc(ctx context.Context, method, token, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", b.Service+"/xrpc/"+method, body)
+	if err != nil {
+		return fmt.Errorf("error creating %s request: %v", method, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending %s request: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned status %d: %s", method, resp.StatusCode, string(bodyBytes))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("error decoding %s response: %v", method, err)
+		}
+	}
+	return nil
+}
+
+func (b *BlueskyNotifier) createSession(ctx context.Context) (*blueskySession, error) {
+	payload, err := json.Marshal(map[string]string{
+		"identifier": b.Identifier,
+		"password":   b.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling session request: %v", err)
+	}
+
+	var session blueskySession
+	if err := b.xrpc(ctx, "com.atproto.server.createSession", "", "application/json", bytes.NewBuffer(payload), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// uploadThumb uploads the game's image as a blob for the link card
+func (b *BlueskyNotifier) uploadThumb(ctx context.Context, session *blueskySession, imageURL string) (json.RawMessage, error) {
+	// Epic's CDN can resize images, keeping thumbnails below the blob limit
+	thumbURL, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing image URL: %v", err)
+	}
+	query := thumbURL.Query()
+	query.Set("resize", "1")
+	query.Set("w", "640")
+	thumbURL.RawQuery = query.Encode()
+
+	imgResp, err := httpGet(ctx, b.client, thumbURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("error downloading image: %v", err)
+	}
+	defer imgResp.Body.Close()
+
+	if imgResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading image: status %d", imgResp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(imgResp.Body, blueskyMaxThumbSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image: %v", err)
+	}
+	if len(data) > blueskyMaxThumbSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", blueskyMaxThumbSize)
+	}
+
+	contentType := imgResp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	var result struct {
+		Blob json.RawMessage `json:"blob"`
+	}
+	if err := b.xrpc(ctx, "com.atproto.repo.uploadBlob", session.AccessJwt, contentType, bytes.NewReader(data), &result); err != nil {
+		return nil, err
+	}
+	return result.Blob, nil
+}
+
+func (b *BlueskyNotifier) createPost(ctx context.Context, session *blueskySession, game Game) error {
+	text, err := messageTemplates.RenderGame(b.Name(), game)
+	if err != nil {
+		return err
+	}
+
+	external := map[string]interface{}{
+		"uri":         game.URL,
+		"title":       game.Title,
+		"description": truncateRunes(game.Description, 300),
+	}
+	if game.ImageURL != "" {
+		thumb, err := b.uploadThumb(ctx, session, game.ImageURL)
+		if err != nil {
+			log.Printf("Warning: Error uploading Bluesky thumbnail for %s: %v", game.Title, err)
+		} else {
+			external["thumb"] = thumb
+		}
+	}
+
+	record := map[string]interface{}{
+		"$type":     "app.bsky.feed.post",
+		"text":      truncateRunes(text, 300),
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"langs":     []string{"en"},
+		"embed": map[string]interface{}{
+			"$type":    "app.bsky.embed.external",
+			"external": external,
+		},
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"repo":       session.DID,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling post: %v", err)
+	}
+
+	return b.xrpc(ctx, "com.atproto.repo.createRecord", session.AccessJwt, "application/json", bytes.NewBuffer(payload), nil)
+}
+
+// truncateRunes shortens s to at most max characters, adding an ellipsis when cut
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
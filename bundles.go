@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"epic-games-api/pkg/epicgames"
+)
+
+// bundleItemsQuery lists the items of an offer, which for a bundle are the
+// games and add-ons it contains
+const bundleItemsQuery = `
+query bundleItems($namespace: String!, $id: String!, $locale: String) {
+  Catalog {
+    catalogOffer(namespace: $namespace, id: $id, locale: $locale) {
+      items {
+        id
+        title
+      }
+    }
+  }
+}`
+
+var (
+	bundleContentsMu    sync.Mutex
+	bundleContentsCache = map[string][]string{} // By locale and game ID, contents don't change
+)
+
+// resolveBundles sets the titles included in the Epic bundles among games.
+// Bundles that fail to resolve are logged and only listed by their name.
+func resolveBundles(ctx context.Context, games []Game, locale string) {
+	for i := range games {
+		if games[i].OfferType != "BUNDLE" || games[i].namespace == "" || games[i].offerID == "" {
+			continue
+		}
+		contents, err := lookupBundleContents(ctx, games[i].namespace, games[i].offerID, locale)
+		if err != nil {
+			log.Printf("Warning: Error resolving bundle %s: %v", games[i].Title, err)
+			continue
+		}
+
+		for _, title := range contents {
+			if !strings.EqualFold(title, games[i].Title) {
+				games[i].BundleContents = append(games[i].BundleContents, title)
+			}
+		}
+	}
+}
+
+// lookupBundleContents returns the titles of the items of a bundle, fetched
+// once per bundle
+func lookupBundleContents(ctx context.Context, namespace, offerID, locale string) ([]string, error) {
+	bundleContentsMu.Lock()
+	defer bundleContentsMu.Unlock()
+
+	key := locale + "|" + gameID(namespace, offerID)
+	if contents, ok := bundleContentsCache[key]; ok {
+		return contents, nil
+	}
+
+	contents, err := fetchBundleContents(ctx, namespace, offerID, locale)
+	if err != nil {
+		return nil, err
+	}
+	bundleContentsCache[key] = contents
+	return contents, nil
+}
+
+// fetchBundleContents fetches the distinct titles of the items of a bundle
+func fetchBundleContents(ctx context.Context, namespace, offerID, locale string) ([]string, error) {
+	requestBody, err := json.Marshal(GraphQLRequest{
+		Query: bundleItemsQuery,
+		Variables: map[string]interface{}{
+			"namespace": namespace,
+			"id":        offerID,
+			"locale":    locale,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://graphql.epicgames.com/graphql", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var offerResp struct {
+		Data struct {
+			Catalog struct {
+				CatalogOffer struct {
+					Items []struct {
+						ID    string `json:"id"`
+						Title string `json:"title"`
+					} `json:"items"`
+				} `json:"catalogOffer"`
+			} `json:"Catalog"`
+		} `json:"data"`
+		Errors []GraphQLError `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&offerResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	items := offerResp.Data.Catalog.CatalogOffer.Items
+	if len(offerResp.Errors) > 0 && len(items) == 0 {
+		return nil, fmt.Errorf("error from GraphQL API: %s", epicgames.JoinErrors(offerResp.Errors))
+	}
+
+	contents := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		title := strings.TrimSpace(item.Title)
+		if title == "" || seen[strings.ToLower(title)] {
+			continue
+		}
+		seen[strings.ToLower(title)] = true
+		contents = append(contents, title)
+	}
+	return contents, nil
+}
+
+// bundleContentsText lists what a bundle includes for notifications, e.g.
+// "Includes: Game A, Game B", empty for other offers
+func bundleContentsText(game Game) string {
+	if len(game.BundleContents) == 0 {
+		return ""
+	}
+	return "Includes: " + strings.Join(game.BundleContents, ", ")
+}
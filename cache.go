@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// freeGamesTTL is how often the background refresher fetches the free games
+// again, so bursts of API requests don't each hit Epic. Set by -cache-ttl,
+// zero disables the cache and every request fetches.
+var freeGamesTTL = 15 * time.Minute
+
+// freeGamesIdle is how long the refresher keeps fetching a query nobody asks
+// for anymore
+const freeGamesIdle = 24 * time.Hour
+
+// freeGamesRequest identifies a free games fetch
+type freeGamesRequest struct {
+	countryCode     string
+	locale          string
+	timezone        string
+	includeUpcoming bool
+	withAddons      bool
+}
+
+// freeGamesResult is a snapshot of the free games of a request
+type freeGamesResult struct {
+	games     []Game
+	warnings  []string
+	source    string
+	fetchedAt time.Time
+	// refreshErr is why the last fetch failed, set when the snapshot is stale
+	refreshErr error
+}
+
+// freeGamesEntry holds the latest good snapshot of a request. fetching holds
+// a token while fetching so concurrent misses wait for a single request to
+// Epic, or for their context to end. mu only guards the fields and is never
+// held across a fetch.
+type freeGamesEntry struct {
+	fetching chan struct{}
+
+	mu            sync.Mutex
+	result        *freeGamesResult
+	lastErr       error // Error of the last fetch, nil once one succeeds
+	lastRequested time.Time
+}
+
+var (
+	freeGamesCacheMu sync.Mutex
+	freeGamesCache   = map[freeGamesRequest]*freeGamesEntry{}
+)
+
+// freeGamesMaxEntries bounds the cached requests, each refetched by the
+// refresher. The least recently requested one is dropped to make room.
+const freeGamesMaxEntries = 100
+
+// newFreeGamesEntry creates an entry without a snapshot
+func newFreeGamesEntry() *freeGamesEntry {
+	return &freeGamesEntry{fetching: make(chan struct{}, 1)}
+}
+
+// lock waits for the fetch token, false when ctx ends first
+func (e *freeGamesEntry) lock(ctx context.Context) bool {
+	select {
+	case e.fetching <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// unlock releases the fetch token
+func (e *freeGamesEntry) unlock() {
+	<-e.fetching
+}
+
+// cachedFreeGames returns the latest snapshot of the free games of a request.
+// The games are only fetched when there's no snapshot yet, the cache is
+// disabled or refresh is set; when that fetch fails the previous snapshot is
+// served with refreshErr set. hit reports whether no fetch was needed. The
+// games are a copy the caller may modify. Waiting for and fetching the games
+// stop when ctx ends.
+func cachedFreeGames(ctx context.Context, request freeGamesRequest, refresh bool) (result *freeGamesResult, hit bool, err error) {
+	freeGamesCacheMu.Lock()
+	entry := freeGamesCache[request]
+	if entry == nil {
+		if len(freeGamesCache) >= freeGamesMaxEntries {
+			evictFreeGamesEntry()
+		}
+		entry = newFreeGamesEntry()
+		freeGamesCache[request] = entry
+	}
+	freeGamesCacheMu.Unlock()
+
+	requested := time.Now()
+	entry.mu.Lock()
+	entry.lastRequested = requested
+	snapshot, lastErr := entry.result, entry.lastErr
+	entry.mu.Unlock()
+
+	if snapshot != nil && !refresh && freeGamesTTL > 0 {
+		result = snapshot.copy()
+		result.refreshErr = lastErr
+		return result, true, nil
+	}
+
+	if !entry.lock(ctx) {
+		return nil, false, fmt.Errorf("error waiting for the free games: %v", ctx.Err())
+	}
+	defer entry.unlock()
+
+	// Another request may have fetched while this one waited
+	entry.mu.Lock()
+	snapshot = entry.result
+	entry.mu.Unlock()
+	if snapshot != nil && snapshot.fetchedAt.After(requested) {
+		return snapshot.copy(), false, nil
+	}
+
+	if err := entry.fetch(ctx, request); err != nil {
+		if snapshot == nil {
+			return nil, false, err
+		}
+		log.Printf("Warning: Error fetching free games, serving games from %s: %v", snapshot.fetchedAt.Format(time.RFC3339), err)
+		stale := snapshot.copy()
+		stale.refreshErr = err
+		return stale, false, nil
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return entry.result.copy(), false, nil
+}
+
+// evictFreeGamesEntry drops the least recently requested entry,
+// freeGamesCacheMu must be held
+func evictFreeGamesEntry() {
+	var oldest freeGamesRequest
+	var oldestRequested time.Time
+	found := false
+	for request, entry := range freeGamesCache {
+		entry.mu.Lock()
+		requested := entry.lastRequested
+		entry.mu.Unlock()
+		if !found || requested.Before(oldestRequested) {
+			oldest, oldestRequested, found = request, requested, true
+		}
+	}
+	if found {
+		delete(freeGamesCache, oldest)
+	}
+}
+
+// fetch replaces the snapshot with freshly fetched games, the fetch token
+// must be held. A failed fetch keeps the previous snapshot, and one canceled
+// by its caller isn't reported as Epic's failure.
+func (e *freeGamesEntry) fetch(ctx context.Context, request freeGamesRequest) error {
+	games, warnings, source, err := loadFreeGames(ctx, request.countryCode, request.locale, request.includeUpcoming, request.withAddons, request.timezone)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			e.lastErr = err
+		}
+		return err
+	}
+	e.lastErr = nil
+	e.result = &freeGamesResult{games: games, warnings: warnings, source: source, fetchedAt: time.Now()}
+	return nil
+}
+
+// copy returns a copy of the snapshot with the relative times brought up to
+// date
+func (r *freeGamesResult) copy() *freeGamesResult {
+	copied := *r
+	copied.games = slices.Clone(r.games)
+	refreshRelativeTimes(copied.games, time.Now())
+	return &copied
+}
+
+// stale reports whether the snapshot is served because fetching failed
+func (r *freeGamesResult) stale() bool {
+	return r.refreshErr != nil
+}
+
+// age is how long ago the games were fetched
+func (r *freeGamesResult) age() time.Duration {
+	return time.Since(r.fetchedAt)
+}
+
+// runFreeGamesRefresher fetches the free games of every recent request every
+// freeGamesTTL in the background, so requests are answered from a snapshot
+// without waiting on Epic. Requests idle for freeGamesIdle are dropped.
+func runFreeGamesRefresher() {
+	ticker := time.NewTicker(freeGamesTTL)
+	defer ticker.Stop()
+
+	refresherHeartbeat.Store(time.Now().UnixNano())
+	for range ticker.C {
+		freeGamesCacheMu.Lock()
+		var requests []freeGamesRequest
+		var entries []*freeGamesEntry
+		for request, entry := range freeGamesCache {
+			entry.mu.Lock()
+			idle := time.Since(entry.lastRequested) > freeGamesIdle
+			entry.mu.Unlock()
+			if idle {
+				delete(freeGamesCache, request)
+				continue
+			}
+			requests = append(requests, request)
+			entries = append(entries, entry)
+		}
+		freeGamesCacheMu.Unlock()
+
+		for i, entry := range entries {
+			entry.lock(context.Background())
+			err := entry.fetch(context.Background(), requests[i])
+			entry.unlock()
+			if err != nil {
+				log.Printf("Warning: Error refreshing free games for %s/%s, keeping the previous ones: %v", requests[i].countryCode, requests[i].locale, err)
+			}
+		}
+		refresherHeartbeat.Store(time.Now().UnixNano())
+	}
+}
+
+// refreshRelativeTimes recomputes when the games start and end relative to
+// now, which go stale while the games are cached
+func refreshRelativeTimes(games []Game, now time.Time) {
+	for i := range games {
+		if games[i].DatePrecision != "exact" {
+			continue
+		}
+		games[i].StartsIn, games[i].EndsIn = "", ""
+		if games[i].startTime.After(now) {
+			games[i].StartsIn = humanizeDuration(games[i].startTime.Sub(now))
+		}
+		if games[i].endTime.After(now) {
+			games[i].EndsIn = humanizeDuration(games[i].endTime.Sub(now))
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Views of the giveaway calendar
+const (
+	calendarMonth = "month"
+	calendarWeek  = "week"
+)
+
+// Statuses of a giveaway on the calendar, at the time of the request
+const (
+	calendarLive     = "live"
+	calendarUpcoming = "upcoming"
+	calendarEnded    = "ended"
+)
+
+// CalendarGiveaway is a giveaway window plotted on the calendar
+type CalendarGiveaway struct {
+	ID            string    `json:"id,omitempty"`
+	Title         string    `json:"title"`
+	URL           string    `json:"url,omitempty"`
+	ImageURL      string    `json:"image_url,omitempty"`
+	Publisher     string    `json:"publisher,omitempty"`
+	Status        string    `json:"status"` // "live", "upcoming" or "ended"
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	DatePrecision string    `json:"date_precision"`
+}
+
+// CalendarResponse is returned by the calendar endpoint
+type CalendarResponse struct {
+	Success       bool               `json:"success"`
+	SchemaVersion int                `json:"schema_version"`
+	Code          string             `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string             `json:"message,omitempty"`
+	RequestID     string             `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	View          string             `json:"view,omitempty"`       // "month" or "week"
+	Country       string             `json:"country,omitempty"`
+	Timezone      string             `json:"timezone,omitempty"`
+	Start         string             `json:"start,omitempty"` // First day shown, 2006-01-02
+	End           string             `json:"end,omitempty"`   // Last day shown
+	Previous      string             `json:"previous,omitempty"`
+	Next          string             `json:"next,omitempty"`
+	Count         int                `json:"count"`
+	Data          []CalendarGiveaway `json:"data"`
+
+	// The period of the view, start included and end excluded, at midnight
+	// in the timezone
+	from, to time.Time
+	day      time.Time // The requested day
+	today    time.Time
+}
+
+// calendarRequest reads the parameters of the calendar endpoints, the view
+// defaulting to the current month
+func calendarRequest(r *http.Request, opts ServerOptions, now time.Time) (*CalendarResponse, error) {
+	query := r.URL.Query()
+	calendar := &CalendarResponse{
+		View:     strings.ToLower(query.Get("view")),
+		Country:  strings.ToUpper(query.Get("country")),
+		Timezone: query.Get("timezone"),
+	}
+	if calendar.View == "" {
+		calendar.View = calendarMonth
+	}
+	if calendar.View != calendarMonth && calendar.View != calendarWeek {
+		return nil, errorf(ErrBadParameter, "Invalid view %q, expected month or week", calendar.View)
+	}
+	if calendar.Country == "" {
+		calendar.Country = opts.Country
+	}
+	if calendar.Timezone == "" {
+		calendar.Timezone = opts.Timezone
+	}
+	location, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		return nil, errorf(ErrBadParameter, "Invalid timezone %q, expected an IANA name like Europe/Berlin", calendar.Timezone)
+	}
+
+	now = now.In(location)
+	calendar.today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	calendar.day = calendar.today
+	if value := query.Get("date"); value != "" {
+		if calendar.day, err = time.ParseInLocation("2006-01-02", value, location); err != nil {
+			return nil, errorf(ErrBadParameter, "Invalid date %q, expected YYYY-MM-DD", value)
+		}
+	}
+
+	// Weeks start on Monday, a month shows the weeks it overlaps
+	calendar.from = startOfWeek(calendar.day)
+	calendar.to = calendar.from.AddDate(0, 0, 7)
+	previous, next := calendar.day.AddDate(0, 0, -7), calendar.day.AddDate(0, 0, 7)
+	if calendar.View == calendarMonth {
+		first := time.Date(calendar.day.Year(), calendar.day.Month(), 1, 0, 0, 0, 0, location)
+		calendar.from = startOfWeek(first)
+		calendar.to = startOfWeek(first.AddDate(0, 1, -1)).AddDate(0, 0, 7)
+		previous, next = first.AddDate(0, -1, 0), first.AddDate(0, 1, 0)
+	}
+	calendar.Start = calendar.from.Format("2006-01-02")
+	calendar.End = calendar.to.AddDate(0, 0, -1).Format("2006-01-02")
+	calendar.Previous = previous.Format("2006-01-02")
+	calendar.Next = next.Format("2006-01-02")
+	return calendar, nil
+}
+
+// startOfWeek returns the Monday of the week of day
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return time.Date(day.Year(), day.Month(), day.Day()-offset, 0, 0, 0, 0, day.Location())
+}
+
+// plotGiveaways fills the calendar with the giveaways of the history and the
+// current and upcoming games whose windows overlap its period, ordered by
+// start
+func (c *CalendarResponse) plotGiveaways(entries []HistoryEntry, games []Game, now time.Time) {
+	c.Data = []CalendarGiveaway{}
+	seen := map[string]bool{}
+	add := func(giveaway CalendarGiveaway) {
+		if giveaway.StartDate.IsZero() || giveaway.EndDate.IsZero() {
+			return
+		}
+		if !giveaway.StartDate.Before(c.to) || !giveaway.EndDate.After(c.from) {
+			return
+		}
+		key := giveaway.ID
+		if key == "" {
+			key = giveaway.Title
+		}
+		key += "|" + giveaway.StartDate.UTC().Format(time.RFC3339)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		location := c.from.Location()
+		giveaway.StartDate = giveaway.StartDate.In(location)
+		giveaway.EndDate = giveaway.EndDate.In(location)
+		switch {
+		case now.Before(giveaway.StartDate):
+			giveaway.Status = calendarUpcoming
+		case now.Before(giveaway.EndDate):
+			giveaway.Status = calendarLive
+		default:
+			giveaway.Status = calendarEnded
+		}
+		c.Data = append(c.Data, giveaway)
+	}
+
+	// The fetched games come first, they're more up to date than the history
+	for _, game := range games {
+		add(CalendarGiveaway{
+			ID:            game.ID,
+			Title:         game.Title,
+			URL:           game.URL,
+			ImageURL:      game.ImageURL,
+			Publisher:     game.Publisher,
+			StartDate:     game.startTime,
+			EndDate:       game.endTime,
+			DatePrecision: game.DatePrecision,
+		})
+	}
+	for _, entry := range entries {
+		add(CalendarGiveaway{
+			ID:            entry.ID,
+			Title:         entry.Title,
+			URL:           entry.URL,
+			ImageURL:      entry.ImageURL,
+			Publisher:     entry.Publisher,
+			StartDate:     entry.StartDate,
+			EndDate:       entry.EndDate,
+			DatePrecision: entry.DatePrecision,
+		})
+	}
+
+	sort.SliceStable(c.Data, func(i, j int) bool {
+		if !c.Data[i].StartDate.Equal(c.Data[j].StartDate) {
+			return c.Data[i].StartDate.Before(c.Data[j].StartDate)
+		}
+		return c.Data[i].Title < c.Data[j].Title
+	})
+	c.Count = len(c.Data)
+}
+
+// buildGiveawayCalendar reads the request and plots the archived giveaways of
+// its country, along with the current and upcoming games of its store.
+// Failing to fetch the games still plots the history, with a message.
+func buildGiveawayCalendar(r *http.Request, opts ServerOptions) (*CalendarResponse, error) {
+	now := time.Now()
+	calendar, err := calendarRequest(r, opts, now)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Country, opts.Timezone = calendar.Country, calendar.Timezone
+	games, err := opts.freeGames(r.Context(), true)
+	if err != nil {
+		log.Printf("Warning: Error fetching games for the calendar: %v", err)
+		calendar.Message = fmt.Sprintf("Showing past giveaways only, fetching the current games failed: %v", err)
+	}
+	var entries []HistoryEntry
+	if opts.History != nil {
+		entries = opts.History.Query(0, calendar.Country, "")
+	}
+
+	calendar.plotGiveaways(entries, games, now)
+	calendar.Success = true
+	calendar.SchemaVersion = apiSchemaVersion
+	return calendar, nil
+}
+
+// calendarJSONHandler serves GET /v1/calendar?view=month&date=2026-10-01,
+// the giveaway windows of a month or week
+func calendarJSONHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	calendar, err := buildGiveawayCalendar(r, opts)
+	if err != nil {
+		status, code := errorStatus(err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(CalendarResponse{SchemaVersion: apiSchemaVersion, Code: code, RequestID: requestID(w), Message: err.Error()})
+		return
+	}
+
+	jsonData, _ := json.MarshalIndent(calendar, "", "  ")
+	writeConditional(w, r, jsonData, jsonData)
+}
+
+// calendarTemplate renders the calendar page
+var calendarTemplate = template.Must(template.ParseFS(dashboardFiles, "web/calendar.html"))
+
+// calendarPage is the data of the calendar page
+type calendarPage struct {
+	*CalendarResponse
+	Heading  string
+	Weekdays []string
+	Weeks    []calendarWeekRow
+	Links    map[string]string // URLs of the other periods and views
+}
+
+// calendarWeekRow is a week of the calendar page, with a bar per giveaway
+// running that week
+type calendarWeekRow struct {
+	Days []calendarDay
+	Bars []calendarBar
+}
+
+type calendarDay struct {
+	Date    time.Time
+	Column  int  // 1 (Monday) to 7
+	Outside bool // Not in the month shown
+	Today   bool
+}
+
+// calendarBar is the part of a giveaway window within a week, spanning
+// columns 1 (Monday) to 7
+type calendarBar struct {
+	CalendarGiveaway
+	Column    int
+	Span      int
+	Continued bool // Started in an earlier week
+	Continues bool // Ends in a later week
+}
+
+// calendarPageHandler serves the calendar as an HTML page at /calendar, with
+// the parameters of /v1/calendar
+func calendarPageHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	calendar, err := buildGiveawayCalendar(r, opts)
+	if err != nil {
+		status, _ := errorStatus(err)
+		httpError(w, err.Error(), status)
+		return
+	}
+
+	page := calendarPage{
+		CalendarResponse: calendar,
+		Weekdays:         []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"},
+		Links:            map[string]string{},
+	}
+	if calendar.View == calendarMonth {
+		page.Heading = calendar.day.Format("January 2006")
+	} else {
+		page.Heading = "Week of " + calendar.from.Format("January 2, 2006")
+	}
+	link := func(view, date string) string {
+		query := url.Values{"view": {view}, "timezone": {calendar.Timezone}, "country": {calendar.Country}}
+		if date != "" {
+			query.Set("date", date)
+		}
+		return "/calendar?" + query.Encode()
+	}
+	day := calendar.day.Format("2006-01-02")
+	page.Links["previous"] = link(calendar.View, calendar.Previous)
+	page.Links["next"] = link(calendar.View, calendar.Next)
+	page.Links["today"] = link(calendar.View, "")
+	page.Links["month"] = link(calendarMonth, day)
+	page.Links["week"] = link(calendarWeek, day)
+	page.Links["json"] = strings.Replace(link(calendar.View, day), "/calendar?", "/v1/calendar?", 1)
+
+	for start := calendar.from; start.Before(calendar.to); start = start.AddDate(0, 0, 7) {
+		page.Weeks = append(page.Weeks, calendarWeekOf(calendar, start))
+	}
+
+	var body bytes.Buffer
+	if err := calendarTemplate.Execute(&body, page); err != nil {
+		httpError(w, fmt.Sprintf("Error rendering the calendar: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(body.Bytes())
+}
+
+// calendarWeekOf lays out the week starting on start
+func calendarWeekOf(calendar *CalendarResponse, start time.Time) calendarWeekRow {
+	var week calendarWeekRow
+	end := start.AddDate(0, 0, 7)
+	for i := range 7 {
+		date := start.AddDate(0, 0, i)
+		week.Days = append(week.Days, calendarDay{
+			Date:    date,
+			Column:  i + 1,
+			Outside: calendar.View == calendarMonth && date.Month() != calendar.day.Month(),
+			Today:   date.Equal(calendar.today),
+		})
+	}
+
+	for _, giveaway := range calendar.Data {
+		if !giveaway.StartDate.Before(end) || !giveaway.EndDate.After(start) {
+			continue
+		}
+		// A window ending at midnight doesn't reach into that day
+		last := giveaway.EndDate.Add(-time.Nanosecond)
+		first, lastColumn := 0, 6
+		if giveaway.StartDate.After(start) {
+			first = daysBetween(start, giveaway.StartDate)
+		}
+		if last.Before(end) {
+			lastColumn = daysBetween(start, last)
+		}
+		week.Bars = append(week.Bars, calendarBar{
+			CalendarGiveaway: giveaway,
+			Column:           first + 1,
+			Span:             lastColumn - first + 1,
+			Continued:        giveaway.StartDate.Before(start),
+			Continues:        !last.Before(end),
+		})
+	}
+	return week
+}
+
+// daysBetween counts the calendar days from the midnight start to t
+func daysBetween(start, t time.Time) int {
+	days := 0
+	for next := start.AddDate(0, 0, 1); !next.After(t); next = next.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
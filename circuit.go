@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Circuit breaker settings, set by -circuit-threshold and -circuit-cooldown
+var (
+	circuitThreshold = 5
+	circuitCooldown  = 5 * time.Minute
+)
+
+// Breakers of the Epic endpoints, so a rate limiting or failing Epic isn't
+// hammered by every refresh and API request
+var (
+	promotionsBreaker = NewCircuitBreaker("promotions")
+	graphQLBreaker    = NewCircuitBreaker("graphql")
+)
+
+// CircuitBreaker stops calling a failing service. After circuitThreshold
+// failures in a row the circuit opens and calls fail right away. Once
+// circuitCooldown passed, a single call goes through as a recovery probe:
+// its success closes the circuit, its failure opens it for another cooldown.
+type CircuitBreaker struct {
+	name string
+
+	mu       sync.Mutex
+	failures int       // Failures in a row
+	openedAt time.Time // Zero while closed
+	probing  bool      // A recovery probe is in flight
+}
+
+// NewCircuitBreaker creates a closed circuit breaker, named in logs and errors
+func NewCircuitBreaker(name string) *CircuitBreaker {
+	return &CircuitBreaker{name: name}
+}
+
+// Allow returns an error while the circuit is open. Once the cooldown passed
+// it lets one call through as the recovery probe, which must be followed by
+// Record.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < circuitCooldown {
+		return fmt.Errorf("circuit of %s is open after %d failures, retrying after %s", b.name, b.failures, b.openedAt.Add(circuitCooldown).Format(time.RFC3339))
+	}
+	b.probing = true
+	log.Printf("Probing %s after its circuit opened", b.name)
+	return nil
+}
+
+// Record counts the outcome of an allowed call, opening or closing the circuit
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.probing
+	b.probing = false
+
+	if err == nil {
+		if !b.openedAt.IsZero() {
+			log.Printf("Circuit of %s closed, it recovered", b.name)
+		}
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	b.failures++
+	if circuitThreshold > 0 && (probe || b.failures >= circuitThreshold) {
+		if b.openedAt.IsZero() {
+			log.Printf("Warning: Circuit of %s opened after %d failures: %v", b.name, b.failures, err)
+		}
+		b.openedAt = time.Now()
+	}
+}
+
+// Abort ends an allowed call that was canceled by its caller, without
+// counting it
+func (b *CircuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Open reports whether calls are currently failing fast
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+// epicDegraded reports whether the circuits of every Epic endpoint of the
+// data source are open, so the API can only serve cached games
+func epicDegraded() bool {
+	switch dataSource {
+	case sourcePromotions:
+		return promotionsBreaker.Open()
+	case sourceGraphQL:
+		return graphQLBreaker.Open()
+	}
+	return promotionsBreaker.Open() && graphQLBreaker.Open()
+}
+
+// CircuitNotifier stops sending to a channel that keeps failing. Skipped
+// notifications fail like any other, so the delivery retries pick them up
+// once the channel recovered.
+type CircuitNotifier struct {
+	Notifier
+	breaker *CircuitBreaker
+}
+
+// Notify announces the games unless the channel's circuit is open
+func (c *CircuitNotifier) Notify(ctx context.Context, games []Game) error {
+	if err := c.breaker.Allow(); err != nil {
+		return err
+	}
+	err := c.Notifier.Notify(ctx, games)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// The caller gave up, that says nothing about the channel
+		c.breaker.Abort()
+	} else {
+		c.breaker.Record(err)
+	}
+	return err
+}
+
+// applyCircuitBreakers wraps every notifier with a circuit breaker of its
+// own, unless circuitThreshold is 0
+func applyCircuitBreakers(notifiers []Notifier) []Notifier {
+	if circuitThreshold <= 0 {
+		return notifiers
+	}
+	wrapped := make([]Notifier, 0, len(notifiers))
+	for _, n := range notifiers {
+		wrapped = append(wrapped, &CircuitNotifier{Notifier: n, breaker: NewCircuitBreaker(channelID(n))})
+	}
+	return wrapped
+}
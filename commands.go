@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// programName is the name of the binary in completions and the man page
+const programName = "epic-games-api"
+
+// Commands of the CLI, serve runs when none is given
+const (
+	commandServe      = "serve"
+	commandCheck      = "check"
+	commandNotify     = "notify"
+	commandHistory    = "history"
+	commandValidate   = "validate"
+	commandExport     = "export"
+	commandImport     = "import"
+	commandCompletion = "completion"
+	commandMan        = "man"
+)
+
+// cliCommand describes a command for the usage, completions and man page
+type cliCommand struct {
+	Name    string
+	Args    string // Positional arguments, e.g. "[file]"
+	Summary string
+	Flags   func(*flag.FlagSet) // Defines the command's flags, nil without any
+}
+
+// cliCommands are the commands in the order they're listed
+var cliCommands = []cliCommand{
+	{commandServe, "", "run the API server, the scheduled jobs and the notifiers (default)", nil},
+	{commandCheck, "", "fetch the free games, print them and exit", func(flags *flag.FlagSet) { checkFlags(flags) }},
+	{commandNotify, "", "fetch the free games, send them to the configured channels once and exit", nil},
+	{commandHistory, "", "print the archived giveaways", func(flags *flag.FlagSet) { historyFlags(flags) }},
+	{commandValidate, "", "check the configuration and exit", func(flags *flag.FlagSet) { validateFlags(flags) }},
+	{commandExport, "[file]", "write the persisted state to file, or to standard output", nil},
+	{commandImport, "file", "merge a state snapshot into the persisted state", nil},
+	{commandCompletion, "bash|zsh|fish", "print the shell completion script", nil},
+	{commandMan, "", "print the man page", nil},
+}
+
+// commandFlagSet returns the flags of a command
+func commandFlagSet(command cliCommand) *flag.FlagSet {
+	flags := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	if command.Flags != nil {
+		command.Flags(flags)
+	}
+	return flags
+}
+
+// usage prints the commands and the global flags, which go before the
+// command
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [command flags]\n\nCommands:\n", os.Args[0])
+	for _, command := range cliCommands {
+		fmt.Fprintf(out, "  %-24s %s\n", strings.TrimSpace(command.Name+" "+command.Args), command.Summary)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// parseCommand returns the command and its arguments from what's left after
+// the global flags
+func parseCommand(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return commandServe, nil, nil
+	}
+	names := make([]string, 0, len(cliCommands))
+	for _, command := range cliCommands {
+		if command.Name == args[0] {
+			return args[0], args[1:], nil
+		}
+		names = append(names, command.Name)
+	}
+	return "", nil, fmt.Errorf("unknown command %q, expected one of %s", args[0], strings.Join(names, ", "))
+}
+
+// Exit statuses of the check command, for scripts
+const (
+	checkExitFound  = 0
+	checkExitError  = 1
+	checkExitNoGame = 2
+)
+
+// Output formats of the check command
+const (
+	checkFormatTable    = "table"
+	checkFormatJSON     = "json"
+	checkFormatMarkdown = "markdown"
+)
+
+// runCheckCommand fetches the free games, prints them to w and returns the
+// exit status: checkExitFound when there are games, checkExitNoGame when
+// there are none
+func runCheckCommand(args []string, w io.Writer, opts ServerOptions) (int, error) {
+	flags := flag.NewFlagSet(commandCheck, flag.ContinueOnError)
+	upcoming, format := checkFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return checkExitFound, nil
+		}
+		return checkExitError, err
+	}
+	if *format != checkFormatTable && *format != checkFormatJSON && *format != checkFormatMarkdown {
+		return checkExitError, fmt.Errorf("unknown format %q, expected table, json or markdown", *format)
+	}
+
+	games, err := opts.latestGames(context.Background(), *upcoming)
+	if err != nil {
+		return checkExitError, fmt.Errorf("error fetching free games: %v", err)
+	}
+	if err := printGames(w, games, *format); err != nil {
+		return checkExitError, err
+	}
+	if len(games) == 0 {
+		return checkExitNoGame, nil
+	}
+	return checkExitFound, nil
+}
+
+// checkFlags defines the flags of the check command
+func checkFlags(flags *flag.FlagSet) (upcoming *bool, format *string) {
+	upcoming = flags.Bool("upcoming", true, "Include upcoming free games")
+	format = flags.String("format", checkFormatTable, "Output format: table, json or markdown")
+	return upcoming, format
+}
+
+// runNotifyCommand fetches the free games once, archives them and sends them
+// to the notifiers
+func runNotifyCommand(args []string, opts ServerOptions) error {
+	flags := flag.NewFlagSet(commandNotify, flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(opts.Notifiers) == 0 {
+		return fmt.Errorf("no notification channels configured")
+	}
+
+	startedAt := time.Now()
+	games, err := opts.latestGames(context.Background(), true)
+	if err != nil {
+		recordRun(startedAt, nil, err)
+		return fmt.Errorf("error fetching free games: %v", err)
+	}
+	opts.History.Record(games, opts.Country)
+	err = notifyAll(context.Background(), opts.Notifiers, games)
+	recordRun(startedAt, games, err)
+	return err
+}
+
+// runHistoryCommand prints the archived giveaways matching the flags to w
+func runHistoryCommand(args []string, w io.Writer, history *HistoryStore) error {
+	flags := flag.NewFlagSet(commandHistory, flag.ContinueOnError)
+	year, country, title := historyFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tCOUNTRY\tTITLE\tPRICE")
+	for _, entry := range history.Query(*year, *country, *title) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.StartDate.Format("2006-01-02"), entry.EndDate.Format("2006-01-02"), entry.Country, entry.Title, entry.OriginalPrice)
+	}
+	return tw.Flush()
+}
+
+// historyFlags defines the flags of the history command
+func historyFlags(flags *flag.FlagSet) (year *int, country, title *string) {
+	year = flags.Int("year", 0, "Only giveaways that started in this year")
+	country = flags.String("country", "", "Only giveaways seen in this country")
+	title = flags.String("title", "", "Only games whose title contains this")
+	return year, country, title
+}
+
+// printGames writes the games to w in a check format
+func printGames(w io.Writer, games []Game, format string) error {
+	switch format {
+	case checkFormatJSON:
+		if games == nil {
+			games = []Game{}
+		}
+		data, err := json.MarshalIndent(APIResponse{
+			Success:       true,
+			SchemaVersion: apiSchemaVersion,
+			Count:         len(games),
+			Total:         len(games),
+			Data:          games,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling games: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+
+	case checkFormatMarkdown:
+		if len(games) == 0 {
+			_, err := fmt.Fprintln(w, "No free games right now.")
+			return err
+		}
+		// Pipes would end the cell early
+		cell := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+		fmt.Fprintln(w, "| Game | Status | Start | End | Store |")
+		fmt.Fprintln(w, "| ---- | ------ | ----- | --- | ----- |")
+		for _, game := range games {
+			_, err := fmt.Fprintf(w, "| [%s](%s) | %s | %s | %s | %s |\n", cell(game.Title), game.URL, game.Status, cell(game.StartDate), cell(game.EndDate), storeName(game))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tTITLE\tSTART\tEND\tURL")
+	for _, game := range games {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", game.Status, strings.TrimSpace(game.Title), game.StartDate, game.EndDate, game.URL)
+	}
+	return tw.Flush()
+}
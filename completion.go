@@ -0,0 +1,210 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagNames returns the names of the flags, as typed with a single dash
+func flagNames(flags *flag.FlagSet) []string {
+	var names []string
+	flags.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return names
+}
+
+// commandNames returns the names of the commands
+func commandNames() []string {
+	names := make([]string, 0, len(cliCommands))
+	for _, command := range cliCommands {
+		names = append(names, command.Name)
+	}
+	return names
+}
+
+// runCompletionCommand prints the completion script of a shell
+func runCompletionCommand(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: completion bash|zsh|fish")
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(w)
+	case "zsh":
+		writeZshCompletion(w)
+	case "fish":
+		writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unknown shell %q, expected bash, zsh or fish", args[0])
+	}
+	return nil
+}
+
+// completionValues returns the fixed values a command completes besides its
+// flags, and whether it completes file names
+func completionValues(command string) ([]string, bool) {
+	switch command {
+	case commandCompletion:
+		return []string{"bash", "zsh", "fish"}, false
+	case commandExport, commandImport:
+		return nil, true
+	}
+	return nil, false
+}
+
+// writeBashCompletion writes a completion function for bash, loaded with
+// source <(epic-games-api completion bash)
+func writeBashCompletion(w io.Writer) {
+	fn := "_" + strings.ReplaceAll(programName, "-", "_")
+	fmt.Fprintf(w, "# bash completion for %s\n", programName)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\" command=\"\" word\n")
+	fmt.Fprintf(w, "    for word in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+	fmt.Fprintf(w, "        case \"$word\" in\n")
+	fmt.Fprintf(w, "            %s) command=\"$word\"; break ;;\n", strings.Join(commandNames(), "|"))
+	fmt.Fprintf(w, "        esac\n")
+	fmt.Fprintf(w, "    done\n")
+	fmt.Fprintf(w, "    case \"$command\" in\n")
+	fmt.Fprintf(w, "        \"\") COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", strings.Join(append(commandNames(), flagNames(flag.CommandLine)...), " "))
+	for _, command := range cliCommands {
+		values, files := completionValues(command.Name)
+		words := append(flagNames(commandFlagSet(command)), values...)
+		switch {
+		case files:
+			fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n", command.Name)
+		case len(words) > 0:
+			fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", command.Name, strings.Join(words, " "))
+		}
+	}
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F %s %s\n", fn, programName)
+}
+
+// writeZshCompletion writes a completion function for zsh, installed as
+// _epic-games-api in $fpath or loaded with source
+func writeZshCompletion(w io.Writer) {
+	fn := "_" + strings.ReplaceAll(programName, "-", "_")
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'" }
+
+	fmt.Fprintf(w, "#compdef %s\n\n", programName)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "  local -a commands\n")
+	fmt.Fprintf(w, "  commands=(\n")
+	for _, command := range cliCommands {
+		fmt.Fprintf(w, "    %s\n", quote(command.Name+":"+command.Summary))
+	}
+	fmt.Fprintf(w, "  )\n")
+	fmt.Fprintf(w, "  local command word\n")
+	fmt.Fprintf(w, "  for word in ${words[2,CURRENT-1]}; do\n")
+	fmt.Fprintf(w, "    case $word in\n")
+	fmt.Fprintf(w, "      (%s) command=$word; break ;;\n", strings.Join(commandNames(), "|"))
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "  done\n")
+	fmt.Fprintf(w, "  case $command in\n")
+	fmt.Fprintf(w, "    ('')\n")
+	fmt.Fprintf(w, "      _describe 'command' commands\n")
+	fmt.Fprintf(w, "      compadd -- %s ;;\n", strings.Join(flagNames(flag.CommandLine), " "))
+	for _, command := range cliCommands {
+		values, files := completionValues(command.Name)
+		words := append(flagNames(commandFlagSet(command)), values...)
+		switch {
+		case files:
+			fmt.Fprintf(w, "    (%s) _files ;;\n", command.Name)
+		case len(words) > 0:
+			fmt.Fprintf(w, "    (%s) compadd -- %s ;;\n", command.Name, strings.Join(words, " "))
+		}
+	}
+	fmt.Fprintf(w, "  esac\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n", fn)
+	fmt.Fprintf(w, "  %s \"$@\"\n", fn)
+	fmt.Fprintf(w, "else\n")
+	fmt.Fprintf(w, "  compdef %s %s\n", fn, programName)
+	fmt.Fprintf(w, "fi\n")
+}
+
+// writeFishCompletion writes the completions for fish, installed in
+// ~/.config/fish/completions/epic-games-api.fish
+func writeFishCompletion(w io.Writer) {
+	quote := func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+	}
+	complete := func(condition, args string) {
+		fmt.Fprintf(w, "complete -c %s -n %s %s\n", programName, quote(condition), args)
+	}
+
+	fmt.Fprintf(w, "# fish completion for %s\n", programName)
+	fmt.Fprintf(w, "complete -c %s -f\n", programName)
+	for _, command := range cliCommands {
+		complete("__fish_use_subcommand", fmt.Sprintf("-a %s -d %s", command.Name, quote(command.Summary)))
+	}
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		complete("__fish_use_subcommand", fmt.Sprintf("-o %s -d %s", f.Name, quote(f.Usage)))
+	})
+	for _, command := range cliCommands {
+		condition := "__fish_seen_subcommand_from " + command.Name
+		commandFlagSet(command).VisitAll(func(f *flag.Flag) {
+			complete(condition, fmt.Sprintf("-o %s -d %s", f.Name, quote(f.Usage)))
+		})
+		values, files := completionValues(command.Name)
+		if files {
+			complete(condition, "-F")
+		}
+		if len(values) > 0 {
+			complete(condition, "-xa "+quote(strings.Join(values, " ")))
+		}
+	}
+}
+
+// writeManPage writes the man page in roff
+func writeManPage(w io.Writer) {
+	// Hyphens are escaped, roff would print them as dashes
+	escape := strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace
+	// Defaults aren't listed, they come from the environment and may be
+	// secrets
+	writeFlags := func(flags *flag.FlagSet) {
+		flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, ".TP\n.B \\-%s\n", escape(f.Name))
+			usage := escape(f.Usage)
+			if strings.HasPrefix(usage, ".") || strings.HasPrefix(usage, "'") {
+				usage = `\&` + usage
+			}
+			fmt.Fprintln(w, usage)
+		})
+	}
+
+	fmt.Fprintf(w, ".TH %s 1\n", strings.ToUpper(escape(programName)))
+	fmt.Fprintf(w, ".SH NAME\n%s \\- Epic Games Store free games API, notifier and CLI\n", escape(programName))
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n[\\fIflags\\fR] [\\fIcommand\\fR] [\\fIcommand flags\\fR]\n", escape(programName))
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, "Serves the free games of the Epic Games Store and other stores over HTTP and gRPC, and announces new giveaways on the configured notification channels.")
+	fmt.Fprintln(w, "Without a command the server runs. The other commands run once and exit.")
+	fmt.Fprintln(w, "Every flag defaults to its environment variable, see the README.")
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, command := range cliCommands {
+		fmt.Fprintf(w, ".TP\n.B %s", escape(command.Name))
+		if command.Args != "" {
+			fmt.Fprintf(w, " \\fI%s\\fR", escape(command.Args))
+		}
+		fmt.Fprintf(w, "\n%s\n", escape(command.Summary))
+	}
+	fmt.Fprintln(w, ".SH OPTIONS")
+	fmt.Fprintln(w, "Global flags go before the command.")
+	writeFlags(flag.CommandLine)
+	for _, command := range cliCommands {
+		flags := commandFlagSet(command)
+		if len(flagNames(flags)) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, ".SS %s\n", escape(command.Name))
+		writeFlags(flags)
+	}
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+	fmt.Fprintln(w, ".B check")
+	fmt.Fprintf(w, "exits with %d when it found games, %d when there are none and %d on errors.\n", checkExitFound, checkExitNoGame, checkExitError)
+	fmt.Fprintln(w, "The other commands exit with 0 on success and 1 on errors.")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressibleTypes are the content types worth compressing
+var compressibleTypes = []string{"application/json", "text/html", "text/calendar", "image/svg+xml", "text/csv", "application/xml", "text/javascript", "application/javascript", "text/css"}
+
+// compressHandler compresses JSON, XML, CSV, HTML, SVG and calendar responses of at least minSize
+// bytes with gzip or deflate, depending on the client's Accept-Encoding.
+// A negative minSize disables compression.
+func compressHandler(next http.Handler, minSize int) http.Handler {
+	if minSize < 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Caches must tell compressed and plain responses apart
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+		bw.finish(encoding, minSize)
+	})
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip. Encodings with q=0 are refused.
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] || accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// bufferedResponseWriter holds the response until the handler is done, so the
+// decision to compress can take its size into account
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool // Flushed, everything is passed through uncompressed
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.streaming {
+		return
+	}
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.ResponseWriter.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+// Flush gives up on compression for streamed responses such as Server-Sent
+// Events, which would otherwise be held back until the handler returns
+func (b *bufferedResponseWriter) Flush() {
+	if !b.streaming {
+		b.streaming = true
+		b.ResponseWriter.WriteHeader(b.status)
+		b.ResponseWriter.Write(b.body.Bytes())
+		b.body.Reset()
+	}
+	if flusher, ok := b.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish writes the buffered response, compressed if worthwhile
+func (b *bufferedResponseWriter) finish(encoding string, minSize int) {
+	if b.streaming {
+		return
+	}
+	header := b.ResponseWriter.Header()
+	compressible := false
+	contentType := header.Get("Content-Type")
+	if contentType == "" && b.body.Len() > 0 {
+		contentType = http.DetectContentType(b.body.Bytes())
+	}
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			compressible = true
+			break
+		}
+	}
+	if !compressible || header.Get("Content-Encoding") != "" || b.body.Len() < minSize || b.body.Len() == 0 {
+		b.ResponseWriter.WriteHeader(b.status)
+		b.ResponseWriter.Write(b.body.Bytes())
+		return
+	}
+
+	var compressed bytes.Buffer
+	var zw io.WriteCloser
+	if encoding == "gzip" {
+		zw = gzip.NewWriter(&compressed)
+	} else {
+		zw = zlib.NewWriter(&compressed) // HTTP "deflate" is zlib-wrapped
+	}
+	zw.Write(b.body.Bytes())
+	zw.Close()
+
+	header.Set("Content-Encoding", encoding)
+	header.Del("Content-Length")
+	// Compressed bodies differ from the uncompressed ones, mark strong ETags weak
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	b.ResponseWriter.Write(compressed.Bytes())
+}
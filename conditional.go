@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contentVersions remembers when each ETag was first served, which is used as
+// its Last-Modified time
+type contentVersions struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// contentVersionsLimit bounds the number of remembered ETags, the oldest
+// are forgotten once it's reached
+const contentVersionsLimit = 1000
+
+var servedVersions = &contentVersions{seen: make(map[string]time.Time)}
+
+// lastModified returns when etag was first served
+func (c *contentVersions) lastModified(etag string) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.seen[etag]; ok {
+		return t
+	}
+	if len(c.seen) >= contentVersionsLimit {
+		for key, t := range c.seen {
+			if time.Since(t) > time.Hour {
+				delete(c.seen, key)
+			}
+		}
+		if len(c.seen) >= contentVersionsLimit {
+			c.seen = make(map[string]time.Time)
+		}
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	c.seen[etag] = now
+	return now
+}
+
+// withoutRelativeTimes blanks the fields that change every minute, so they
+// don't change the ETag of otherwise identical content
+func withoutRelativeTimes(games []Game) []Game {
+	stable := make([]Game, len(games))
+	for i, game := range games {
+		game.StartsIn, game.EndsIn = "", ""
+		stable[i] = game
+	}
+	return stable
+}
+
+// writeConditional writes a response body with ETag and Last-Modified headers,
+// answering 304 Not Modified when the client already has it. The ETag is
+// computed from version, the content without minute-by-minute changes, and is
+// weak as the body may still differ in relative times.
+func writeConditional(w http.ResponseWriter, r *http.Request, body, version []byte) {
+	sum := sha256.Sum256(append([]byte(r.URL.RawQuery+"\n"), version...))
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	modified := servedVersions.lastModified(etag)
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified")
+
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when no ETag was sent
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.After(since)
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// psPlusGamesListURL is the games list behind the PS Plus monthly games page
+// of playstation.com
+const psPlusGamesListURL = "https://www.playstation.com/bin/imagic/gameslist"
+
+// Game Pass catalog APIs: a sigl lists product IDs, the display catalog
+// describes the products
+const (
+	gamePassSiglURL      = "https://catalog.gamepass.com/sigls/v2"
+	gamePassRecentSiglID = "eab7757c-ff70-45af-bfa6-79d3cfb2bf81" // Recently added console games
+	xboxDisplayCatalog   = "https://displaycatalog.mp.microsoft.com/v7.0/products"
+)
+
+var (
+	// includePSPlus adds the PS Plus monthly games to every fetch, set by -ps-plus
+	includePSPlus bool
+	// includeGamePass adds the games recently added to Xbox Game Pass to every
+	// fetch, set by -xbox-game-pass
+	includeGamePass bool
+)
+
+// psPlusGamesList is the part of the PS Plus games list the service uses
+type psPlusGamesList []struct {
+	Games []struct {
+		ConceptID string   `json:"conceptId"`
+		Name      string   `json:"name"`
+		ImageURL  string   `json:"imageUrl"`
+		Device    []string `json:"device"` // e.g. "PS4", "PS5"
+	} `json:"games"`
+}
+
+// fetchPSPlusMonthlyGames returns the games PS Plus members can add to their
+// library this month. They stay playable while subscribed.
+func fetchPSPlusMonthlyGames(ctx context.Context, locale string) ([]Game, error) {
+	query := url.Values{
+		"locale":       {strings.ToLower(locale)},
+		"categoryList": {"plus-monthly-games-list"},
+	}
+	var list psPlusGamesList
+	if err := getJSON(ctx, psPlusGamesListURL+"?"+query.Encode(), &list); err != nil {
+		return nil, err
+	}
+
+	var games []Game
+	for _, category := range list {
+		for _, item := range category.Games {
+			games = append(games, Game{
+				ID:            gameID(storePSPlus, item.ConceptID),
+				Title:         item.Name,
+				ImageURL:      item.ImageURL,
+				URL:           "https://store.playstation.com/" + strings.ToLower(locale) + "/concept/" + item.ConceptID,
+				Status:        "free",
+				StartDate:     "Unknown",
+				EndDate:       "Unknown",
+				DatePrecision: "unknown",
+				OfferType:     "BASE_GAME",
+				OfferKind:     offerKindSubscription,
+				Store:         storePSPlus,
+				Platforms:     item.Device,
+				namespace:     storePSPlus,
+				offerID:       item.ConceptID,
+			})
+		}
+	}
+	return games, nil
+}
+
+// gamePassProducts is the part of a display catalog response the service uses
+type gamePassProducts struct {
+	Products []struct {
+		ProductID           string `json:"ProductId"`
+		LocalizedProperties []struct {
+			ProductTitle     string `json:"ProductTitle"`
+			ShortDescription string `json:"ShortDescription"`
+			PublisherName    string `json:"PublisherName"`
+			Images           []struct {
+				ImagePurpose string `json:"ImagePurpose"`
+				URI          string `json:"Uri"`
+			} `json:"Images"`
+		} `json:"LocalizedProperties"`
+		Properties struct {
+			Category string `json:"Category"`
+		} `json:"Properties"`
+	} `json:"Products"`
+}
+
+// fetchGamePassAdditions returns the games recently added to Xbox Game Pass.
+// They are part of the subscription, not given away.
+func fetchGamePassAdditions(ctx context.Context, countryCode, locale string) ([]Game, error) {
+	query := url.Values{
+		"id":       {gamePassRecentSiglID},
+		"language": {strings.ToLower(locale)},
+		"market":   {strings.ToUpper(countryCode)},
+	}
+	// The first entry describes the list, the others are products
+	var sigl []struct {
+		ID string `json:"id"`
+	}
+	if err := getJSON(ctx, gamePassSiglURL+"?"+query.Encode(), &sigl); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range sigl {
+		if entry.ID != "" {
+			ids = append(ids, entry.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query = url.Values{
+		"bigIds":    {strings.Join(ids, ",")},
+		"market":    {strings.ToUpper(countryCode)},
+		"languages": {strings.ToLower(locale)},
+	}
+	var catalog gamePassProducts
+	if err := getJSON(ctx, xboxDisplayCatalog+"?"+query.Encode(), &catalog); err != nil {
+		return nil, err
+	}
+
+	var games []Game
+	for _, product := range catalog.Products {
+		if len(product.LocalizedProperties) == 0 {
+			continue
+		}
+		properties := product.LocalizedProperties[0]
+		game := Game{
+			ID:            gameID(storeGamePass, product.ProductID),
+			Title:         properties.ProductTitle,
+			Description:   properties.ShortDescription,
+			URL:           "https://www.microsoft.com/store/productId/" + product.ProductID,
+			Status:        "free",
+			StartDate:     "Unknown",
+			EndDate:       "Unknown",
+			DatePrecision: "unknown",
+			Publisher:     properties.PublisherName,
+			OfferType:     "BASE_GAME",
+			OfferKind:     offerKindSubscription,
+			Store:         storeGamePass,
+			Platforms:     []string{"Xbox"},
+			namespace:     storeGamePass,
+			offerID:       product.ProductID,
+		}
+		if product.Properties.Category != "" {
+			game.Genres = []string{product.Properties.Category}
+		}
+		for _, image := range properties.Images {
+			if image.ImagePurpose == "SuperHeroArt" || game.ImageURL == "" && image.ImagePurpose == "BoxArt" {
+				game.ImageURL = "https:" + strings.TrimPrefix(image.URI, "https:")
+			}
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// getJSON fetches a URL and decodes its JSON response into v
+func getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// dashboardFiles is the web dashboard, a single page listing the free games
+// from the JSON API
+//
+//go:embed web
+var dashboardFiles embed.FS
+
+// indexHandler serves the dashboard at /
+func indexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	serveDashboardFile(w, r, "index.html")
+}
+
+// dashboardAssetsHandler serves the scripts and styles of the dashboard under
+// /assets/, the pages have their own routes
+func dashboardAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/assets/")
+	if name == "" || path.Ext(name) == ".html" || !fs.ValidPath(name) {
+		http.NotFound(w, r)
+		return
+	}
+	serveDashboardFile(w, r, name)
+}
+
+// serveDashboardFile writes a file of the dashboard, revalidated by ETag so a
+// new release is picked up right away
+func serveDashboardFile(w http.ResponseWriter, r *http.Request, name string) {
+	data, err := dashboardFiles.ReadFile(path.Join("web", name))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	writeConditional(w, r, data, data)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// processStarted is when the process started, for the uptime of the debug
+// endpoint
+var processStarted = time.Now()
+
+// DebugResponse is returned by the debug endpoint
+type DebugResponse struct {
+	Success       bool       `json:"success"`
+	SchemaVersion int        `json:"schema_version"`
+	Data          *DebugInfo `json:"data"`
+}
+
+// DebugInfo is a snapshot of the runtime, to diagnose memory growth and
+// leaking goroutines of long-running deployments
+type DebugInfo struct {
+	StartedAt     time.Time   `json:"started_at"`
+	UptimeSeconds int64       `json:"uptime_seconds"`
+	Goroutines    int         `json:"goroutines"`
+	GOMAXPROCS    int         `json:"gomaxprocs"`
+	Heap          DebugHeap   `json:"heap"`
+	Build         *DebugBuild `json:"build,omitempty"` // Missing when the binary has no build info
+}
+
+// DebugHeap holds the memory statistics of the runtime
+type DebugHeap struct {
+	AllocBytes    uint64     `json:"alloc_bytes"` // Live heap objects
+	InuseBytes    uint64     `json:"inuse_bytes"`
+	IdleBytes     uint64     `json:"idle_bytes"`
+	ReleasedBytes uint64     `json:"released_bytes"` // Returned to the OS
+	SysBytes      uint64     `json:"sys_bytes"`      // Obtained from the OS, all uses
+	Objects       uint64     `json:"objects"`
+	GCCount       uint32     `json:"gc_count"`
+	GCPauseTotal  float64    `json:"gc_pause_total_ms"`
+	LastGC        *time.Time `json:"last_gc,omitempty"`
+}
+
+// DebugBuild describes the binary
+type DebugBuild struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings,omitempty"` // Build flags and VCS revision
+}
+
+// collectDebugInfo reads the runtime statistics. It stops the world briefly,
+// like every ReadMemStats.
+func collectDebugInfo() *DebugInfo {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	info := &DebugInfo{
+		StartedAt:     processStarted.UTC(),
+		UptimeSeconds: int64(time.Since(processStarted).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Heap: DebugHeap{
+			AllocBytes:    mem.HeapAlloc,
+			InuseBytes:    mem.HeapInuse,
+			IdleBytes:     mem.HeapIdle,
+			ReleasedBytes: mem.HeapReleased,
+			SysBytes:      mem.Sys,
+			Objects:       mem.HeapObjects,
+			GCCount:       mem.NumGC,
+			GCPauseTotal:  float64(mem.PauseTotalNs) / float64(time.Millisecond),
+		},
+	}
+	if mem.LastGC > 0 {
+		last := time.Unix(0, int64(mem.LastGC)).UTC()
+		info.Heap.LastGC = &last
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.Build = &DebugBuild{
+			GoVersion: build.GoVersion,
+			Path:      build.Main.Path,
+			Version:   build.Main.Version,
+			Settings:  map[string]string{},
+		}
+		for _, setting := range build.Settings {
+			info.Build.Settings[setting.Key] = setting.Value
+		}
+	}
+	return info
+}
+
+// debugInfoHandler serves GET /v1/admin/debug
+func debugInfoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(DebugResponse{Success: true, SchemaVersion: apiSchemaVersion, Data: collectDebugInfo()})
+}
+
+// newAdminMux serves pprof under /debug/pprof/ and the runtime snapshot at
+// /v1/admin/debug, requiring an API key when keys are configured
+func newAdminMux(keys *APIKeys) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", keys.Require(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", keys.Require(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", keys.Require(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", keys.Require(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", keys.Require(pprof.Trace))
+	handleAPIOn(mux, "/v1/admin/debug", "/api/admin/debug", keys.Require(debugInfoHandler))
+	return mux
+}
+
+// isDebugPath reports whether the path is served by the admin mux
+func isDebugPath(path string) bool {
+	return strings.HasPrefix(path, "/debug/pprof") || path == "/v1/admin/debug" || path == "/api/admin/debug"
+}
+
+// debugHandler routes the debug endpoints to admin, or answers them with 404
+// when admin is nil. Importing net/http/pprof registers it on the default
+// mux, which must not expose it without authentication.
+func debugHandler(next http.Handler, admin http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isDebugPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if admin == nil {
+			http.NotFound(w, r)
+			return
+		}
+		admin.ServeHTTP(w, r)
+	})
+}
+
+// startAdminServer serves the debug endpoints of handler on host and port in
+// the background, meant to be reachable only from the host or the private
+// network
+func startAdminServer(host string, port int, handler http.Handler) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("error listening for the admin server: %v", err)
+	}
+	go func() {
+		if err := http.Serve(listener, handler); err != nil {
+			log.Printf("Warning: Admin server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("Admin server listening on %s...\n", listener.Addr())
+	return nil
+}
+
+// isLoopbackHost reports whether host only accepts connections from the
+// machine itself
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
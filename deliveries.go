@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"epic-games-api/storage"
+)
+
+// Defaults of the deliveries endpoint
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// deliveryLog records every notification attempt. It's the state database
+// when one is set in main, otherwise the log is kept in memory.
+var deliveryLog storage.Store = storage.NewMemoryStore()
+
+// statusCodePattern finds the HTTP status in the errors of the notifiers,
+// which report it as "... returned status 404: ..."
+var statusCodePattern = regexp.MustCompile(`\bstatus:? (\d{3})\b`)
+
+// urlPattern finds the URLs in the errors of the notifiers, whose paths and
+// queries often hold the webhook or bot tokens
+var urlPattern = regexp.MustCompile(`\b([a-zA-Z][a-zA-Z0-9+.-]*)://[^\s"'<>]+`)
+
+// DeliveryRecord is a notification attempt as returned by the deliveries
+// endpoint
+type DeliveryRecord struct {
+	ID          int64                  `json:"id"`
+	Channel     string                 `json:"channel"`
+	Result      string                 `json:"result"` // "delivered" or "failed"
+	Error       string                 `json:"error,omitempty"`
+	StatusCode  int                    `json:"status_code,omitempty"`
+	Attempt     int                    `json:"attempt"`
+	RetryOf     int64                  `json:"retry_of,omitempty"`
+	GameCount   int                    `json:"game_count"`
+	Games       []storage.DeliveryGame `json:"games"`
+	DeliveredAt time.Time              `json:"delivered_at"`
+}
+
+// DeliveriesResponse is returned by the deliveries endpoints
+type DeliveriesResponse struct {
+	Success       bool             `json:"success"`
+	SchemaVersion int              `json:"schema_version"`
+	Code          string           `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string           `json:"message,omitempty"`
+	RequestID     string           `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	Count         int              `json:"count"`
+	Data          []DeliveryRecord `json:"data"`
+}
+
+// recordDelivery logs a notification attempt of a channel and returns its ID,
+// 0 if it couldn't be recorded. retried is the failed delivery an attempt
+// retries, nil for first attempts.
+func recordDelivery(channel string, games []Game, err error, retried *storage.Delivery) int64 {
+	delivery := storage.Delivery{
+		Channel:     channel,
+		Games:       make([]storage.DeliveryGame, 0, len(games)),
+		Attempt:     1,
+		DeliveredAt: time.Now(),
+	}
+	for _, game := range games {
+		delivery.Games = append(delivery.Games, storage.DeliveryGame{ID: game.ID, Title: game.Title})
+	}
+	if err != nil {
+		delivery.Error = redactURLs(err.Error())
+		delivery.StatusCode = deliveryStatusCode(err)
+	}
+	if retried != nil {
+		delivery.Attempt = retried.Attempt + 1
+		delivery.RetryOf = retried.ID
+		if retried.RetryOf != 0 {
+			delivery.RetryOf = retried.RetryOf
+		}
+	}
+
+	id, err := deliveryLog.RecordDelivery(delivery)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return id
+}
+
+// redactURLs shortens the URLs in message to their scheme and host, so the
+// log doesn't leak the credentials of the channels
+func redactURLs(message string) string {
+	return urlPattern.ReplaceAllStringFunc(message, func(match string) string {
+		u, err := url.Parse(match)
+		if err != nil || u.Host == "" {
+			scheme, _, _ := strings.Cut(match, "://")
+			return scheme + "://[redacted]"
+		}
+		return u.Scheme + "://" + u.Hostname() + "/[redacted]"
+	})
+}
+
+// deliveryStatusCode returns the HTTP status a notifier reported in its
+// error, 0 when there's none
+func deliveryStatusCode(err error) int {
+	match := statusCodePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	code, _ := strconv.Atoi(match[1])
+	return code
+}
+
+// retryDelivery sends the games of a failed delivery that are still in games
+// to the notifiers of its channel again, and records the attempt
+func retryDelivery(ctx context.Context, delivery *storage.Delivery, notifiers []Notifier, games []Game) (int64, error) {
+	ids := map[string]bool{}
+	for _, game := range delivery.Games {
+		ids[game.ID] = true
+	}
+	var retry []Game
+	for _, game := range games {
+		if ids[game.ID] {
+			retry = append(retry, game)
+		}
+	}
+	if len(retry) == 0 {
+		return 0, fmt.Errorf("none of the games of delivery %d are free anymore", delivery.ID)
+	}
+
+	var channel []Notifier
+	for _, n := range notifiers {
+		if channelID(n) == delivery.Channel {
+			channel = append(channel, n)
+		}
+	}
+	if len(channel) == 0 {
+		return 0, fmt.Errorf("channel %s isn't configured anymore", delivery.Channel)
+	}
+
+	var errs []error
+	for _, n := range channel {
+		if err := notifyChannel(ctx, n, retry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	id := recordDelivery(delivery.Channel, retry, err, delivery)
+	if err != nil {
+		return id, err
+	}
+	log.Printf("%s notification retried for %d games", delivery.Channel, len(retry))
+	return id, nil
+}
+
+// runDeliveryRetries retries the failed deliveries of the last day every
+// delay, waiting delay times the attempts made between the attempts of a
+// delivery, until maxRetries retries failed. Deliveries of a channel older
+// than its last successful one are left alone, that run sent the games again.
+func runDeliveryRetries(notifiers func() []Notifier, fetchGames func(context.Context) ([]Game, error), maxRetries int, delay time.Duration) {
+	ticker := time.NewTicker(delay)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		pending, err := pendingRetries(maxRetries, delay)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		games, err := fetchGames(context.Background())
+		if err != nil {
+			log.Printf("Warning: Error fetching games to retry deliveries: %v", err)
+			continue
+		}
+		for i := range pending {
+			if _, err := retryDelivery(context.Background(), &pending[i], notifiers(), games); err != nil {
+				log.Printf("Warning: Error retrying delivery %d to %s: %v", pending[i].ID, pending[i].Channel, err)
+			}
+		}
+	}
+}
+
+// pendingRetries returns the failed deliveries due for another attempt
+func pendingRetries(maxRetries int, delay time.Duration) ([]storage.Delivery, error) {
+	recent, err := deliveryLog.Deliveries(storage.DeliveryFilter{Since: time.Now().Add(-24 * time.Hour)})
+	if err != nil {
+		return nil, err
+	}
+
+	lastAttempt := map[int64]int{} // By the ID of the first attempt
+	lastSuccess := map[string]time.Time{}
+	for _, delivery := range recent {
+		first := delivery.ID
+		if delivery.RetryOf != 0 {
+			first = delivery.RetryOf
+		}
+		lastAttempt[first] = max(lastAttempt[first], delivery.Attempt)
+		if delivery.Error == "" && delivery.DeliveredAt.After(lastSuccess[delivery.Channel]) {
+			lastSuccess[delivery.Channel] = delivery.DeliveredAt
+		}
+	}
+
+	var pending []storage.Delivery
+	for _, delivery := range recent {
+		first := delivery.ID
+		if delivery.RetryOf != 0 {
+			first = delivery.RetryOf
+		}
+		switch {
+		case delivery.Error == "",
+			delivery.Attempt > maxRetries,
+			lastAttempt[first] > delivery.Attempt,
+			lastSuccess[delivery.Channel].After(delivery.DeliveredAt),
+			time.Since(delivery.DeliveredAt) < delay*time.Duration(delivery.Attempt):
+			continue
+		}
+		pending = append(pending, delivery)
+	}
+	return pending, nil
+}
+
+// toDeliveryRecord converts a stored delivery for the API
+func toDeliveryRecord(delivery storage.Delivery) DeliveryRecord {
+	record := DeliveryRecord{
+		ID:          delivery.ID,
+		Channel:     delivery.Channel,
+		Result:      "delivered",
+		Error:       delivery.Error,
+		StatusCode:  delivery.StatusCode,
+		Attempt:     delivery.Attempt,
+		RetryOf:     delivery.RetryOf,
+		GameCount:   len(delivery.Games),
+		Games:       delivery.Games,
+		DeliveredAt: delivery.DeliveredAt,
+	}
+	if delivery.Error != "" {
+		record.Result = "failed"
+	}
+	if record.Games == nil {
+		record.Games = []storage.DeliveryGame{}
+	}
+	return record
+}
+
+// deliveriesHandler serves GET /v1/deliveries?channel=discord&failed=true&since=2025-01-01&limit=50
+func deliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter := storage.DeliveryFilter{
+		Channel:    r.URL.Query().Get("channel"),
+		FailedOnly: parseBool(r.URL.Query().Get("failed")),
+		Limit:      defaultDeliveriesLimit,
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			writeDeliveriesError(w, errorf(ErrBadParameter, "Invalid limit %q, expected 1 to %d", value, maxDeliveriesLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+	if value := r.URL.Query().Get("since"); value != "" {
+		since, err := parseSince(value)
+		if err != nil {
+			writeDeliveriesError(w, withKind(ErrBadParameter, err))
+			return
+		}
+		filter.Since = since
+	}
+
+	deliveries, err := deliveryLog.Deliveries(filter)
+	if err != nil {
+		writeDeliveriesError(w, err)
+		return
+	}
+	records := make([]DeliveryRecord, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		records = append(records, toDeliveryRecord(delivery))
+	}
+
+	jsonData, _ := json.MarshalIndent(DeliveriesResponse{
+		Success:       true,
+		SchemaVersion: apiSchemaVersion,
+		Count:         len(records),
+		Data:          records,
+	}, "", "  ")
+	w.Write(jsonData)
+}
+
+// deliveryRetryHandler serves POST /v1/deliveries/{id}/retry, sending the
+// games of a delivery that are still free again
+func deliveryRetryHandler(w http.ResponseWriter, r *http.Request, notifiers []Notifier, fetchGames func(context.Context) ([]Game, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_, rest, _ := strings.Cut(r.URL.Path, "/deliveries/")
+	rawID, ok := strings.CutSuffix(rest, "/retry")
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if !ok || err != nil {
+		writeDeliveriesError(w, errorf(ErrNotFound, "Unknown deliveries path, expected /v1/deliveries/{id}/retry"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeDeliveriesError(w, errorf(ErrMethodNotAllowed, "Retry a delivery with POST"))
+		return
+	}
+
+	delivery, err := deliveryLog.Delivery(id)
+	if err != nil {
+		writeDeliveriesError(w, err)
+		return
+	}
+	if delivery == nil {
+		writeDeliveriesError(w, errorf(ErrNotFound, "No delivery with ID %d", id))
+		return
+	}
+
+	games, err := fetchGames(r.Context())
+	if err != nil {
+		writeDeliveriesError(w, errorf(ErrUpstreamUnavailable, "Error fetching games: %v", err))
+		return
+	}
+	retryID, err := retryDelivery(r.Context(), delivery, notifiers, games)
+	if retryID == 0 {
+		writeDeliveriesError(w, withKind(ErrConflict, err))
+		return
+	}
+	retry, lookupErr := deliveryLog.Delivery(retryID)
+	if lookupErr != nil || retry == nil {
+		writeDeliveriesError(w, fmt.Errorf("Error reading delivery %d: %v", retryID, lookupErr))
+		return
+	}
+
+	response := DeliveriesResponse{
+		Success:       err == nil,
+		SchemaVersion: apiSchemaVersion,
+		Count:         1,
+		Data:          []DeliveryRecord{toDeliveryRecord(*retry)},
+	}
+	if err != nil {
+		w.WriteHeader(ErrNotifierFailed.Status)
+		response.Code = ErrNotifierFailed.Code
+		response.Message = fmt.Sprintf("Retry failed: %v", err)
+		response.RequestID = requestID(w)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeDeliveriesError writes an error response of the deliveries endpoints,
+// with the status and code of the error's kind
+func writeDeliveriesError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(DeliveriesResponse{SchemaVersion: apiSchemaVersion, Code: code, RequestID: requestID(w), Message: err.Error(), Data: []DeliveryRecord{}})
+}
+
+// parseSince parses an RFC3339 time or a YYYY-MM-DD date
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q, expected YYYY-MM-DD or an RFC3339 time", value)
+	}
+	return t, nil
+}
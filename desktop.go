@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DesktopNotifier raises native desktop notifications, for running the binary
+// as a personal daemon without any external service
+type DesktopNotifier struct {
+	tracker *SeenTracker
+}
+
+// NewDesktopNotifier creates a desktop notifier. Games already shown are
+// remembered by tracker.
+func NewDesktopNotifier(tracker *SeenTracker) *DesktopNotifier {
+	return &DesktopNotifier{tracker: tracker}
+}
+
+// Name returns the channel identifier
+func (d *DesktopNotifier) Name() string {
+	return "desktop"
+}
+
+// pendingGames returns the games not announced yet
+func (d *DesktopNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return d.tracker.Unseen(games), d.tracker
+}
+
+// Notify shows a notification for each new game
+func (d *DesktopNotifier) Notify(ctx context.Context, games []Game) error {
+	for _, game := range d.tracker.Unseen(games) {
+		body := "Free now " + onStore(game)
+		if isUpcoming(game) {
+			body = "Coming soon for free " + onStore(game)
+		}
+		if game.EndDate != "Unknown" {
+			body += " until " + game.EndDate
+		}
+
+		if err := showDesktopNotification(ctx, game.Title, body); err != nil {
+			return err
+		}
+		if err := d.tracker.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+// showDesktopNotification uses the platform's notification tool:
+// notify-send on Linux/BSD, osascript on macOS and a PowerShell toast on Windows
+func showDesktopNotification(ctx context.Context, title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, body))
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=Epic Games Free Games", title, body)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error showing desktop notification: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// windowsToastScript builds a PowerShell script raising a toast notification
+// through the WinRT notification API
+func windowsToastScript(title, body string) string {
+	// Single-quoted PowerShell strings only need quotes doubled, the XML
+	// template needs its own escaping
+	xmlEscape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	psEscape := func(s string) string {
+		return strings.ReplaceAll(xmlEscape.Replace(s), "'", "''")
+	}
+
+	return fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml('<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>')
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)`,
+		psEscape(title), psEscape(body))
+}
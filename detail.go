@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PromoWindow is a promotion of an offer as returned by Epic
+type PromoWindow struct {
+	StartDate          string `json:"start_date"` // RFC3339
+	EndDate            string `json:"end_date"`
+	Upcoming           bool   `json:"upcoming"`
+	DiscountType       string `json:"discount_type"`
+	DiscountPercentage int    `json:"discount_percentage"`
+}
+
+// KeyImage is an image of an offer, e.g. "Thumbnail" or "OfferImageWide"
+type KeyImage struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// GameDetail is a game with the raw promotion windows and images of its offer
+type GameDetail struct {
+	Game
+	Promotions []PromoWindow `json:"promotions"`
+	KeyImages  []KeyImage    `json:"key_images"`
+}
+
+// GameDetailResponse is returned by the per-game endpoint
+type GameDetailResponse struct {
+	Success       bool        `json:"success"`
+	SchemaVersion int         `json:"schema_version"`
+	Code          string      `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string      `json:"message,omitempty"`
+	RequestID     string      `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	Data          *GameDetail `json:"data,omitempty"`
+}
+
+// gameID is the stable identifier of an offer, "{namespace}:{offerID}"
+func gameID(namespace, offerID string) string {
+	if namespace == "" || offerID == "" {
+		return ""
+	}
+	return namespace + ":" + offerID
+}
+
+// gameDetailHandler serves GET /v1/free-games/{id} for one of the current or
+// upcoming free games
+func gameDetailHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	_, rawID, _ := strings.Cut(r.URL.Path, "/free-games/")
+	id, err := url.PathUnescape(rawID)
+	if err != nil || id == "" || strings.Contains(id, "/") {
+		w.WriteHeader(ErrBadParameter.Status)
+		json.NewEncoder(w).Encode(GameDetailResponse{SchemaVersion: apiSchemaVersion, Code: ErrBadParameter.Code, RequestID: requestID(w), Message: "Invalid game ID"})
+		return
+	}
+
+	games, err := opts.freeGames(r.Context(), true)
+	if err != nil {
+		status, code := errorStatus(err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(GameDetailResponse{SchemaVersion: apiSchemaVersion, Code: code, RequestID: requestID(w), Message: fmt.Sprintf("Error fetching games: %v", err)})
+		return
+	}
+
+	for _, game := range games {
+		if game.ID == id {
+			detail := &GameDetail{
+				Game:       game,
+				Promotions: game.promotions,
+				KeyImages:  game.keyImages,
+			}
+			jsonData, _ := json.MarshalIndent(GameDetailResponse{Success: true, SchemaVersion: apiSchemaVersion, Data: detail}, "", "  ")
+			stable := *detail
+			stable.Game = withoutRelativeTimes([]Game{game})[0]
+			version, _ := json.Marshal(stable)
+			writeConditional(w, r, jsonData, version)
+			return
+		}
+	}
+
+	w.WriteHeader(ErrNotFound.Status)
+	json.NewEncoder(w).Encode(GameDetailResponse{SchemaVersion: apiSchemaVersion, Code: ErrNotFound.Code, RequestID: requestID(w), Message: fmt.Sprintf("No current or upcoming free game with ID %q", id)})
+}
@@ -2,12 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Discord supports up to 10 embeds per message
+const discordMaxEmbeds = 10
+
 // DiscordEmbed represents a Discord embed object
 type DiscordEmbed struct {
 	Title       string                 `json:"title,omitempty"`
@@ -40,80 +50,404 @@ type DiscordEmbedFooter struct {
 
 // DiscordWebhookMessage represents a Discord webhook message
 type DiscordWebhookMessage struct {
-	Content   string         `json:"content,omitempty"`
-	Username  string         `json:"username,omitempty"`
-	AvatarURL string         `json:"avatar_url,omitempty"`
-	Embeds    []DiscordEmbed `json:"embeds,omitempty"`
+	Content         string                  `json:"content,omitempty"`
+	Username        string                  `json:"username,omitempty"`
+	AvatarURL       string                  `json:"avatar_url,omitempty"`
+	Embeds          []DiscordEmbed          `json:"embeds,omitempty"`
+	AllowedMentions *DiscordAllowedMentions `json:"allowed_mentions,omitempty"`
+	ThreadName      string                  `json:"thread_name,omitempty"` // Creates a forum post
+	Components      []DiscordActionRow      `json:"components,omitempty"`
+}
+
+// DiscordActionRow is a row of message components
+type DiscordActionRow struct {
+	Type       int             `json:"type"` // Always 1
+	Components []DiscordButton `json:"components"`
+}
+
+// DiscordButton is a message button, only link buttons (style 5) are used
+type DiscordButton struct {
+	Type  int    `json:"type"` // Always 2
+	Style int    `json:"style"`
+	Label string `json:"label"`
+	URL   string `json:"url,omitempty"`
+}
+
+// DiscordAllowedMentions controls which mentions in the content actually ping
+type DiscordAllowedMentions struct {
+	Parse []string `json:"parse"`
+	Roles []string `json:"roles,omitempty"`
+	Users []string `json:"users,omitempty"`
+}
+
+// DiscordOptions customizes the messages sent to a Discord webhook
+type DiscordOptions struct {
+	// Mentions added to the message when it contains currently free or
+	// upcoming games, e.g. "<@&ROLE_ID>", "<@USER_ID>" or "@everyone"
+	MentionFree     string
+	MentionUpcoming string
+
+	// EditInPlace keeps a single message listing the current games and edits
+	// it when the lineup changes instead of posting new messages. The message
+	// ID is stored in MessageStateFile between runs.
+	EditInPlace      bool
+	MessageStateFile string
+
+	// Template restyles the messages, the built-in style is used when nil
+	Template *DiscordTemplate
+
+	// ThreadID posts into an existing thread of the webhook's channel
+	ThreadID string
+	// ForumPosts creates a new forum post per game, for webhooks of forum
+	// channels. It can't be combined with ThreadID.
+	ForumPosts bool
+
+	// LinkButtons attaches "Claim on Epic" buttons, plus a button to
+	// MoreGamesURL if set. Only messages sent by a bot can carry them.
+	LinkButtons  bool
+	MoreGamesURL string
+}
+
+// validate checks for conflicting options
+func (o DiscordOptions) validate() error {
+	if o.ForumPosts && o.ThreadID != "" {
+		return fmt.Errorf("forum posts can't be combined with a thread ID")
+	}
+	return nil
 }
 
-// SendDiscordNotification sends game information to Discord via webhook
-func SendDiscordNotification(webhookURL string, games []Game) error {
+// template returns the configured template or the default one
+func (o DiscordOptions) template() *DiscordTemplate {
+	if o.Template != nil {
+		return o.Template
+	}
+	return defaultDiscordTemplate
+}
+
+var discordMentionPattern = regexp.MustCompile(`<@(&|!)?(\d+)>|@(everyone|here)`)
+
+// DiscordDeliveryResult describes what SendDiscordNotification delivered
+type DiscordDeliveryResult struct {
+	Delivered []Game // Games included in messages Discord accepted
+	Failed    []Game // Games that could not be delivered
+	Messages  int    // Number of messages posted
+}
+
+// SendDiscordNotification sends game information to Discord via webhook. Games
+// are split across as many messages as needed for Discord's embed limit, or
+// posted as one forum post each when options.ForumPosts is set.
+func SendDiscordNotification(ctx context.Context, webhookURL string, games []Game, options DiscordOptions) (*DiscordDeliveryResult, error) {
+	webhookURL = discordThreadURL(webhookURL, options.ThreadID)
+	return sendDiscordMessages(games, options, func(message DiscordWebhookMessage) error {
+		payload, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("error marshaling webhook message: %v", err)
+		}
+		_, err = discordWebhookRequest(ctx, "POST", webhookURL, "", payload, nil)
+		return err
+	})
+}
+
+// sendDiscordMessages builds the messages announcing games and hands them to
+// post, which delivers them through a webhook or the bot API
+func sendDiscordMessages(games []Game, options DiscordOptions, post func(DiscordWebhookMessage) error) (*DiscordDeliveryResult, error) {
+	result := &DiscordDeliveryResult{}
 	if len(games) == 0 {
-		return nil // No games to notify about
+		return result, nil // No games to notify about
+	}
+
+	// Add mentions depending on which kinds of games are announced
+	hasFree, hasUpcoming := false, false
+	for _, game := range games {
+		if isUpcoming(game) {
+			hasUpcoming = true
+		} else {
+			hasFree = true
+		}
+	}
+	var mentions []string
+	if hasFree && options.MentionFree != "" {
+		mentions = append(mentions, options.MentionFree)
+	}
+	if hasUpcoming && options.MentionUpcoming != "" && options.MentionUpcoming != options.MentionFree {
+		mentions = append(mentions, options.MentionUpcoming)
 	}
 
+	tmpl := options.template()
 
-	// Create webhook message
-	message := DiscordWebhookMessage{
-		Content:   "🎮 Free Games from Epic Games Store 🎮",
-		Embeds:    []DiscordEmbed{},
+	if options.ForumPosts {
+		return sendDiscordForumPosts(games, mentions, options, post)
 	}
 
-	// Add embeds for each game (Discord supports up to 10 embeds per message)
+	for start := 0; start < len(games); start += discordMaxEmbeds {
+		end := start + discordMaxEmbeds
+		if end > len(games) {
+			end = len(games)
+		}
+		batch := games[start:end]
+
+		// Create webhook message, only the first one carries the header and mentions
+		message := DiscordWebhookMessage{
+			Embeds: []DiscordEmbed{},
+		}
+		if start == 0 {
+			message.Content = tmpl.render(tmpl.content, newDiscordContentData(games))
+			if len(mentions) > 0 {
+				message.Content += "\n" + strings.Join(mentions, " ")
+			}
+			message.AllowedMentions = buildAllowedMentions(strings.Join(mentions, " "))
+		} else {
+			message.AllowedMentions = &DiscordAllowedMentions{Parse: []string{}}
+		}
+
+		for _, game := range batch {
+			message.Embeds = append(message.Embeds, createGameEmbed(game, tmpl))
+		}
+		if options.LinkButtons {
+			message.Components = createLinkButtons(batch, options.MoreGamesURL)
+		}
+
+		if err := post(message); err != nil {
+			result.Failed = append(result.Failed, games[start:]...)
+			return result, err
+		}
+
+		result.Delivered = append(result.Delivered, batch...)
+		result.Messages++
+	}
+
+	return result, nil
+}
+
+// sendDiscordForumPosts creates a forum post per game, titled with the game
+// title. Each post carries the header and mentions for its own game.
+func sendDiscordForumPosts(games []Game, mentions []string, options DiscordOptions, post func(DiscordWebhookMessage) error) (*DiscordDeliveryResult, error) {
+	tmpl := options.template()
+	result := &DiscordDeliveryResult{}
 	for i, game := range games {
-		if i >= 10 {
-			break // Discord limit: maximum 10 embeds per message
+		message := DiscordWebhookMessage{
+			Content:         tmpl.render(tmpl.content, newDiscordContentData([]Game{game})),
+			Embeds:          []DiscordEmbed{createGameEmbed(game, tmpl)},
+			AllowedMentions: buildAllowedMentions(strings.Join(mentions, " ")),
+			ThreadName:      discordThreadName(game.Title),
+		}
+		if len(mentions) > 0 {
+			message.Content += "\n" + strings.Join(mentions, " ")
 		}
-		message.Embeds = append(message.Embeds, createGameEmbed(game))
+		if options.LinkButtons {
+			message.Components = createLinkButtons([]Game{game}, options.MoreGamesURL)
+		}
+
+		if err := post(message); err != nil {
+			result.Failed = append(result.Failed, games[i:]...)
+			return result, err
+		}
+
+		result.Delivered = append(result.Delivered, game)
+		result.Messages++
 	}
+	return result, nil
+}
 
-	// Marshal the message to JSON
-	payload, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("error marshaling webhook message: %v", err)
+// createLinkButtons adds a "Claim on Epic" (or GOG) link button per game, a
+// "Watch Trailer" button to a single game with a trailer, plus a button
+// linking to the list of free games when moreGamesURL is set. Discord allows
+// 5 buttons per row.
+func createLinkButtons(games []Game, moreGamesURL string) []DiscordActionRow {
+	var buttons []DiscordButton
+	for _, game := range games {
+		if game.URL == "" {
+			continue
+		}
+		label := "Claim on " + storeShortName(game)
+		if len(games) > 1 {
+			label = truncateRunes("Claim "+game.Title, 80)
+		}
+		buttons = append(buttons, DiscordButton{Type: 2, Style: 5, Label: label, URL: game.URL})
+	}
+	if len(games) == 1 && games[0].TrailerURL != "" {
+		buttons = append(buttons, DiscordButton{Type: 2, Style: 5, Label: "Watch Trailer", URL: games[0].TrailerURL})
+	}
+	if moreGamesURL != "" {
+		buttons = append(buttons, DiscordButton{Type: 2, Style: 5, Label: "More free games", URL: moreGamesURL})
+	}
+
+	var rows []DiscordActionRow
+	for start := 0; start < len(buttons); start += 5 {
+		end := start + 5
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		rows = append(rows, DiscordActionRow{Type: 1, Components: buttons[start:end]})
+	}
+	return rows
+}
+
+// discordThreadName truncates a forum post title to Discord's 100 character limit
+func discordThreadName(title string) string {
+	if title == "" {
+		return "Free game"
 	}
+	return truncateRunes(title, 100)
+}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(payload))
+// discordThreadURL adds the thread_id parameter to the webhook URL, so
+// messages are posted into (or edited in) that thread
+func discordThreadURL(webhookURL, threadID string) string {
+	if threadID == "" {
+		return webhookURL
+	}
+	u, err := url.Parse(webhookURL)
 	if err != nil {
-		return fmt.Errorf("error creating webhook request: %v", err)
+		return webhookURL // Reported when the request is sent
 	}
+	query := u.Query()
+	query.Set("thread_id", threadID)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+// discordMaxAttempts is how often a webhook request is tried when Discord is
+// rate limiting or failing
+const discordMaxAttempts = 5
 
-	// Send the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+// discordWebhookRequest sends payload to the webhook (or a sub path of it such
+// as /messages/{id}), waiting for the created message when out is set.
+// Rate limited (429) and failed (5xx) requests are retried, honoring
+// Retry-After and otherwise backing off exponentially.
+func discordWebhookRequest(ctx context.Context, method, webhookURL, subPath string, payload []byte, out interface{}) (int, error) {
+	u, err := url.Parse(webhookURL)
 	if err != nil {
-		return fmt.Errorf("error sending webhook request: %v", err)
+		return 0, fmt.Errorf("error parsing webhook URL: %v", err)
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + subPath
+	if out != nil {
+		query := u.Query()
+		query.Set("wait", "true")
+		u.RawQuery = query.Encode()
 	}
-	defer resp.Body.Close()
+	return discordRequest(ctx, method, u.String(), "", payload, out)
+}
+
+// discordRequest sends payload to a Discord API endpoint, authorized with the
+// given Authorization header value if set, and decodes the response into out
+func discordRequest(ctx context.Context, method, endpoint, authorization string, payload []byte, out interface{}) (int, error) {
+	client := newHTTPClient(10 * time.Second)
+	backoff := time.Second
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewBuffer(payload))
+		if err != nil {
+			return 0, fmt.Errorf("error creating Discord request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("error sending Discord request: %v", err)
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if out != nil {
+				if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+					return resp.StatusCode, fmt.Errorf("error decoding Discord response: %v", err)
+				}
+			}
+			return resp.StatusCode, nil
+		}
 
-	// Check response status
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("Discord webhook returned non-2xx status code: %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= discordMaxAttempts {
+			return resp.StatusCode, fmt.Errorf("Discord returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		wait := backoff
+		if retryAfter := discordRetryAfter(resp, bodyBytes); retryAfter > 0 {
+			wait = retryAfter
+		}
+		log.Printf("Discord returned status %d, retrying in %v", resp.StatusCode, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return resp.StatusCode, fmt.Errorf("Discord returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		}
+		backoff *= 2
 	}
+}
 
-	return nil
+// discordRetryAfter reads how long to wait before retrying a rate limited
+// request, from the Retry-After header or the retry_after field of the body
+func discordRetryAfter(resp *http.Response, body []byte) time.Duration {
+	if header := resp.Header.Get("Retry-After"); header != "" {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	var rateLimit struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &rateLimit) == nil && rateLimit.RetryAfter > 0 {
+		return time.Duration(rateLimit.RetryAfter * float64(time.Second))
+	}
+	return 0
 }
 
-// createGameEmbed creates a Discord embed for a game
-func createGameEmbed(game Game) DiscordEmbed {
-	// Set color based on game status
-	color := 0x0078F2 // Epic Games blue color
-	if game.Status == "free" {
-		color = 0x2ECC71 // Green color for free games
-	} else if game.Status == "coming soon" {
-		color = 0xF1C40F // Yellow color for upcoming games
+// buildAllowedMentions only allows the mentions configured by the user to
+// ping, so game titles or descriptions can never trigger one
+func buildAllowedMentions(content string) *DiscordAllowedMentions {
+	allowed := &DiscordAllowedMentions{Parse: []string{}}
+	for _, match := range discordMentionPattern.FindAllStringSubmatch(content, -1) {
+		switch {
+		case match[3] != "":
+			// @everyone and @here are both controlled by "everyone"
+			if len(allowed.Parse) == 0 {
+				allowed.Parse = append(allowed.Parse, "everyone")
+			}
+		case match[1] == "&":
+			allowed.Roles = append(allowed.Roles, match[2])
+		default:
+			allowed.Users = append(allowed.Users, match[2])
+		}
 	}
+	return allowed
+}
+
+// withRelativeTime appends a Discord timestamp to an exact date, which every
+// reader sees as a live relative time such as "in 2 days"
+func withRelativeTime(date string, t time.Time, precision string) string {
+	if precision != "exact" || t.IsZero() {
+		return date
+	}
+	return fmt.Sprintf("%s (<t:%d:R>)", date, t.Unix())
+}
 
-	// Create embed
+// createGameEmbed creates a Discord embed for a game, rendered by discord.tmpl
+// when the message templates have one
+func createGameEmbed(game Game, tmpl *DiscordTemplate) DiscordEmbed {
+	if embed, ok, err := messageTemplates.discordEmbed(game); ok {
+		if err == nil {
+			if embed.Timestamp == "" {
+				embed.Timestamp = time.Now().Format(time.RFC3339)
+			}
+			return embed
+		}
+		log.Printf("Warning: %v, using the built-in embed", err)
+	}
+
+	// Create embed, colored based on game status
 	embed := DiscordEmbed{
-		Title:       game.Title,
-		Description: game.Description,
+		Title:       tmpl.render(tmpl.title, game),
+		Description: tmpl.render(tmpl.description, game),
 		URL:         game.URL,
-		Color:       color,
+		Color:       tmpl.color(game.Status),
 		Timestamp:   time.Now().Format(time.RFC3339),
 		Fields:      []DiscordEmbedField{},
 	}
@@ -121,35 +455,94 @@ func createGameEmbed(game Game) DiscordEmbed {
 	// Add publisher field if available
 	if game.Publisher != "" {
 		embed.Fields = append(embed.Fields, DiscordEmbedField{
-			Name:   "Publisher",
+			Name:   tmpl.Labels.Publisher,
 			Value:  game.Publisher,
 			Inline: true,
 		})
 	}
 
 	// Add status field
-	statusText := "Currently Free"
-	if game.Status == "coming soon" {
-		statusText = "Coming Soon"
+	statusText := tmpl.Labels.StatusFree
+	if game.Status == "mystery" {
+		statusText = tmpl.Labels.StatusMystery
+	} else if isUpcoming(game) {
+		statusText = tmpl.Labels.StatusUpcoming
 	}
 	embed.Fields = append(embed.Fields, DiscordEmbedField{
-		Name:   "Status",
+		Name:   tmpl.Labels.Status,
 		Value:  statusText,
 		Inline: true,
 	})
 
+	// Add what the game normally costs, unless it's always free
+	if !isFreePrice(game.OriginalPrice) {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Price,
+			Value:  fmt.Sprintf("Normally %s — 100%% off", game.OriginalPrice),
+			Inline: true,
+		})
+	}
+
+	if len(game.BundleContents) > 0 {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Includes,
+			Value:  truncateRunes(strings.Join(game.BundleContents, "\n"), 1024),
+			Inline: false,
+		})
+	}
+
+	if score := criticScoreText(game); score != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Score,
+			Value:  score,
+			Inline: true,
+		})
+	}
+
+	if playtime := playtimeText(game); playtime != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Playtime,
+			Value:  playtime,
+			Inline: true,
+		})
+	}
+
+	if hint := compatibilityText(game); hint != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Linux,
+			Value:  hint,
+			Inline: true,
+		})
+	}
+
+	if game.regionWarning != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Region,
+			Value:  "⚠️ " + game.regionWarning,
+			Inline: true,
+		})
+	}
+
+	if game.TrailerURL != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   tmpl.Labels.Trailer,
+			Value:  fmt.Sprintf("[Watch Trailer](%s)", game.TrailerURL),
+			Inline: true,
+		})
+	}
+
 	// Add dates fields if they're not unknown
 	if game.StartDate != "Unknown" {
 		embed.Fields = append(embed.Fields, DiscordEmbedField{
-			Name:   "Available From",
-			Value:  game.StartDate,
+			Name:   tmpl.Labels.AvailableFrom,
+			Value:  withRelativeTime(game.StartDate, game.startTime, game.DatePrecision),
 			Inline: false,
 		})
 	}
 	if game.EndDate != "Unknown" {
 		embed.Fields = append(embed.Fields, DiscordEmbedField{
-			Name:   "Available Until",
-			Value:  game.EndDate,
+			Name:   tmpl.Labels.AvailableUntil,
+			Value:  withRelativeTime(game.EndDate, game.endTime, game.DatePrecision),
 			Inline: false,
 		})
 	}
@@ -161,20 +554,12 @@ func createGameEmbed(game Game) DiscordEmbed {
 		}
 	}
 
-	// Add footer with date precision
-	precisionText := ""
-	switch game.DatePrecision {
-	case "exact":
-		precisionText = "Dates are exact"
-	case "estimated":
-		precisionText = "Dates are estimated"
-	case "unknown":
-		precisionText = "Dates are unknown"
-	}
-	
-	embed.Footer = &DiscordEmbedFooter{
-		Text: precisionText,
+	// Add footer, by default describing the date precision
+	if footer := tmpl.render(tmpl.footer, game); footer != "" {
+		embed.Footer = &DiscordEmbedFooter{
+			Text: footer,
+		}
 	}
 
 	return embed
-} 
\ No newline at end of file
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// discordAPIBase is the Discord REST API used with bot tokens
+const discordAPIBase = "https://discord.com/api/v10"
+
+// DiscordBotNotifier posts notifications to a channel with a bot token. Unlike
+// plain webhooks, bot messages can carry link buttons.
+type DiscordBotNotifier struct {
+	Token     string
+	ChannelID string
+	Options   DiscordOptions
+	tracker   *SeenTracker
+}
+
+// NewDiscordBotNotifier creates a notifier posting to channelID as the bot
+// with the given token. Link buttons are always attached.
+func NewDiscordBotNotifier(token, channelID string, options DiscordOptions, tracker *SeenTracker) *DiscordBotNotifier {
+	options.LinkButtons = true
+	return &DiscordBotNotifier{
+		Token:     token,
+		ChannelID: channelID,
+		Options:   options,
+		tracker:   tracker,
+	}
+}
+
+// Name returns the channel identifier
+func (d *DiscordBotNotifier) Name() string {
+	return "discord-bot"
+}
+
+// pendingGames returns the games not announced yet
+func (d *DiscordBotNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return d.tracker.Unseen(games), d.tracker
+}
+
+// Notify posts the games that haven't been announced yet to the channel
+func (d *DiscordBotNotifier) Notify(ctx context.Context, games []Game) error {
+	result, err := sendDiscordMessages(d.tracker.Unseen(games), d.Options, func(message DiscordWebhookMessage) error {
+		return d.post(ctx, message)
+	})
+
+	// Games that weren't delivered are left for the next run
+	if markErr := d.tracker.MarkSeen(result.Delivered...); markErr != nil {
+		log.Printf("Warning: %v", markErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%d of %d games delivered: %v", len(result.Delivered), len(result.Delivered)+len(result.Failed), err)
+	}
+	return nil
+}
+
+// post sends a message to the channel, or to the configured thread. In forum
+// channels a new post is started with the message.
+func (d *DiscordBotNotifier) post(ctx context.Context, message DiscordWebhookMessage) error {
+	channelID := d.ChannelID
+	if d.Options.ThreadID != "" {
+		channelID = d.Options.ThreadID
+	}
+
+	endpoint := fmt.Sprintf("%s/channels/%s/messages", discordAPIBase, channelID)
+	var body interface{} = message
+	if message.ThreadName != "" {
+		endpoint = fmt.Sprintf("%s/channels/%s/threads", discordAPIBase, channelID)
+		name := message.ThreadName
+		message.ThreadName = ""
+		body = map[string]interface{}{
+			"name":    name,
+			"message": message,
+		}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshaling Discord message: %v", err)
+	}
+	_, err = discordRequest(ctx, "POST", endpoint, "Bot "+d.Token, payload, nil)
+	return err
+}
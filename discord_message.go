@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// discordMessageState remembers the webhook message that is edited in place
+// and the lineup it currently shows
+type discordMessageState struct {
+	mu        sync.Mutex
+	path      string
+	MessageID string `json:"message_id"`
+	ThreadID  string `json:"thread_id,omitempty"` // Forum post holding the message
+	Lineup    string `json:"lineup"`
+}
+
+// current reports whether the message already shows the lineup of games
+func (s *discordMessageState) current(games []Game) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MessageID != "" && discordLineup(games) == s.Lineup
+}
+
+// loadDiscordMessageState loads the state from path, if it exists. An empty
+// path keeps the state in memory only.
+func loadDiscordMessageState(path string) *discordMessageState {
+	state := &discordMessageState{path: path}
+	if path == "" {
+		return state
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Error reading Discord message state %s: %v", path, err)
+		}
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		log.Printf("Warning: Error parsing Discord message state %s: %v", path, err)
+	}
+	return state
+}
+
+// save persists the state, the caller must hold the lock
+func (s *discordMessageState) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling Discord message state: %v", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing Discord message state: %v", err)
+	}
+	return nil
+}
+
+// discordLineup summarizes the games shown in the message, so unchanged
+// lineups don't cause edits
+func discordLineup(games []Game) string {
+	keys := make([]string, 0, len(games))
+	for _, game := range games {
+		keys = append(keys, gameKey(game)+"|"+game.Status)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\n")
+}
+
+// UpdateDiscordMessage keeps a single "Current Free Games" webhook message up
+// to date, editing it whenever the lineup changes instead of posting a new
+// message every run. A new message is posted the first time, or when the
+// previous one was deleted.
+func UpdateDiscordMessage(ctx context.Context, webhookURL string, games []Game, options DiscordOptions, state *discordMessageState) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	lineup := discordLineup(games)
+	if state.MessageID != "" && lineup == state.Lineup {
+		return nil
+	}
+
+	tmpl := options.template()
+
+	embeds := []DiscordEmbed{}
+	for i, game := range games {
+		if i >= discordMaxEmbeds {
+			break
+		}
+		embeds = append(embeds, createGameEmbed(game, tmpl))
+	}
+
+	content := tmpl.render(tmpl.lineupContent, newDiscordContentData(games))
+	content += fmt.Sprintf("\nLast updated <t:%d:R>", time.Now().Unix())
+
+	// Embeds are always sent, an empty list clears the previous lineup
+	message := map[string]interface{}{
+		"content":          content,
+		"embeds":           embeds,
+		"allowed_mentions": DiscordAllowedMentions{Parse: []string{}},
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling webhook message: %v", err)
+	}
+
+	threadID := options.ThreadID
+	if options.ForumPosts {
+		threadID = state.ThreadID
+	}
+
+	if state.MessageID != "" {
+		status, err := discordWebhookRequest(ctx, "PATCH", discordThreadURL(webhookURL, threadID), "/messages/"+state.MessageID, payload, nil)
+		if err == nil {
+			state.Lineup = lineup
+			return state.save()
+		}
+		if status != http.StatusNotFound {
+			return err
+		}
+		log.Printf("Discord message %s no longer exists, posting a new one", state.MessageID)
+	}
+
+	// In forum channels the message starts a new post, whose thread is needed
+	// to edit it later
+	if options.ForumPosts {
+		message["thread_name"] = "Current Free Games"
+		if payload, err = json.Marshal(message); err != nil {
+			return fmt.Errorf("error marshaling webhook message: %v", err)
+		}
+	}
+
+	var created struct {
+		ID        string `json:"id"`
+		ChannelID string `json:"channel_id"`
+	}
+	if _, err := discordWebhookRequest(ctx, "POST", discordThreadURL(webhookURL, options.ThreadID), "", payload, &created); err != nil {
+		return err
+	}
+
+	state.MessageID = created.ID
+	if options.ForumPosts {
+		state.ThreadID = created.ChannelID
+	}
+	state.Lineup = lineup
+	return state.save()
+}
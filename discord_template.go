@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DiscordTemplateConfig is the JSON template file format used to restyle
+// Discord notifications. Every field is optional and falls back to the
+// default. Content, LineupContent, Title, Description and Footer are Go
+// text/templates.
+type DiscordTemplateConfig struct {
+	// Message content, executed with .Games, .Count and .Stores (e.g. "Epic Games Store and GOG")
+	Content string `json:"content"`
+	// Content of the edit-in-place lineup message, executed with .Games, .Count and .Stores
+	LineupContent string `json:"lineup_content"`
+	// Embed title, description and footer, executed with the Game
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Footer      string `json:"footer"`
+	// Embed colors by status ("free", "coming soon", "mystery") plus "default", as
+	// "#RRGGBB" or a decimal number
+	Colors map[string]string `json:"colors"`
+	// Field labels and status texts
+	Labels DiscordLabels `json:"labels"`
+}
+
+// DiscordLabels are the field names and status texts used in embeds
+type DiscordLabels struct {
+	Publisher      string `json:"publisher"`
+	Status         string `json:"status"`
+	AvailableFrom  string `json:"available_from"`
+	AvailableUntil string `json:"available_until"`
+	Price          string `json:"price"`
+	Score          string `json:"score"`
+	Playtime       string `json:"playtime"`
+	Linux          string `json:"linux"`
+	Trailer        string `json:"trailer"`
+	Region         string `json:"region"`
+	Includes       string `json:"includes"`
+	StatusFree     string `json:"status_free"`
+	StatusUpcoming string `json:"status_upcoming"`
+	StatusMystery  string `json:"status_mystery"`
+}
+
+// DiscordTemplate is a loaded and compiled DiscordTemplateConfig
+type DiscordTemplate struct {
+	content       *template.Template
+	lineupContent *template.Template
+	title         *template.Template
+	description   *template.Template
+	footer        *template.Template
+	colors        map[string]int
+	Labels        DiscordLabels
+}
+
+// discordContentData is passed to the content templates
+type discordContentData struct {
+	Games  []Game
+	Count  int
+	Stores string // Names of the stores of the games
+}
+
+// newDiscordContentData prepares the content template data for games
+func newDiscordContentData(games []Game) discordContentData {
+	return discordContentData{Games: games, Count: len(games), Stores: storesName(games)}
+}
+
+// defaultDiscordTemplateConfig reproduces the built-in notification style
+func defaultDiscordTemplateConfig() DiscordTemplateConfig {
+	return DiscordTemplateConfig{
+		Content:       "🎮 Free Games from {{ .Stores }} 🎮",
+		LineupContent: "{{ if .Count }}🎮 Current Free Games on {{ .Stores }} 🎮{{ else }}🎮 No free games on {{ .Stores }} right now 🎮{{ end }}",
+		Title:         "{{ .Title }}",
+		Description:   "{{ .Description }}",
+		Footer: `{{ if eq .DatePrecision "exact" }}Dates are exact{{ else if eq .DatePrecision "estimated" }}Dates are estimated` +
+			`{{ else if eq .DatePrecision "unknown" }}Dates are unknown{{ end }}`,
+		Colors: map[string]string{
+			"default":     "#0078F2", // Epic Games blue color
+			"free":        "#2ECC71", // Green color for free games
+			"coming soon": "#F1C40F", // Yellow color for upcoming games
+			"mystery":     "#9B59B6", // Purple color for mystery games
+		},
+		Labels: DiscordLabels{
+			Publisher:      "Publisher",
+			Status:         "Status",
+			AvailableFrom:  "Available From",
+			AvailableUntil: "Available Until",
+			Price:          "Price",
+			Score:          "Score",
+			Playtime:       "Length",
+			Linux:          "Linux",
+			Trailer:        "Trailer",
+			Region:         "Region",
+			Includes:       "Includes",
+			StatusFree:     "Currently Free",
+			StatusUpcoming: "Coming Soon",
+			StatusMystery:  "Mystery Game",
+		},
+	}
+}
+
+// defaultDiscordTemplate is used when no template file is configured
+var defaultDiscordTemplate = mustCompileDiscordTemplate(defaultDiscordTemplateConfig())
+
+// LoadDiscordTemplate reads a JSON template file, filling unset fields with
+// the defaults
+func LoadDiscordTemplate(path string) (*DiscordTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Discord template: %v", err)
+	}
+
+	var config DiscordTemplateConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing Discord template: %v", err)
+	}
+
+	defaults := defaultDiscordTemplateConfig()
+	for _, field := range []struct{ value, fallback *string }{
+		{&config.Content, &defaults.Content},
+		{&config.LineupContent, &defaults.LineupContent},
+		{&config.Title, &defaults.Title},
+		{&config.Description, &defaults.Description},
+		{&config.Footer, &defaults.Footer},
+		{&config.Labels.Publisher, &defaults.Labels.Publisher},
+		{&config.Labels.Status, &defaults.Labels.Status},
+		{&config.Labels.AvailableFrom, &defaults.Labels.AvailableFrom},
+		{&config.Labels.AvailableUntil, &defaults.Labels.AvailableUntil},
+		{&config.Labels.Price, &defaults.Labels.Price},
+		{&config.Labels.StatusFree, &defaults.Labels.StatusFree},
+		{&config.Labels.StatusUpcoming, &defaults.Labels.StatusUpcoming},
+		{&config.Labels.StatusMystery, &defaults.Labels.StatusMystery},
+	} {
+		if *field.value == "" {
+			*field.value = *field.fallback
+		}
+	}
+	for status, color := range defaults.Colors {
+		if _, ok := config.Colors[status]; !ok {
+			if config.Colors == nil {
+				config.Colors = map[string]string{}
+			}
+			config.Colors[status] = color
+		}
+	}
+
+	tmpl, err := compileDiscordTemplate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catch templates referring to unknown fields at startup rather than
+	// when the first notification is sent
+	sample := Game{Title: "Sample", Status: "free", StartDate: "Unknown", EndDate: "Unknown", DatePrecision: "unknown"}
+	for name, t := range map[string]*template.Template{"title": tmpl.title, "description": tmpl.description, "footer": tmpl.footer} {
+		if err := t.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, fmt.Errorf("error in Discord %s template: %v", name, err)
+		}
+	}
+	for name, t := range map[string]*template.Template{"content": tmpl.content, "lineup_content": tmpl.lineupContent} {
+		if err := t.Execute(&bytes.Buffer{}, newDiscordContentData([]Game{sample})); err != nil {
+			return nil, fmt.Errorf("error in Discord %s template: %v", name, err)
+		}
+	}
+
+	return tmpl, nil
+}
+
+func compileDiscordTemplate(config DiscordTemplateConfig) (*DiscordTemplate, error) {
+	tmpl := &DiscordTemplate{
+		colors: map[string]int{},
+		Labels: config.Labels,
+	}
+
+	for _, field := range []struct {
+		name   string
+		source string
+		target **template.Template
+	}{
+		{"content", config.Content, &tmpl.content},
+		{"lineup_content", config.LineupContent, &tmpl.lineupContent},
+		{"title", config.Title, &tmpl.title},
+		{"description", config.Description, &tmpl.description},
+		{"footer", config.Footer, &tmpl.footer},
+	} {
+		parsed, err := template.New(field.name).Funcs(templateFuncs).Parse(field.source)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Discord %s template: %v", field.name, err)
+		}
+		*field.target = parsed
+	}
+
+	for status, value := range config.Colors {
+		color, err := parseDiscordColor(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Discord color for %q: %v", status, err)
+		}
+		tmpl.colors[status] = color
+	}
+
+	return tmpl, nil
+}
+
+func mustCompileDiscordTemplate(config DiscordTemplateConfig) *DiscordTemplate {
+	tmpl, err := compileDiscordTemplate(config)
+	if err != nil {
+		panic(err)
+	}
+	return tmpl
+}
+
+// parseDiscordColor parses "#RRGGBB", "0xRRGGBB" or a decimal color value
+func parseDiscordColor(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	base := 10
+	if strings.HasPrefix(value, "#") {
+		value, base = value[1:], 16
+	} else if strings.HasPrefix(strings.ToLower(value), "0x") {
+		value, base = value[2:], 16
+	}
+	color, err := strconv.ParseInt(value, base, 32)
+	if err != nil {
+		return 0, err
+	}
+	return int(color), nil
+}
+
+// color returns the embed color for a game status
+func (t *DiscordTemplate) color(status string) int {
+	if color, ok := t.colors[status]; ok {
+		return color
+	}
+	return t.colors["default"]
+}
+
+// render executes a template, logging failures instead of failing the
+// whole notification
+func (t *DiscordTemplate) render(tmpl *template.Template, data interface{}) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Warning: Error rendering Discord %s template: %v", tmpl.Name(), err)
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,11 @@
+// Command epic-games-api serves the free games of the Epic Games Store and
+// other stores over HTTP and gRPC, and announces new ones on the
+// notification channels.
+//
+// The code other Go programs can import lives under pkg: pkg/epicgames fetches
+// Epic's catalog, which this package turns into free games, and pkg/client
+// calls the REST API of a running instance. The notification channels, the HTTP handlers and the
+// state stores stay in this package. They share process-wide state (the
+// state database, the outbound transport, the message templates and the
+// circuit breakers) set up from the flags in main, so they aren't a library.
+package main
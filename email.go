@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends the games as a plain-text email over SMTP
+type EmailNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// ImplicitTLS connects with TLS straight away (usually port 465) instead of
+	// upgrading the connection with STARTTLS
+	ImplicitTLS bool
+	tracker     *SeenTracker // Remembers the games already emailed
+}
+
+// Name returns the channel identifier
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// pendingGames returns the games not announced yet
+func (e *EmailNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return e.tracker.Unseen(games), e.tracker
+}
+
+// Notify emails the list of games not announced yet to every recipient
+func (e *EmailNotifier) Notify(ctx context.Context, games []Game) error {
+	return notifyUnseen(e.tracker, games, 0, func(games []Game) error {
+		return e.send(ctx, games)
+	})
+}
+
+// send emails the list of games in a single message
+func (e *EmailNotifier) send(ctx context.Context, games []Game) error {
+	message, err := e.buildMessage(games)
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(e.Host, e.Port)
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	if e.ImplicitTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: e.Host}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to SMTP server: %v", err)
+	}
+	// net/smtp doesn't take a context, the connection is closed when ctx ends
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error creating SMTP client: %v", err)
+	}
+	defer client.Close()
+
+	if !e.ImplicitTLS {
+		// Upgrade to STARTTLS when the server supports it, like smtp.SendMail
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: e.Host}); err != nil {
+				return fmt.Errorf("error starting TLS: %v", err)
+			}
+		}
+	}
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("error authenticating with SMTP server: %v", err)
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return fmt.Errorf("error setting sender: %v", err)
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("error setting recipient %s: %v", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error starting message: %v", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("error writing message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage renders the email headers and the plain-text body
+func (e *EmailNotifier) buildMessage(games []Game) ([]byte, error) {
+	body, err := messageTemplates.Render(e.Name(), formatText, games)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("From: %s\r\n", e.From))
+	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(e.To, ", ")))
+	sb.WriteString(fmt.Sprintf("Subject: %d Free Games from %s\r\n", len(games), storesName(games)))
+	sb.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	// SMTP lines end with CRLF, templates are written with plain newlines
+	sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(sb.String()), nil
+}
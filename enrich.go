@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Enricher adds data from an outside source to the fetched games, e.g.
+// critic scores. Failed lookups are logged and leave the games as they are.
+type Enricher interface {
+	Name() string
+	Enrich(ctx context.Context, games []Game)
+}
+
+// enrichers run in order after every fetch, set up in main from the flags
+var enrichers []Enricher
+
+// enrichTimeout bounds running all the enrichers after a fetch, set by
+// -enrich-timeout. Lookups still running then fail and are tried again on the
+// next fetch.
+var enrichTimeout = 30 * time.Second
+
+// enrichGames runs every enricher over the games
+func enrichGames(ctx context.Context, games []Game) {
+	ctx, cancel := withStageTimeout(ctx, enrichTimeout)
+	defer cancel()
+
+	for _, enricher := range enrichers {
+		enricher.Enrich(ctx, games)
+	}
+}
+
+// titleCache caches the lookups of an enricher by lowercase title, persisted
+// as JSON when a path is set so weekly runs don't query the same titles again
+type titleCache[T any] struct {
+	mu      sync.Mutex
+	name    string // Source name used in log messages, e.g. "OpenCritic"
+	path    string
+	entries map[string]*T
+}
+
+// newTitleCache creates a cache, loading it from path if it exists. An empty
+// path keeps the cache in memory only.
+func newTitleCache[T any](name, path string) *titleCache[T] {
+	c := &titleCache[T]{
+		name:    name,
+		path:    path,
+		entries: make(map[string]*T),
+	}
+
+	if path == "" {
+		return c
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Error reading %s cache file %s: %v", name, path, err)
+		}
+		return c
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		log.Printf("Warning: Error parsing %s cache file %s: %v", name, path, err)
+	}
+	return c
+}
+
+// titleKey is the cache key of a game title
+func titleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// minTitleSimilarity is how close a title found by a search has to be to
+// the store title, so "Game" doesn't get the data of "Game 2"
+const minTitleSimilarity = 0.85
+
+// normalizeTitle lowercases a title and drops everything but letters, digits
+// and single spaces, so "DOOM® Eternal" matches "Doom Eternal"
+func normalizeTitle(title string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteRune(r)
+			space = false
+		case unicode.IsSpace(r) || r == '-' || r == ':' || r == '_':
+			space = true
+		}
+	}
+	return sb.String()
+}
+
+// titleSimilarity compares two titles after normalizing them, from 0 for
+// nothing in common to 1 for the same title
+func titleSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeTitle(a)), []rune(normalizeTitle(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+
+	// Levenshtein distance, keeping one row of the matrix
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return 1 - float64(row[len(rb)])/float64(longest)
+}
+
+// save persists the cache, the caller must hold the lock
+func (c *titleCache[T]) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling %s cache: %v", c.name, err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing %s cache file: %v", c.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Export formats of the free games list besides JSON
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatXML  = "xml"
+)
+
+// exportContentTypes are the media types of each format
+var exportContentTypes = map[string]string{
+	formatJSON: "application/json",
+	formatCSV:  "text/csv; charset=utf-8",
+	formatXML:  "application/xml; charset=utf-8",
+}
+
+// negotiateFormat picks the response format from the format parameter, or
+// from the Accept header when the parameter doesn't name one
+func negotiateFormat(format, accept string) string {
+	switch strings.ToLower(format) {
+	case formatCSV:
+		return formatCSV
+	case formatXML:
+		return formatXML
+	}
+
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		var candidate string
+		switch mediaType {
+		case "application/json", "*/*":
+			candidate = formatJSON
+		case "text/csv":
+			candidate = formatCSV
+		case "application/xml", "text/xml":
+			candidate = formatXML
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+	return best
+}
+
+// exportField is one Game field selected for export
+type exportField struct {
+	name      string
+	index     int
+	omitEmpty bool
+	item      string // Element name of the items of a list in XML
+}
+
+// exportItemNames names the XML elements of the items of each list field
+var exportItemNames = map[string]string{
+	"genres":                "genre",
+	"tags":                  "tag",
+	"platforms":             "platform",
+	"screenshots":           "screenshot",
+	"available_countries":   "country",
+	"unavailable_countries": "country",
+	"bundle_contents":       "title",
+}
+
+// exportFields lists the Game fields to export in struct order, honoring the
+// field mask
+func exportFields(mask fieldMask) []exportField {
+	var fields []exportField
+	t := reflect.TypeOf(Game{})
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || (mask != nil && !mask[name]) {
+			continue
+		}
+		fields = append(fields, exportField{name: name, index: i, omitEmpty: mask == nil && opts == "omitempty", item: exportItemNames[name]})
+	}
+	return fields
+}
+
+// encodeCSV writes one row per game with a header row of the JSON field
+// names. List fields are joined with "; ".
+func encodeCSV(games []Game, mask fieldMask) ([]byte, error) {
+	fields := exportFields(mask)
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+	}
+	writer.Write(header)
+
+	for _, game := range games {
+		v := reflect.ValueOf(game)
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			value := v.Field(field.index)
+			if value.Kind() == reflect.Slice {
+				row[i] = csvSafe(strings.Join(value.Interface().([]string), "; "))
+			} else {
+				row[i] = csvSafe(exportValue(value))
+			}
+		}
+		writer.Write(row)
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// exportValue formats a scalar field, leaving zero numbers empty like the
+// JSON leaves them out
+func exportValue(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int64:
+		if value.Int() == 0 {
+			return ""
+		}
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Float64:
+		if value.Float() == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	}
+	return value.String()
+}
+
+// csvSafe keeps spreadsheets from evaluating cells as formulas
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// encodeXML writes the response as a <response> element with a <game> element
+// per game, whose children are named like the JSON fields
+func encodeXML(response APIResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+
+	attr := func(name, value string) xml.Attr {
+		return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+	}
+	root := xml.StartElement{Name: xml.Name{Local: "response"}, Attr: []xml.Attr{
+		attr("success", strconv.FormatBool(response.Success)),
+		attr("schema_version", strconv.Itoa(response.SchemaVersion)),
+		attr("count", strconv.Itoa(response.Count)),
+		attr("total", strconv.Itoa(response.Total)),
+		attr("offset", strconv.Itoa(response.Offset)),
+	}}
+	if response.Limit > 0 {
+		root.Attr = append(root.Attr, attr("limit", strconv.Itoa(response.Limit)))
+	}
+	if response.Source != "" {
+		root.Attr = append(root.Attr, attr("source", response.Source))
+	}
+	if response.Stale {
+		root.Attr = append(root.Attr, attr("stale", "true"))
+	}
+	if response.Degraded {
+		root.Attr = append(root.Attr, attr("degraded", "true"))
+	}
+	root.Attr = append(root.Attr, attr("age", strconv.Itoa(response.Age)))
+	enc.EncodeToken(root)
+	if response.Message != "" {
+		enc.EncodeElement(response.Message, xml.StartElement{Name: xml.Name{Local: "message"}})
+	}
+
+	fields := exportFields(response.fields)
+	for _, game := range response.Data {
+		gameElement := xml.StartElement{Name: xml.Name{Local: "game"}}
+		enc.EncodeToken(gameElement)
+		v := reflect.ValueOf(game)
+		for _, field := range fields {
+			value := v.Field(field.index)
+			if field.omitEmpty && value.IsZero() {
+				continue
+			}
+			element := xml.StartElement{Name: xml.Name{Local: field.name}}
+			if value.Kind() == reflect.Slice {
+				// genres => <genres><genre>...</genre></genres>
+				item := xml.StartElement{Name: xml.Name{Local: field.item}}
+				enc.EncodeToken(element)
+				for _, s := range value.Interface().([]string) {
+					enc.EncodeElement(s, item)
+				}
+				enc.EncodeToken(element.End())
+			} else {
+				enc.EncodeElement(exportValue(value), element)
+			}
+		}
+		enc.EncodeToken(gameElement.End())
+	}
+
+	enc.EncodeToken(root.End())
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+// exportGame has numeric and list fields set, and a zero playtime
+var exportGame = Game{
+	Title:        "Control",
+	Status:       "free",
+	Store:        "epic",
+	CriticScore:  85,
+	ReleaseYear:  2019,
+	PlaytimeMain: 11.5,
+	AvailableIn:  []string{"US", "DE"},
+}
+
+func TestEncodeCSVNumbers(t *testing.T) {
+	mask, err := parseFieldMask("title,critic_score,release_year,playtime_main,playtime_completionist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := encodeCSV([]Game{exportGame}, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Control", "85", "2019", "11.5", ""}
+	if len(rows) != 2 || strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Errorf("rows = %q, want a header and %q", rows, want)
+	}
+}
+
+func TestEncodeXMLNumbersAndLists(t *testing.T) {
+	body, err := encodeXML(APIResponse{Success: true, Count: 1, Total: 1, Data: []Game{exportGame}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<critic_score>85</critic_score>",
+		"<release_year>2019</release_year>",
+		"<playtime_main>11.5</playtime_main>",
+		"<available_countries>",
+		"<country>US</country>",
+		"<country>DE</country>",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("XML is missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(string(body), "playtime_completionist") {
+		t.Errorf("XML has the zero playtime_completionist:\n%s", body)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// googleServiceAccount is the subset of a service-account key file we need
+type googleServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMNotifier publishes new free games to a Firebase Cloud Messaging topic
+type FCMNotifier struct {
+	Topic   string
+	account googleServiceAccount
+	key     *rsa.PrivateKey
+	tracker *SeenTracker
+	client  *http.Client
+
+	tokenMu     sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// NewFCMNotifier creates an FCM notifier from a service-account JSON key file.
+// Games already published are remembered by tracker.
+func NewFCMNotifier(serviceAccountFile, topic string, tracker *SeenTracker) (*FCMNotifier, error) {
+	data, err := os.ReadFile(serviceAccountFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading service account file: %v", err)
+	}
+
+	var account googleServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("error parsing service account file: %v", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("service account private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing service account private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("service account private key is not an RSA key")
+	}
+
+	return &FCMNotifier{
+		Topic:   topic,
+		account: account,
+		key:     key,
+		tracker: tracker,
+		client:  newHTTPClient(10 * time.Second),
+	}, nil
+}
+
+// Name returns the channel identifier
+func (f *FCMNotifier) Name() string {
+	return "fcm"
+}
+
+// pendingGames returns the games not announced yet
+func (f *FCMNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return f.tracker.Unseen(games), f.tracker
+}
+
+// Notify publishes one message per new game to the topic
+func (f *FCMNotifier) Notify(ctx context.Context, games []Game) error {
+	unseen := f.tracker.Unseen(games)
+	if len(unseen) == 0 {
+		return nil
+	}
+
+	token, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, game := range unseen {
+		if err := f.send(ctx, token, game); err != nil {
+			return fmt.Errorf("error publishing %s: %v", game.Title, err)
+		}
+		if err := f.tracker.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+func (f *FCMNotifier) send(ctx context.Context, token string, game Game) error {
+	body := "Free now " + onStore(game)
+	if isUpcoming(game) {
+		body = "Coming soon for free " + onStore(game)
+	}
+	if game.EndDate != "Unknown" {
+		body += " until " + game.EndDate
+	}
+
+	message := map[string]interface{}{
+		"message": map[string]interface{}{
+			"topic": f.Topic,
+			"notification": map[string]string{
+				"title": game.Title,
+				"body":  body,
+				"image": game.ImageURL,
+			},
+			// Data values must be strings; apps can use them to deep link
+			"data": map[string]string{
+				"title":      game.Title,
+				"url":        game.URL,
+				"status":     game.Status,
+				"start_date": game.StartDate,
+				"end_date":   game.EndDate,
+			},
+		},
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling FCM message: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", f.account.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("error creating FCM request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending FCM request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("FCM returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// token returns a cached OAuth2 access token, exchanging a freshly signed JWT
+// assertion for a new one when it is about to expire
+func (f *FCMNotifier) token(ctx context.Context) (string, error) {
+	f.tokenMu.Lock()
+	defer f.tokenMu.Unlock()
+
+	if f.accessToken != "" && time.Until(f.tokenExpiry) > time.Minute {
+		return f.accessToken, nil
+	}
+
+	assertion, err := f.signAssertion()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", f.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting access token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error decoding token response: %v", err)
+	}
+
+	f.accessToken = result.AccessToken
+	f.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return f.accessToken, nil
+}
+
+// signAssertion builds the RS256 signed JWT used to request an access token
+func (f *FCMNotifier) signAssertion() (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   f.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   f.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing token assertion: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
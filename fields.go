@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldMask selects the Game fields returned by the API, by JSON name. A nil
+// mask returns every field.
+type fieldMask map[string]bool
+
+// gameJSONFields maps the JSON names of Game to their struct field index
+var gameJSONFields = func() map[string]int {
+	fields := map[string]int{}
+	t := reflect.TypeOf(Game{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// parseFieldMask parses a comma-separated fields parameter such as
+// "title,url,end_date"
+func parseFieldMask(value string) (fieldMask, error) {
+	if value == "" {
+		return nil, nil
+	}
+	mask := fieldMask{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := gameJSONFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		mask[name] = true
+	}
+	return mask, nil
+}
+
+// encodeGame marshals only the masked fields of a game, in struct order.
+// Selected fields are always included, even when empty.
+func (m fieldMask) encodeGame(game Game) (json.RawMessage, error) {
+	if m == nil {
+		return json.Marshal(game)
+	}
+
+	v := reflect.ValueOf(game)
+	t := v.Type()
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if !m[name] {
+			continue
+		}
+		value, err := json.Marshal(v.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON encodes the response, applying the field mask to the games
+func (r APIResponse) MarshalJSON() ([]byte, error) {
+	type plain APIResponse
+	if r.fields == nil {
+		return json.Marshal(plain(r))
+	}
+
+	data := make([]json.RawMessage, 0, len(r.Data))
+	for _, game := range r.Data {
+		encoded, err := r.fields.encodeGame(game)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, encoded)
+	}
+
+	return json.Marshal(struct {
+		plain
+		Data []json.RawMessage `json:"data"`
+	}{plain(r), data})
+}
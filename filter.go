@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GameFilter restricts which games a notification channel receives. The zero
+// value lets every game through.
+type GameFilter struct {
+	Status           string   `json:"status"`             // "free", "coming soon" or "mystery", empty for all
+	ExcludeDLC       bool     `json:"exclude_dlc"`        // Skip add-ons and DLC
+	OnlyDLC          bool     `json:"only_dlc"`           // Only add-ons and DLC
+	MinOriginalPrice float64  `json:"min_original_price"` // Minimum regular price in the store currency
+	Genres           []string `json:"genres"`             // Allowed genres, case-insensitive
+	OfferKinds       []string `json:"offer_kinds"`        // Allowed offer kinds, e.g. "giveaway", empty for all
+	Stores           []string `json:"stores"`             // Allowed stores, e.g. "gog", empty for all
+	Platforms        []string `json:"platforms"`          // Allowed platforms, e.g. "PC" or "PS5", empty for all
+	Region           string   `json:"region"`             // Country of the audience, games not free there get a warning
+}
+
+// Match reports whether the game passes the filter
+func (f GameFilter) Match(game Game) bool {
+	if f.Status != "" && !strings.EqualFold(f.Status, game.Status) {
+		return false
+	}
+	if f.ExcludeDLC && isDLC(game) {
+		return false
+	}
+	if f.OnlyDLC && !isDLC(game) {
+		return false
+	}
+	if f.MinOriginalPrice > 0 && game.originalPrice < f.MinOriginalPrice {
+		return false
+	}
+	if !hasOfferKind(f.OfferKinds, game) {
+		return false
+	}
+	if len(f.Stores) > 0 && !containsFold(f.Stores, gameStore(game)) {
+		return false
+	}
+	if len(f.Platforms) > 0 && !matchesPlatform(f.Platforms, game) {
+		return false
+	}
+	if len(f.Genres) > 0 {
+		for _, allowed := range f.Genres {
+			for _, genre := range game.Genres {
+				if strings.EqualFold(allowed, genre) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Apply returns the games passing the filter
+func (f GameFilter) Apply(games []Game) []Game {
+	var matched []Game
+	for _, game := range games {
+		if f.Match(game) {
+			matched = append(matched, game)
+		}
+	}
+	return matched
+}
+
+// matchesPlatform reports whether the game runs on one of the platforms
+func matchesPlatform(platforms []string, game Game) bool {
+	for _, platform := range game.Platforms {
+		if containsFold(platforms, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDLC reports whether the offer is an add-on rather than a base game
+func isDLC(game Game) bool {
+	switch strings.ToUpper(game.OfferType) {
+	case "ADD_ON", "DLC", "EDITION", "UNLOCKABLE", "DIGITAL_EXTRA":
+		return true
+	}
+	return false
+}
+
+// FilteredNotifier only passes the games matching Filter to the wrapped notifier
+type FilteredNotifier struct {
+	Notifier
+	Filter GameFilter
+}
+
+// Notify announces the matching games, with a warning on those not free in
+// the filter's region. An empty list is still passed on, so lineups edited in
+// place are cleared.
+func (f *FilteredNotifier) Notify(ctx context.Context, games []Game) error {
+	return f.Notifier.Notify(ctx, withRegionWarnings(f.Filter.Apply(games), f.Filter.Region))
+}
+
+// parseNotifyFilters parses the NOTIFY_FILTERS JSON object, which maps channel
+// IDs to filters, e.g. {"discord": {"status": "free", "exclude_dlc": true}}
+func parseNotifyFilters(value string) (map[string]GameFilter, error) {
+	var filters map[string]GameFilter
+	if err := json.Unmarshal([]byte(value), &filters); err != nil {
+		return nil, fmt.Errorf("error parsing filters: %v", err)
+	}
+	for name, filter := range filters {
+		if err := filter.validate(); err != nil {
+			return nil, fmt.Errorf("invalid filter for %s: %v", name, err)
+		}
+	}
+	return filters, nil
+}
+
+// applyNotifyFilters wraps the notifiers that have a filter configured
+func applyNotifyFilters(notifiers []Notifier, filters map[string]GameFilter) []Notifier {
+	wrapped := make([]Notifier, 0, len(notifiers))
+	for _, n := range notifiers {
+		if filter, ok := filters[channelID(n)]; ok {
+			n = &FilteredNotifier{Notifier: n, Filter: filter}
+		}
+		wrapped = append(wrapped, n)
+	}
+	return wrapped
+}
+
+// parseURLFilter reads filter options from the query of a notification URL:
+// status, exclude_dlc, only_dlc, min_price, genres, offer_kinds, stores,
+// platforms (lists separated by "|") and region. ok is false when none are set.
+func parseURLFilter(query url.Values) (filter GameFilter, ok bool, err error) {
+	if status := query.Get("status"); status != "" {
+		filter.Status, ok = status, true
+	}
+	if excludeDLC := query.Get("exclude_dlc"); excludeDLC != "" {
+		filter.ExcludeDLC, ok = parseBool(excludeDLC), true
+	}
+	if onlyDLC := query.Get("only_dlc"); onlyDLC != "" {
+		filter.OnlyDLC, ok = parseBool(onlyDLC), true
+	}
+	if minPrice := query.Get("min_price"); minPrice != "" {
+		if filter.MinOriginalPrice, err = strconv.ParseFloat(minPrice, 64); err != nil {
+			return filter, false, fmt.Errorf("invalid min_price")
+		}
+		ok = true
+	}
+	if genres := query.Get("genres"); genres != "" {
+		filter.Genres, ok = strings.Split(genres, "|"), true
+	}
+	if offerKinds := query.Get("offer_kinds"); offerKinds != "" {
+		filter.OfferKinds, ok = strings.Split(offerKinds, "|"), true
+	}
+	if stores := query.Get("stores"); stores != "" {
+		filter.Stores, ok = strings.Split(stores, "|"), true
+	}
+	if platforms := query.Get("platforms"); platforms != "" {
+		filter.Platforms, ok = strings.Split(platforms, "|"), true
+	}
+	if region := query.Get("region"); region != "" {
+		filter.Region, ok = region, true
+	}
+	return filter, ok, filter.validate()
+}
+
+// validate rejects unknown statuses, offer kinds, stores and regions, and
+// contradicting DLC options
+func (f GameFilter) validate() error {
+	if f.ExcludeDLC && f.OnlyDLC {
+		return fmt.Errorf("exclude_dlc and only_dlc can't both be set")
+	}
+	if f.Region != "" {
+		if err := validateCountry(f.Region); err != nil {
+			return err
+		}
+	}
+	for _, kind := range f.OfferKinds {
+		if err := validateOfferKind(strings.ToLower(kind)); err != nil {
+			return err
+		}
+	}
+	for _, store := range f.Stores {
+		if err := validateStore(strings.ToLower(store)); err != nil {
+			return err
+		}
+	}
+	switch strings.ToLower(f.Status) {
+	case "", "free", "coming soon", "mystery":
+		return nil
+	}
+	return fmt.Errorf("unknown status %q", f.Status)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Fixture modes, selected with -fixture-mode
+const (
+	fixtureReplay = "replay" // Answer Epic's requests with the recorded responses
+	fixtureRecord = "record" // Send Epic's requests and save their responses
+)
+
+// fixture is a recorded exchange with one of Epic's APIs
+type fixture struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	RequestBody string          `json:"request_body,omitempty"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`      // The response, when it's JSON
+	BodyText    string          `json:"body_text,omitempty"` // The response otherwise
+}
+
+// key identifies the request of the fixture
+func (f *fixture) key() string {
+	return fixtureKey(f.Method, f.URL, []byte(f.RequestBody))
+}
+
+// fixtureKey identifies a request by method, URL and body
+func fixtureKey(method, url string, body []byte) string {
+	sum := sha256.Sum256([]byte(method + " " + url + "\n" + string(body)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// fixtureTransport answers the requests to Epic from the fixtures of a
+// directory, or records them there, so the fetch, date parsing and status
+// classification can run against canned responses. Requests to other hosts,
+// like the notification channels, go through base.
+type fixtureTransport struct {
+	dir    string
+	record bool
+	base   http.RoundTripper
+
+	mu       sync.Mutex
+	fixtures map[string]*fixture // By key
+}
+
+// newFixtureTransport loads the fixtures of dir, which must exist when
+// replaying
+func newFixtureTransport(dir, mode string, base http.RoundTripper) (*fixtureTransport, error) {
+	t := &fixtureTransport{dir: dir, base: base, fixtures: map[string]*fixture{}}
+	switch mode {
+	case fixtureReplay:
+	case fixtureRecord:
+		t.record = true
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("error creating fixture directory: %v", err)
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unknown fixture mode %q, expected replay or record", mode)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", dir)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading fixture: %v", err)
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("error parsing fixture %s: %v", filepath.Base(file), err)
+		}
+		t.fixtures[f.key()] = &f
+	}
+	return t, nil
+}
+
+// configureFixtures answers Epic's requests from the fixtures in dir, or
+// records them there. It must be called after configureOutboundProxy.
+func configureFixtures(dir, mode string) error {
+	if dir == "" {
+		return nil
+	}
+	t, err := newFixtureTransport(dir, mode, outboundTransport.base)
+	if err != nil {
+		return err
+	}
+	outboundTransport.base = t
+	if t.record {
+		log.Printf("Recording Epic's responses into %s", dir)
+	} else {
+		log.Printf("Serving Epic's responses from the %d fixtures in %s", len(t.fixtures), dir)
+	}
+	return nil
+}
+
+// isEpicHost reports whether requests to host are answered by fixtures
+func isEpicHost(host string) bool {
+	return host == "epicgames.com" || strings.HasSuffix(host, ".epicgames.com")
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isEpicHost(req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := fixtureKey(req.Method, req.URL.String(), body)
+
+	if !t.record {
+		t.mu.Lock()
+		f := t.fixtures[key]
+		t.mu.Unlock()
+		if f == nil {
+			return nil, fmt.Errorf("no fixture for %s %s", req.Method, req.URL)
+		}
+		return f.response(req), nil
+	}
+
+	// The body was consumed, send a copy of the request with it
+	sent := req.Clone(req.Context())
+	sent.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := t.base.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	f := &fixture{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: string(body),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if json.Valid(respBody) {
+		f.Body = respBody
+	} else {
+		f.BodyText = string(respBody)
+	}
+	if err := t.save(f, req.URL.Hostname()+"-"+key+".json"); err != nil {
+		log.Printf("Warning: Error recording fixture: %v", err)
+	}
+	return resp, nil
+}
+
+// save writes a fixture into the directory
+func (t *fixtureTransport) save(f *fixture, name string) error {
+	// Keep the & of query strings readable
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(f); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fixtures[f.key()] = f
+	return os.WriteFile(filepath.Join(t.dir, name), buf.Bytes(), 0644)
+}
+
+// response builds the recorded response to req
+func (f *fixture) response(req *http.Request) *http.Response {
+	body := []byte(f.Body)
+	if f.Body == nil {
+		body = []byte(f.BodyText)
+	}
+	header := http.Header{}
+	if f.ContentType != "" {
+		header.Set("Content-Type", f.ContentType)
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// replayFixtures answers Epic's requests from the fixtures of dir for the
+// rest of the test
+func replayFixtures(t *testing.T, dir string) {
+	t.Helper()
+	base := outboundTransport.base
+	fixtures, err := newFixtureTransport(dir, fixtureReplay, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outboundTransport.base = fixtures
+	t.Cleanup(func() { outboundTransport.base = base })
+}
+
+// stubTransport answers every request with its body, counting the requests
+type stubTransport struct {
+	body     string
+	requests int
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.requests++
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestFixtureFreeGames(t *testing.T) {
+	replayFixtures(t, "testdata/epic")
+
+	games, warnings, source, err := loadFreeGames(context.Background(), "US", "en-US", true, false, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != sourcePromotions || len(warnings) > 0 {
+		t.Errorf("source %q, warnings %v", source, warnings)
+	}
+
+	// The add-on and the discounted game are dropped
+	want := []struct {
+		title, status, start, end, genre string
+	}{
+		{"Hollow Lantern", "free", "2026-10-15 15:00:00 UTC", "2026-10-22 15:00:00 UTC", "Action"},
+		{"Clockwork Tides", "coming soon", "2026-10-22 15:00:00 UTC", "2026-10-29 15:00:00 UTC", "Strategy"},
+	}
+	if len(games) != len(want) {
+		t.Fatalf("got %d games, want %d", len(games), len(want))
+	}
+	for i, w := range want {
+		game := games[i]
+		if game.Title != w.title || game.Status != w.status {
+			t.Errorf("game %d is %q (%s), want %q (%s)", i, game.Title, game.Status, w.title, w.status)
+		}
+		if game.StartDate != w.start || game.EndDate != w.end || game.DatePrecision != "exact" {
+			t.Errorf("%s runs %s to %s (%s), want %s to %s", game.Title, game.StartDate, game.EndDate, game.DatePrecision, w.start, w.end)
+		}
+		if len(game.Genres) != 1 || game.Genres[0] != w.genre {
+			t.Errorf("%s has genres %v, want %s", game.Title, game.Genres, w.genre)
+		}
+	}
+	if games[0].URL != "https://store.epicgames.com/en-US/p/hollow-lantern" {
+		t.Errorf("URL %s", games[0].URL)
+	}
+	if games[0].ImageURL != "https://cdn1.epicgames.com/offer/lantern/thumb.jpg" {
+		t.Errorf("image URL %s", games[0].ImageURL)
+	}
+}
+
+func TestFixtureTimezone(t *testing.T) {
+	replayFixtures(t, "testdata/epic")
+
+	games, _, _, err := loadFreeGames(context.Background(), "US", "en-US", false, false, "UTC+8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("got %d games, want only the free one", len(games))
+	}
+	if games[0].StartDate != "2026-10-15 23:00:00 UTC+8" || games[0].StartDateISO != "2026-10-15T15:00:00Z" {
+		t.Errorf("starts %s (%s)", games[0].StartDate, games[0].StartDateISO)
+	}
+}
+
+func TestFixtureMessages(t *testing.T) {
+	replayFixtures(t, "testdata/epic")
+
+	games, _, _, err := loadFreeGames(context.Background(), "US", "en-US", true, false, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{formatText, formatMarkdown, formatHTML} {
+		message, err := messageTemplates.Render("", format, games)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		for _, text := range []string{"Hollow Lantern", "Clockwork Tides", "2026-10-22 15:00:00 UTC"} {
+			if !strings.Contains(message, text) {
+				t.Errorf("%s message misses %q:\n%s", format, text, message)
+			}
+		}
+	}
+}
+
+func TestFixtureUnknownRequest(t *testing.T) {
+	fixtures, err := newFixtureTransport("testdata/epic", fixtureReplay, &stubTransport{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "https://store-site-backend-static.ak.epicgames.com/freeGamesPromotions?country=DE", nil)
+	if _, err := fixtures.RoundTrip(req); err == nil {
+		t.Error("expected an error for a request without a fixture")
+	}
+}
+
+func TestFixtureRecord(t *testing.T) {
+	dir := t.TempDir()
+	live := &stubTransport{body: `{"ok":true}`}
+	recorder, err := newFixtureTransport(dir, fixtureRecord, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"query":"tags"}`)
+	req, _ := http.NewRequest("POST", "https://graphql.epicgames.com/graphql", bytes.NewReader(body))
+	resp, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(resp.Body); string(data) != `{"ok":true}` {
+		t.Errorf("recorder answered %s", data)
+	}
+
+	// Only Epic's responses are recorded
+	other, _ := http.NewRequest("POST", "https://hooks.example.com/notify", strings.NewReader("secret"))
+	if _, err := recorder.RoundTrip(other); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || live.requests != 2 {
+		t.Fatalf("recorded %v from %d requests", files, live.requests)
+	}
+	if data, _ := os.ReadFile(files[0]); !strings.Contains(string(data), `"request_body": "{\"query\":\"tags\"}"`) {
+		t.Errorf("fixture misses the request body:\n%s", data)
+	}
+
+	replay, err := newFixtureTransport(dir, fixtureReplay, &stubTransport{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ = http.NewRequest("POST", "https://graphql.epicgames.com/graphql", bytes.NewReader(body))
+	resp, err = replay.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The recorded JSON is indented
+	var replayed bytes.Buffer
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Compact(&replayed, data); err != nil || replayed.String() != `{"ok":true}` {
+		t.Errorf("replay answered %s", data)
+	}
+}
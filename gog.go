@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gogCatalogURL is GOG's catalog API, which also backs the store front page
+const gogCatalogURL = "https://catalog.gog.com/v1/catalog"
+
+// includeGOG adds GOG giveaways to every fetch, set by -gog-giveaways
+var includeGOG bool
+
+// gogCatalogResponse is the part of a GOG catalog response the service uses
+type gogCatalogResponse struct {
+	Products []struct {
+		ID              string   `json:"id"`
+		Slug            string   `json:"slug"`
+		Title           string   `json:"title"`
+		CoverHorizontal string   `json:"coverHorizontal"`
+		Publishers      []string `json:"publishers"`
+		ProductType     string   `json:"productType"` // "game", "pack" or "dlc"
+		StoreLink       string   `json:"storeLink"`
+		Genres          []struct {
+			Name string `json:"name"`
+		} `json:"genres"`
+		Price struct {
+			Final     string `json:"final"`
+			Base      string `json:"base"`
+			BaseMoney struct {
+				Amount   string `json:"amount"`
+				Currency string `json:"currency"`
+			} `json:"baseMoney"`
+		} `json:"price"`
+	} `json:"products"`
+}
+
+// gogOfferTypes maps GOG product types to Epic's offer types, so filters
+// treat both stores alike
+var gogOfferTypes = map[string]string{
+	"game": "BASE_GAME",
+	"pack": "BUNDLE",
+	"dlc":  "DLC",
+}
+
+// fetchGOGGiveaways returns the games GOG currently gives away, which are
+// listed in its catalog as discounted to free. GOG doesn't publish the end
+// of its giveaways, so their dates are unknown.
+func fetchGOGGiveaways(ctx context.Context, countryCode, locale string) ([]Game, error) {
+	query := url.Values{
+		"limit":       {"48"},
+		"price":       {"between:0,0"},
+		"discounted":  {"eq:true"},
+		"productType": {"in:game,pack,dlc"},
+		"countryCode": {strings.ToUpper(countryCode)},
+		"locale":      {locale},
+		"order":       {"desc:trending"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", gogCatalogURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var catalog gogCatalogResponse
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	var games []Game
+	for _, product := range catalog.Products {
+		game := Game{
+			ID:            gameID(storeGOG, product.ID),
+			Title:         product.Title,
+			ImageURL:      product.CoverHorizontal,
+			URL:           product.StoreLink,
+			Status:        "free",
+			StartDate:     "Unknown",
+			EndDate:       "Unknown",
+			DatePrecision: "unknown",
+			OriginalPrice: product.Price.Base,
+			DiscountPrice: product.Price.Final,
+			OfferType:     gogOfferTypes[product.ProductType],
+			OfferKind:     offerKindGiveaway,
+			Store:         storeGOG,
+			namespace:     storeGOG,
+			offerID:       product.ID,
+			currency:      product.Price.BaseMoney.Currency,
+		}
+		if amount, err := strconv.ParseFloat(product.Price.BaseMoney.Amount, 64); err == nil {
+			game.originalPrice = amount
+		}
+		if game.URL == "" && product.Slug != "" {
+			game.URL = "https://www.gog.com/en/game/" + product.Slug
+		}
+		if len(product.Publishers) > 0 {
+			game.Publisher = product.Publishers[0]
+		}
+		for _, genre := range product.Genres {
+			game.Genres = append(game.Genres, genre.Name)
+		}
+		if price, err := strconv.ParseFloat(product.Price.BaseMoney.Amount, 64); err == nil {
+			game.originalPrice = price
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
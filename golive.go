@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// goLiveMaxAttempts is how often the store is checked for a game that should
+// have gone live, as the promotion sometimes shows up a little late
+const goLiveMaxAttempts = 5
+
+// GoLiveWatcher schedules an alert for every upcoming game at the moment its
+// promotion starts, independent of the cron schedule. The alert is a regular
+// notification run, so channels tracking seen games only announce the game
+// that went live and edited lineups are refreshed.
+type GoLiveWatcher struct {
+	mu        sync.Mutex
+	fetch     func() ([]Game, error)
+	notifiers func() []Notifier // Resolved at send time, targets come and go
+	delay     time.Duration     // Wait after the start date before checking
+	timers    map[string]*time.Timer
+}
+
+// NewGoLiveWatcher creates a watcher that uses fetch to confirm a game went
+// live and announces it on the notifiers returned by notifiers at that time
+func NewGoLiveWatcher(fetch func() ([]Game, error), notifiers func() []Notifier, delay time.Duration) *GoLiveWatcher {
+	return &GoLiveWatcher{
+		fetch:     fetch,
+		notifiers: notifiers,
+		delay:     delay,
+		timers:    make(map[string]*time.Timer),
+	}
+}
+
+// Watch schedules alerts for the upcoming games with an exact start date that
+// aren't scheduled yet
+func (w *GoLiveWatcher) Watch(games []Game) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for _, game := range games {
+		if !isUpcoming(game) || game.DatePrecision != "exact" || !game.startTime.After(now) {
+			continue
+		}
+		key := gameKey(game)
+		if _, ok := w.timers[key]; ok {
+			continue
+		}
+
+		game := game
+		wait := game.startTime.Sub(now) + w.delay
+		w.timers[key] = time.AfterFunc(wait, func() {
+			w.goLive(key, game)
+		})
+		log.Printf("Scheduled go-live alert for %s in %v", game.Title, wait.Round(time.Second))
+	}
+}
+
+// goLive announces the game once the store lists it as free
+func (w *GoLiveWatcher) goLive(key string, upcoming Game) {
+	defer func() {
+		w.mu.Lock()
+		delete(w.timers, key)
+		w.mu.Unlock()
+	}()
+
+	for attempt := 1; attempt <= goLiveMaxAttempts; attempt++ {
+		games, err := w.fetch()
+		if err != nil {
+			log.Printf("Error fetching free games for go-live alert: %v", err)
+		} else if game, ok := findLiveGame(games, upcoming); ok {
+			log.Printf("%s is now free, sending go-live alert", game.Title)
+			notifyAll(context.Background(), w.notifiers(), games)
+			return
+		}
+
+		if attempt < goLiveMaxAttempts {
+			time.Sleep(time.Minute)
+		}
+	}
+	log.Printf("Warning: %s didn't go live as expected, leaving it for the next scheduled run", upcoming.Title)
+}
+
+// findLiveGame looks up the currently free version of an upcoming game
+func findLiveGame(games []Game, upcoming Game) (Game, bool) {
+	for _, game := range games {
+		if game.Status != "free" {
+			continue
+		}
+		if upcoming.offerID != "" && game.namespace == upcoming.namespace && game.offerID == upcoming.offerID {
+			return game, true
+		}
+		if upcoming.offerID == "" && game.Title == upcoming.Title {
+			return game, true
+		}
+	}
+	return Game{}, false
+}
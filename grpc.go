@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	freegamesv1 "epic-games-api/proto/freegames/v1"
+)
+
+// freeGamesServer implements the FreeGames gRPC service defined in
+// proto/freegames/v1/freegames.proto
+type freeGamesServer struct {
+	freegamesv1.UnimplementedFreeGamesServer
+
+	opts    ServerOptions
+	keys    *APIKeys     // Required from every call when enabled
+	limiter *RateLimiter // Applied per client address like RATE_LIMIT, may be nil
+	stream  *GameStream  // nil when the stream is disabled
+}
+
+// startGRPCServer serves the FreeGames service on port in the background
+func startGRPCServer(port int, server *freeGamesServer) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("error listening for gRPC: %v", err)
+	}
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := server.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := server.authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	freegamesv1.RegisterFreeGamesServer(s, server)
+	reflection.Register(s) // Lets grpcurl and similar tools discover the service
+	go func() {
+		if err := s.Serve(listener); err != nil {
+			log.Printf("Warning: gRPC server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("gRPC server listening on port %d...\n", port)
+	return nil
+}
+
+// authorize checks the API key and the rate limit of a call, like the HTTP
+// API does. The key is sent in the x-api-key or authorization metadata.
+func (s *freeGamesServer) authorize(ctx context.Context) error {
+	if s.keys.Enabled() {
+		md, _ := metadata.FromIncomingContext(ctx)
+		provided := strings.Join(md.Get("x-api-key"), "")
+		if provided == "" {
+			if token, ok := strings.CutPrefix(strings.Join(md.Get("authorization"), ""), "Bearer "); ok {
+				provided = strings.TrimSpace(token)
+			}
+		}
+		if !s.keys.allowedKey(provided) {
+			return status.Error(codes.Unauthenticated, "a valid API key is required, send it in the x-api-key metadata or as a bearer token")
+		}
+	}
+
+	if s.limiter != nil {
+		client := "unknown"
+		if p, ok := peer.FromContext(ctx); ok {
+			client = p.Addr.String()
+			if host, _, err := net.SplitHostPort(client); err == nil {
+				client = host
+			}
+		}
+		if ok, wait := s.limiter.Allow(client); !ok {
+			return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %d seconds", int(math.Ceil(wait.Seconds())))
+		}
+	}
+	return nil
+}
+
+// ListFreeGames returns the current and, unless excluded, upcoming free games
+func (s *freeGamesServer) ListFreeGames(ctx context.Context, req *freegamesv1.ListFreeGamesRequest) (*freegamesv1.ListFreeGamesResponse, error) {
+	opts := s.opts
+	if req.GetTimezone() != "" {
+		if err := validateTimezone(req.GetTimezone()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		opts.Timezone = req.GetTimezone()
+	}
+
+	result, _, err := opts.cachedGames(ctx, opts.request(!req.GetExcludeUpcoming(), includeAddons), false)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "error fetching games: %v", err)
+	}
+	opts.History.Record(result.games, opts.Country)
+
+	response := &freegamesv1.ListFreeGamesResponse{Source: result.source}
+	for _, game := range result.games {
+		response.Games = append(response.Games, gameToProto(game))
+	}
+	return response, nil
+}
+
+// WatchFreeGames sends the changes detected by the stream refresher until
+// the client cancels the call
+func (s *freeGamesServer) WatchFreeGames(req *freegamesv1.WatchFreeGamesRequest, srv grpc.ServerStreamingServer[freegamesv1.GameEvent]) error {
+	if s.stream == nil {
+		return status.Error(codes.Unavailable, "the game stream is disabled on this server")
+	}
+
+	events, _, unsubscribe := s.stream.subscribe(0)
+	defer unsubscribe()
+
+	if req.GetIncludeCurrent() {
+		for _, game := range s.stream.current() {
+			event := &freegamesv1.GameEvent{Type: freegamesv1.GameEventType_GAME_EVENT_TYPE_ADDED, Game: gameToProto(game)}
+			if err := srv.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "client fell behind the game stream")
+			}
+			if err := srv.Send(streamEventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// gameToProto converts a game to its protobuf message
+func gameToProto(game Game) *freegamesv1.Game {
+	message := &freegamesv1.Game{
+		Id:                    game.ID,
+		Title:                 game.Title,
+		Description:           game.Description,
+		ImageUrl:              game.ImageURL,
+		Url:                   game.URL,
+		StartDate:             game.StartDate,
+		EndDate:               game.EndDate,
+		Publisher:             game.Publisher,
+		OriginalPrice:         game.OriginalPrice,
+		DiscountPrice:         game.DiscountPrice,
+		OfferType:             game.OfferType,
+		Genres:                game.Genres,
+		RevealedFrom:          game.RevealedFrom,
+		OfferKind:             game.OfferKind,
+		Store:                 gameStore(game),
+		Platforms:             game.Platforms,
+		Tags:                  game.Tags,
+		CriticScore:           int32(game.CriticScore),
+		CriticTier:            game.CriticTier,
+		CriticUrl:             game.CriticURL,
+		ReleaseYear:           int32(game.ReleaseYear),
+		Developer:             game.Developer,
+		Screenshots:           game.Screenshots,
+		PlaytimeMain:          game.PlaytimeMain,
+		PlaytimeCompletionist: game.Playtime100,
+		ProtondbTier:          game.ProtonDBTier,
+		SteamDeck:             game.SteamDeck,
+		TrailerUrl:            game.TrailerURL,
+		AvailableCountries:    game.AvailableIn,
+		UnavailableCountries:  game.UnavailableIn,
+		BundleContents:        game.BundleContents,
+	}
+
+	switch game.Status {
+	case "free":
+		message.Status = freegamesv1.GameStatus_GAME_STATUS_FREE
+	case "coming soon":
+		message.Status = freegamesv1.GameStatus_GAME_STATUS_UPCOMING
+	case "mystery":
+		message.Status = freegamesv1.GameStatus_GAME_STATUS_MYSTERY
+	}
+	switch game.DatePrecision {
+	case "exact":
+		message.DatePrecision = freegamesv1.DatePrecision_DATE_PRECISION_EXACT
+	case "estimated":
+		message.DatePrecision = freegamesv1.DatePrecision_DATE_PRECISION_ESTIMATED
+	case "unknown":
+		message.DatePrecision = freegamesv1.DatePrecision_DATE_PRECISION_UNKNOWN
+	}
+	if !game.startTime.IsZero() {
+		message.StartTime = timestamppb.New(game.startTime)
+	}
+	if !game.endTime.IsZero() {
+		message.EndTime = timestamppb.New(game.endTime)
+	}
+	return message
+}
+
+// streamEventToProto converts a stream event to its protobuf message
+func streamEventToProto(event StreamEvent) *freegamesv1.GameEvent {
+	message := &freegamesv1.GameEvent{Id: event.ID, Game: gameToProto(event.Game)}
+	switch event.Type {
+	case eventGameAdded:
+		message.Type = freegamesv1.GameEventType_GAME_EVENT_TYPE_ADDED
+	case eventGameExpiring:
+		message.Type = freegamesv1.GameEventType_GAME_EVENT_TYPE_EXPIRING
+	case eventGameRemoved:
+		message.Type = freegamesv1.GameEventType_GAME_EVENT_TYPE_REMOVED
+	}
+	return message
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is a giveaway detected by the service
+type HistoryEntry struct {
+	ID            string    `json:"id,omitempty"`
+	Title         string    `json:"title"`
+	URL           string    `json:"url,omitempty"`
+	ImageURL      string    `json:"image_url,omitempty"`
+	Publisher     string    `json:"publisher,omitempty"`
+	OriginalPrice string    `json:"original_price,omitempty"` // Formatted regular price
+	PriceValue    float64   `json:"price_value,omitempty"`    // Regular price in Currency
+	Currency      string    `json:"currency,omitempty"`
+	Country       string    `json:"country"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	DatePrecision string    `json:"date_precision"`
+	FirstSeen     time.Time `json:"first_seen"`
+}
+
+// HistoryStore archives every free game the service sees. The archive is
+// persisted in the database, or as JSON when a path is set, so it survives
+// restarts.
+type HistoryStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*HistoryEntry
+}
+
+// HistoryResponse is returned by the history endpoint
+type HistoryResponse struct {
+	Success       bool           `json:"success"`
+	SchemaVersion int            `json:"schema_version"`
+	Code          string         `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string         `json:"message,omitempty"`
+	RequestID     string         `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	Count         int            `json:"count"`
+	Data          []HistoryEntry `json:"data"`
+}
+
+// NewHistoryStore creates a store, loading the archive from path if it
+// exists. An empty path keeps the archive in memory only.
+func NewHistoryStore(path string) *HistoryStore {
+	h := &HistoryStore{
+		path:    path,
+		entries: make(map[string]*HistoryEntry),
+	}
+
+	if stateDB != nil {
+		documents, err := stateDB.History()
+		if err != nil {
+			log.Printf("Warning: Error loading history: %v", err)
+			return h
+		}
+		for key, document := range documents {
+			var entry HistoryEntry
+			if err := json.Unmarshal(document, &entry); err != nil {
+				log.Printf("Warning: Error parsing history entry %s: %v", key, err)
+				continue
+			}
+			h.entries[key] = &entry
+		}
+		return h
+	}
+	if path == "" {
+		return h
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Error reading history file %s: %v", path, err)
+		}
+		return h
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		log.Printf("Warning: Error parsing history file %s: %v", path, err)
+	}
+	return h
+}
+
+// Record archives the currently free games seen in the given country store
+func (h *HistoryStore) Record(games []Game, country string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	changed := false
+	for _, game := range games {
+		// Free weeks and free-to-play games aren't giveaways
+		if game.Status != "free" || game.OfferKind != offerKindGiveaway {
+			continue
+		}
+		key := country + "|" + gameKey(game)
+		if entry, ok := h.entries[key]; ok {
+			// Keep the price of entries first seen without one
+			if entry.PriceValue == 0 && game.originalPrice > 0 {
+				entry.OriginalPrice = game.OriginalPrice
+				entry.PriceValue = game.originalPrice
+				entry.Currency = game.currency
+				changed = true
+			}
+			continue
+		}
+
+		// An entry recorded while the window was estimated is moved to the
+		// real window once it's known
+		if game.DatePrecision == "exact" {
+			estimated := game
+			estimated.DatePrecision = "estimated"
+			estimatedKey := country + "|" + gameKey(estimated)
+			if entry, ok := h.entries[estimatedKey]; ok {
+				entry.StartDate = game.startTime
+				entry.EndDate = game.endTime
+				entry.DatePrecision = game.DatePrecision
+				delete(h.entries, estimatedKey)
+				h.entries[key] = entry
+				changed = true
+				continue
+			}
+		}
+
+		h.entries[key] = &HistoryEntry{
+			ID:            game.ID,
+			Title:         game.Title,
+			URL:           game.URL,
+			ImageURL:      game.ImageURL,
+			Publisher:     game.Publisher,
+			OriginalPrice: game.OriginalPrice,
+			PriceValue:    game.originalPrice,
+			Currency:      game.currency,
+			Country:       country,
+			StartDate:     game.startTime,
+			EndDate:       game.endTime,
+			DatePrecision: game.DatePrecision,
+			FirstSeen:     now,
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := h.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// save persists the archive, the caller must hold the lock
+func (h *HistoryStore) save() error {
+	if stateDB != nil {
+		documents := make(map[string][]byte, len(h.entries))
+		for key, entry := range h.entries {
+			document, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("error marshaling history: %v", err)
+			}
+			documents[key] = document
+		}
+		return stateDB.ReplaceHistory(documents)
+	}
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling history: %v", err)
+	}
+	if err := os.WriteFile(h.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing history file: %v", err)
+	}
+	return nil
+}
+
+// Entries returns a copy of the archive, by entry key
+func (h *HistoryStore) Entries() map[string]*HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make(map[string]*HistoryEntry, len(h.entries))
+	for key, entry := range h.entries {
+		copied := *entry
+		entries[key] = &copied
+	}
+	return entries
+}
+
+// Import adds the archived giveaways that aren't in the archive yet and
+// returns how many were added
+func (h *HistoryStore) Import(entries map[string]*HistoryEntry) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	added := 0
+	for key, entry := range entries {
+		if _, ok := h.entries[key]; ok || entry == nil {
+			continue
+		}
+		copied := *entry
+		h.entries[key] = &copied
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, h.save()
+}
+
+// Compact drops the parts of the giveaways that ended before a time that are
+// only useful while they're live, the key art, and returns how many entries
+// were compacted. The title, dates and prices are kept forever.
+func (h *HistoryStore) Compact(endedBefore time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	compacted := 0
+	for _, entry := range h.entries {
+		ended := entry.EndDate
+		if ended.IsZero() {
+			ended = entry.FirstSeen
+		}
+		if ended.Before(endedBefore) && entry.ImageURL != "" {
+			entry.ImageURL = ""
+			compacted++
+		}
+	}
+
+	if compacted > 0 {
+		if err := h.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return compacted
+}
+
+// Query returns the archived giveaways, newest first. Zero or empty arguments
+// don't filter; title matches case-insensitive substrings.
+func (h *HistoryStore) Query(year int, country, title string) []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := []HistoryEntry{}
+	for _, entry := range h.entries {
+		if year != 0 && entry.StartDate.Year() != year {
+			continue
+		}
+		if country != "" && !strings.EqualFold(entry.Country, country) {
+			continue
+		}
+		if title != "" && !strings.Contains(strings.ToLower(entry.Title), strings.ToLower(title)) {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StartDate.After(entries[j].StartDate)
+	})
+	return entries
+}
+
+// HistoryPrice is the regular price of a game when it was given away
+type HistoryPrice struct {
+	Country       string    `json:"country"`
+	OriginalPrice string    `json:"original_price,omitempty"` // Formatted regular price
+	PriceValue    float64   `json:"price_value,omitempty"`    // Regular price in Currency
+	Currency      string    `json:"currency,omitempty"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+}
+
+// HistoryPriceResponse is returned by the history price endpoint
+type HistoryPriceResponse struct {
+	Success       bool           `json:"success"`
+	SchemaVersion int            `json:"schema_version"`
+	Code          string         `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string         `json:"message,omitempty"`
+	RequestID     string         `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	ID            string         `json:"id,omitempty"`
+	Title         string         `json:"title,omitempty"`
+	Count         int            `json:"count"`
+	Data          []HistoryPrice `json:"data"`
+}
+
+// Prices returns the prices of every archived giveaway of a game, in every
+// country, oldest first
+func (h *HistoryStore) Prices(id string) (string, []HistoryPrice) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	title := ""
+	prices := []HistoryPrice{}
+	for _, entry := range h.entries {
+		if entry.ID != id {
+			continue
+		}
+		title = entry.Title
+		prices = append(prices, HistoryPrice{
+			Country:       entry.Country,
+			OriginalPrice: entry.OriginalPrice,
+			PriceValue:    entry.PriceValue,
+			Currency:      entry.Currency,
+			StartDate:     entry.StartDate,
+			EndDate:       entry.EndDate,
+		})
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		if !prices[i].StartDate.Equal(prices[j].StartDate) {
+			return prices[i].StartDate.Before(prices[j].StartDate)
+		}
+		return prices[i].Country < prices[j].Country
+	})
+	return title, prices
+}
+
+// historyPriceHandler serves GET /v1/history/{id}/price
+func historyPriceHandler(w http.ResponseWriter, r *http.Request, history *HistoryStore) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	_, rest, _ := strings.Cut(r.URL.Path, "/history/")
+	rawID, ok := strings.CutSuffix(rest, "/price")
+	id, err := url.PathUnescape(rawID)
+	if !ok || err != nil || id == "" || strings.Contains(id, "/") {
+		w.WriteHeader(ErrNotFound.Status)
+		json.NewEncoder(w).Encode(HistoryPriceResponse{SchemaVersion: apiSchemaVersion, Code: ErrNotFound.Code, RequestID: requestID(w), Message: "Unknown history path, expected /v1/history/{id}/price"})
+		return
+	}
+
+	title, prices := history.Prices(id)
+	if len(prices) == 0 {
+		w.WriteHeader(ErrNotFound.Status)
+		json.NewEncoder(w).Encode(HistoryPriceResponse{SchemaVersion: apiSchemaVersion, Code: ErrNotFound.Code, RequestID: requestID(w), Message: fmt.Sprintf("No archived giveaway with ID %q", id)})
+		return
+	}
+
+	jsonData, _ := json.MarshalIndent(HistoryPriceResponse{
+		Success:       true,
+		SchemaVersion: apiSchemaVersion,
+		ID:            id,
+		Title:         title,
+		Count:         len(prices),
+		Data:          prices,
+	}, "", "  ")
+	writeConditional(w, r, jsonData, jsonData)
+}
+
+// historyHandler serves GET /v1/history?year=2025&country=US&title=...
+func historyHandler(w http.ResponseWriter, r *http.Request, history *HistoryStore) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	year := 0
+	if value := r.URL.Query().Get("year"); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil {
+			w.WriteHeader(ErrBadParameter.Status)
+			json.NewEncoder(w).Encode(HistoryResponse{SchemaVersion: apiSchemaVersion, Code: ErrBadParameter.Code, RequestID: requestID(w), Message: fmt.Sprintf("Invalid year %q", value)})
+			return
+		}
+	}
+
+	entries := history.Query(year, r.URL.Query().Get("country"), r.URL.Query().Get("title"))
+	jsonData, _ := json.MarshalIndent(HistoryResponse{
+		Success:       true,
+		SchemaVersion: apiSchemaVersion,
+		Count:         len(entries),
+		Data:          entries,
+	}, "", "  ")
+	writeConditional(w, r, jsonData, jsonData)
+}
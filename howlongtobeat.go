@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// howLongToBeatSearchURL is the search API of the HowLongToBeat site, which
+// has no public API. It only answers requests that look like they come from
+// the site.
+const (
+	howLongToBeatSearchURL = "https://howlongtobeat.com/api/search"
+	howLongToBeatOrigin    = "https://howlongtobeat.com"
+)
+
+// Playtimes settle soon after release, titles without a match are retried
+// after a week
+const (
+	playtimeRefresh     = 30 * 24 * time.Hour
+	playtimeMissRefresh = 7 * 24 * time.Hour
+)
+
+// playtime is a cached HowLongToBeat lookup, in hours. A zero Main means
+// the title wasn't found or has no submitted times.
+type playtime struct {
+	Main          float64   `json:"main,omitempty"`
+	Completionist float64   `json:"completionist,omitempty"`
+	Checked       time.Time `json:"checked"`
+}
+
+// HowLongToBeatClient adds playtimes to games, caching them by title
+type HowLongToBeatClient struct {
+	cache *titleCache[playtime]
+}
+
+// NewHowLongToBeatClient creates a client, loading the cache from path if it
+// exists. An empty path keeps the cache in memory only.
+func NewHowLongToBeatClient(path string) *HowLongToBeatClient {
+	return &HowLongToBeatClient{cache: newTitleCache[playtime]("HowLongToBeat", path)}
+}
+
+// Name implements Enricher
+func (c *HowLongToBeatClient) Name() string {
+	return "HowLongToBeat"
+}
+
+// Enrich sets the playtimes of the games HowLongToBeat knows. Failed lookups
+// are logged and leave the game without playtimes.
+func (c *HowLongToBeatClient) Enrich(ctx context.Context, games []Game) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	changed := false
+	for i := range games {
+		key := titleKey(games[i].Title)
+		if key == "" || isMysteryGame(games[i]) {
+			continue
+		}
+
+		cached, ok := c.cache.entries[key]
+		refresh := playtimeRefresh
+		if ok && cached.Main == 0 {
+			refresh = playtimeMissRefresh
+		}
+		if !ok || time.Since(cached.Checked) > refresh {
+			hours, err := c.lookup(ctx, games[i].Title)
+			if err != nil {
+				log.Printf("Warning: Error looking up playtime of %s: %v", games[i].Title, err)
+				if !ok {
+					continue
+				}
+			} else {
+				cached = hours
+				c.cache.entries[key] = hours
+				changed = true
+			}
+		}
+
+		games[i].PlaytimeMain = cached.Main
+		games[i].Playtime100 = cached.Completionist
+	}
+
+	if changed {
+		if err := c.cache.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// lookup searches HowLongToBeat for the title and returns the playtimes of
+// the most similar match
+func (c *HowLongToBeatClient) lookup(ctx context.Context, title string) (*playtime, error) {
+	hours := &playtime{Checked: time.Now()}
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"searchType":  "games",
+		"searchTerms": strings.Fields(title),
+		"searchPage":  1,
+		"size":        20,
+		"searchOptions": map[string]interface{}{
+			"games": map[string]interface{}{
+				"userId":        0,
+				"platform":      "",
+				"sortCategory":  "popular",
+				"rangeCategory": "main",
+				"rangeTime":     map[string]int{"min": 0, "max": 0},
+				"gameplay":      map[string]string{"perspective": "", "flow": "", "genre": ""},
+				"modifier":      "",
+			},
+			"filter":     "",
+			"sort":       0,
+			"randomizer": 0,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", howLongToBeatSearchURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", howLongToBeatOrigin)
+	req.Header.Set("Referer", howLongToBeatOrigin+"/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; epic-games-api)")
+
+	client := newHTTPClient(15 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var searchResp struct {
+		Data []struct {
+			GameName string `json:"game_name"`
+			CompMain int    `json:"comp_main"` // Seconds
+			Comp100  int    `json:"comp_100"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	best := minTitleSimilarity
+	for _, result := range searchResp.Data {
+		similarity := titleSimilarity(title, result.GameName)
+		if similarity < best || result.CompMain == 0 {
+			continue
+		}
+		best = similarity
+		hours.Main = secondsToHours(result.CompMain)
+		hours.Completionist = secondsToHours(result.Comp100)
+	}
+	return hours, nil
+}
+
+// secondsToHours converts a HowLongToBeat time to hours, rounded to halves
+func secondsToHours(seconds int) float64 {
+	return math.Round(float64(seconds)/1800) / 2
+}
+
+// playtimeText formats the main story playtime of a game for notifications,
+// e.g. "Main story: ~12h", empty when unknown
+func playtimeText(game Game) string {
+	if game.PlaytimeMain == 0 {
+		return ""
+	}
+	if game.PlaytimeMain < 1 {
+		return "Main story: <1h"
+	}
+	return fmt.Sprintf("Main story: ~%gh", game.PlaytimeMain)
+}
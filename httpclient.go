@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// userAgent is sent with the outgoing requests that don't set their own, set
+// by -user-agent
+var userAgent = programName
+
+// outboundTransport carries every request to Epic, the other stores and the
+// notification services, so their connections are pooled and reused and
+// they all go through the configured proxy
+var outboundTransport = &userAgentTransport{base: newOutboundTransport(http.ProxyFromEnvironment)}
+
+// newHTTPClient returns a client with its own timeout on the shared
+// transport. Clients are cheap, the connections live in the transport.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: outboundTransport}
+}
+
+// newOutboundTransport creates a transport keeping connections alive, with
+// proxy picking the proxy of each request
+func newOutboundTransport(proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   15 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10, // Notifications post to the same hosts in bursts
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// configureOutboundProxy sends every outgoing request through proxyURL, an
+// http, https, socks5 or socks5h URL. Without one, the standard HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY variables apply. It must be called before any
+// request is sent.
+func configureOutboundProxy(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %v", proxyURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("invalid proxy URL %q, expected an http, https, socks5 or socks5h URL", proxyURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q, missing the host", proxyURL)
+	}
+	outboundTransport.base = newOutboundTransport(http.ProxyURL(u))
+	return nil
+}
+
+// userAgentTransport sets the User-Agent of requests without one
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" && userAgent != "" {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
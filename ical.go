@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarHandler serves GET /calendar.ics, an iCalendar feed with an event
+// spanning the promotion window of every current and upcoming free game.
+// Event times are in UTC, the timezone query parameter (default: the
+// configured one) is the calendar's display timezone. Events remind reminder hours before the giveaway ends (default 24, 0 disables).
+func calendarHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	// The games are fetched in the configured timezone, the calendar's only
+	// names the one to display them in
+	timezone := opts.Timezone
+	if tz := r.URL.Query().Get("timezone"); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			httpError(w, fmt.Sprintf("Invalid timezone %q, expected an IANA name like Europe/Berlin", tz), http.StatusBadRequest)
+			return
+		}
+		timezone = tz
+	}
+	reminder := 24
+	if value := r.URL.Query().Get("reminder"); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 0 {
+			httpError(w, fmt.Sprintf("Invalid reminder %q, expected a number of hours", value), http.StatusBadRequest)
+			return
+		}
+		reminder = hours
+	}
+
+	games, err := opts.freeGames(r.Context(), true)
+	if err != nil {
+		httpError(w, fmt.Sprintf("Error fetching games: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	body := buildCalendar(games, timezone, reminder, time.Now())
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="epic-free-games.ics"`)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	// DTSTAMP changes every request, leave it out of the ETag
+	version := buildCalendar(games, timezone, reminder, time.Time{})
+	writeConditional(w, r, []byte(body), []byte(version))
+}
+
+// buildCalendar renders the games with exact promotion windows as VEVENTs
+func buildCalendar(games []Game, timezone string, reminder int, now time.Time) string {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICalLine(line))
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//epic-games-api//Free Games//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:Epic Games Free Games")
+	if location != time.UTC {
+		writeLine("X-WR-TIMEZONE:" + location.String())
+	}
+
+	for _, game := range games {
+		if game.DatePrecision != "exact" || game.startTime.IsZero() || game.endTime.IsZero() {
+			continue
+		}
+
+		uid := sha256.Sum256([]byte(gameKey(game)))
+		description := game.Description
+		if game.URL != "" {
+			description = strings.TrimSpace(description + "\n\n" + game.URL)
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + hex.EncodeToString(uid[:16]) + "@epic-games-api")
+		writeLine("DTSTAMP:" + now.UTC().Format("20060102T150405Z"))
+		writeLine(iCalTime("DTSTART", game.startTime))
+		writeLine(iCalTime("DTEND", game.endTime))
+		writeLine("SUMMARY:" + escapeICalText("Free on "+storeShortName(game)+": "+game.Title))
+		if description != "" {
+			writeLine("DESCRIPTION:" + escapeICalText(description))
+		}
+		if game.URL != "" {
+			writeLine("URL:" + game.URL)
+		}
+		if reminder > 0 {
+			writeLine("BEGIN:VALARM")
+			writeLine("ACTION:DISPLAY")
+			writeLine("DESCRIPTION:" + escapeICalText(game.Title+" is no longer free soon"))
+			writeLine(fmt.Sprintf("TRIGGER;RELATED=END:-PT%dH", reminder))
+			writeLine("END:VALARM")
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// iCalTime formats a date property in UTC. A TZID would need a VTIMEZONE
+// component describing the zone's offsets, which calendars apply themselves.
+func iCalTime(name string, t time.Time) string {
+	return name + ":" + t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICalText escapes a TEXT value as required by RFC 5545
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICalLine terminates a content line with CRLF, folding it into lines of
+// at most 75 octets without splitting UTF-8 characters
+func foldICalLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
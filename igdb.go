@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IGDB is queried with an app access token of a Twitch application
+const (
+	twitchTokenURL  = "https://id.twitch.tv/oauth2/token"
+	igdbGamesURL    = "https://api.igdb.com/v4/games"
+	igdbImageURL    = "https://images.igdb.com/igdb/image/upload/t_screenshot_big/%s.jpg"
+	igdbScreenshots = 4   // Screenshots kept per game
+	igdbSummaryMax  = 300 // Characters of the IGDB summary used as description
+)
+
+// Metadata of released games hardly changes, titles IGDB doesn't know are
+// retried sooner in case they're added
+const (
+	igdbRefresh     = 30 * 24 * time.Hour
+	igdbMissRefresh = 7 * 24 * time.Hour
+)
+
+// igdbMetadata is a cached IGDB lookup. An empty Name means the title wasn't
+// found.
+type igdbMetadata struct {
+	Name        string    `json:"name,omitempty"`
+	ReleaseYear int       `json:"release_year,omitempty"`
+	Developer   string    `json:"developer,omitempty"`
+	Genres      []string  `json:"genres,omitempty"`
+	Screenshots []string  `json:"screenshots,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Checked     time.Time `json:"checked"`
+}
+
+// IGDBClient adds release year, developer, screenshots and a summary to
+// games, caching them by title
+type IGDBClient struct {
+	ClientID     string
+	ClientSecret string
+
+	tokenMu      sync.Mutex
+	token        string
+	tokenExpires time.Time
+
+	cache *titleCache[igdbMetadata]
+}
+
+// NewIGDBClient creates a client authenticating with the Twitch client
+// credentials, loading the cache from path if it exists. An empty path keeps
+// the cache in memory only.
+func NewIGDBClient(clientID, clientSecret, path string) *IGDBClient {
+	return &IGDBClient{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		cache:        newTitleCache[igdbMetadata]("IGDB", path),
+	}
+}
+
+// Name implements Enricher
+func (c *IGDBClient) Name() string {
+	return "IGDB"
+}
+
+// Enrich adds the IGDB metadata to the games IGDB knows. The summary replaces
+// the description only when the store's is missing or a marketing blurb, and
+// the genres are only set for games the store didn't tag.
+func (c *IGDBClient) Enrich(ctx context.Context, games []Game) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	changed := false
+	for i := range games {
+		key := titleKey(games[i].Title)
+		if key == "" || isMysteryGame(games[i]) {
+			continue
+		}
+
+		cached, ok := c.cache.entries[key]
+		refresh := igdbRefresh
+		if ok && cached.Name == "" {
+			refresh = igdbMissRefresh
+		}
+		if !ok || time.Since(cached.Checked) > refresh {
+			metadata, err := c.lookup(ctx, games[i].Title)
+			if err != nil {
+				log.Printf("Warning: Error looking up IGDB metadata of %s: %v", games[i].Title, err)
+				if !ok {
+					continue
+				}
+			} else {
+				cached = metadata
+				c.cache.entries[key] = metadata
+				changed = true
+			}
+		}
+		if cached.Name == "" {
+			continue
+		}
+
+		games[i].ReleaseYear = cached.ReleaseYear
+		games[i].Developer = cached.Developer
+		games[i].Screenshots = cached.Screenshots
+		if len(games[i].Genres) == 0 {
+			games[i].Genres = cached.Genres
+		}
+		if cached.Summary != "" && isMarketingBlurb(games[i].Description, games[i].Title) {
+			games[i].Description = truncateRunes(cached.Summary, igdbSummaryMax)
+		}
+	}
+
+	if changed {
+		if err := c.cache.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// lookup searches IGDB for the title, only an exact match after normalizing
+// is used since IGDB's search also returns sequels and spin-offs
+func (c *IGDBClient) lookup(ctx context.Context, title string) (*igdbMetadata, error) {
+	metadata := &igdbMetadata{Checked: time.Now()}
+
+	query := fmt.Sprintf(`search "%s"; fields name,summary,first_release_date,genres.name,involved_companies.developer,involved_companies.company.name,screenshots.image_id; where version_parent = null; limit 10;`,
+		strings.ReplaceAll(title, `"`, `\"`))
+	var results []struct {
+		Name             string `json:"name"`
+		Summary          string `json:"summary"`
+		FirstReleaseDate int64  `json:"first_release_date"` // Unix time
+		Genres           []struct {
+			Name string `json:"name"`
+		} `json:"genres"`
+		InvolvedCompanies []struct {
+			Developer bool `json:"developer"`
+			Company   struct {
+				Name string `json:"name"`
+			} `json:"company"`
+		} `json:"involved_companies"`
+		Screenshots []struct {
+			ImageID string `json:"image_id"`
+		} `json:"screenshots"`
+	}
+	if err := c.post(ctx, query, &results); err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if normalizeTitle(result.Name) != normalizeTitle(title) {
+			continue
+		}
+		metadata.Name = result.Name
+		metadata.Summary = strings.TrimSpace(result.Summary)
+		if result.FirstReleaseDate > 0 {
+			metadata.ReleaseYear = time.Unix(result.FirstReleaseDate, 0).UTC().Year()
+		}
+		for _, genre := range result.Genres {
+			metadata.Genres = append(metadata.Genres, genre.Name)
+		}
+		for _, company := range result.InvolvedCompanies {
+			if company.Developer {
+				metadata.Developer = company.Company.Name
+				break
+			}
+		}
+		for _, screenshot := range result.Screenshots {
+			if len(metadata.Screenshots) == igdbScreenshots {
+				break
+			}
+			metadata.Screenshots = append(metadata.Screenshots, fmt.Sprintf(igdbImageURL, screenshot.ImageID))
+		}
+		break
+	}
+	return metadata, nil
+}
+
+// post sends an Apicalypse query to the IGDB games endpoint and decodes the
+// JSON response into v
+func (c *IGDBClient) post(ctx context.Context, query string, v interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", igdbGamesURL, strings.NewReader(query))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Client-ID", c.ClientID)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "text/plain")
+
+	client := newHTTPClient(15 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked, get a new one on the next lookup
+		c.tokenMu.Lock()
+		c.token = ""
+		c.tokenMu.Unlock()
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// accessToken returns an app access token from Twitch, reused until shortly
+// before it expires
+func (c *IGDBClient) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpires) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", twitchTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating Twitch token request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := newHTTPClient(15 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting Twitch access token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bad status from Twitch: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // Seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("error decoding Twitch access token: %v", err)
+	}
+
+	c.token = tokenResp.AccessToken
+	c.tokenExpires = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - time.Hour)
+	return c.token, nil
+}
+
+// marketingPhrases mark store descriptions that advertise the offer instead
+// of describing the game
+var marketingPhrases = []string{
+	"available now",
+	"now available",
+	"pre-order",
+	"pre-purchase",
+	"limited time",
+	"for free",
+	"get it now",
+	"buy now",
+}
+
+// isMarketingBlurb reports whether a store description says little about the
+// game: empty, just the title, a few words, or an advertisement
+func isMarketingBlurb(description, title string) bool {
+	description = strings.TrimSpace(description)
+	if description == "" || normalizeTitle(description) == normalizeTitle(title) {
+		return true
+	}
+	if len(strings.Fields(description)) < 8 || strings.Count(description, "!") >= 2 {
+		return true
+	}
+	lower := strings.ToLower(description)
+	for _, phrase := range marketingPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
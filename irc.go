@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// IRCNotifier connects to an IRC server and announces new free games in a channel
+type IRCNotifier struct {
+	Server       string // host:port
+	UseTLS       bool
+	Nick         string
+	Channel      string
+	ChannelKey   string
+	SASLUser     string
+	SASLPassword string
+	tracker      *SeenTracker
+}
+
+// ircMessage is a parsed IRC protocol line
+type ircMessage struct {
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+// NewIRCNotifier creates an IRC notifier. Games already announced are
+// remembered by tracker.
+func NewIRCNotifier(server string, useTLS bool, nick, channel, channelKey, saslUser, saslPassword string, tracker *SeenTracker) *IRCNotifier {
+	if nick == "" {
+		nick = "EpicFreeGames"
+	}
+	if !strings.HasPrefix(channel, "#") && !strings.HasPrefix(channel, "&") {
+		channel = "#" + channel
+	}
+	return &IRCNotifier{
+		Server:       server,
+		UseTLS:       useTLS,
+		Nick:         nick,
+		Channel:      channel,
+		ChannelKey:   channelKey,
+		SASLUser:     saslUser,
+		SASLPassword: saslPassword,
+		tracker:      tracker,
+	}
+}
+
+// Name returns the channel identifier
+func (i *IRCNotifier) Name() string {
+	return "irc"
+}
+
+// pendingGames returns the games not announced yet
+func (i *IRCNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return i.tracker.Unseen(games), i.tracker
+}
+
+// Notify connects, joins the channel, announces each new game and disconnects
+func (i *IRCNotifier) Notify(ctx context.Context, games []Game) error {
+	unseen := i.tracker.Unseen(games)
+	if len(unseen) == 0 {
+		return nil
+	}
+
+	// Each non-empty line of the message is sent as a PRIVMSG
+	message, err := messageTemplates.Render(i.Name(), formatText, unseen)
+	if err != nil {
+		return err
+	}
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := i.announce(ctx, lines); err != nil {
+		return err
+	}
+
+	if err := i.tracker.MarkSeen(unseen...); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return nil
+}
+
+func (i *IRCNotifier) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	if i.UseTLS {
+		host, _, _ := net.SplitHostPort(i.Server)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		return tlsDialer.DialContext(ctx, "tcp", i.Server)
+	}
+	return dialer.DialContext(ctx, "tcp", i.Server)
+}
+
+// announce runs a short IRC session that registers (optionally with SASL
+// PLAIN), joins the channel and sends the lines
+func (i *IRCNotifier) announce(ctx context.Context, lines []string) error {
+	conn, err := i.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to IRC server: %v", err)
+	}
+	defer conn.Close()
+	// Closing the connection when ctx ends interrupts the session
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	send := func(format string, args ...interface{}) error {
+		_, err := fmt.Fprintf(conn, format+"\r\n", args...)
+		return err
+	}
+
+	nick := i.Nick
+	if i.SASLUser != "" {
+		send("CAP REQ :sasl")
+	}
+	send("NICK %s", nick)
+	if err := send("USER %s 0 * :Epic Games Free Games", nick); err != nil {
+		return fmt.Errorf("error registering with IRC server: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("error reading from IRC server: %v", err)
+		}
+		msg := parseIRCMessage(strings.TrimRight(line, "\r\n"))
+
+		switch msg.Command {
+		case "PING":
+			send("PONG :%s", msg.trailing())
+
+		case "CAP":
+			if len(msg.Params) >= 2 && msg.Params[1] == "ACK" {
+				send("AUTHENTICATE PLAIN")
+			} else if len(msg.Params) >= 2 && msg.Params[1] == "NAK" {
+				return fmt.Errorf("IRC server does not support SASL")
+			}
+
+		case "AUTHENTICATE":
+			if msg.trailing() == "+" {
+				credentials := i.SASLUser + "\x00" + i.SASLUser + "\x00" + i.SASLPassword
+				send("AUTHENTICATE %s", base64.StdEncoding.EncodeToString([]byte(credentials)))
+			}
+
+		case "903": // RPL_SASLSUCCESS
+			send("CAP END")
+
+		case "902", "904", "905", "906": // SASL failures
+			return fmt.Errorf("IRC SASL authentication failed: %s", msg.trailing())
+
+		case "433": // ERR_NICKNAMEINUSE
+			nick += "_"
+			send("NICK %s", nick)
+
+		case "001": // RPL_WELCOME
+			if i.ChannelKey != "" {
+				send("JOIN %s %s", i.Channel, i.ChannelKey)
+			} else {
+				send("JOIN %s", i.Channel)
+			}
+
+		case "366": // RPL_ENDOFNAMES, the join is complete
+			for _, text := range lines {
+				if err := send("PRIVMSG %s :%s", i.Channel, text); err != nil {
+					return fmt.Errorf("error sending to IRC channel: %v", err)
+				}
+				// Stay below typical flood protection limits
+				time.Sleep(time.Second)
+			}
+			send("QUIT :Bye")
+			return nil
+
+		case "403", "405", "471", "473", "474", "475": // Join failures
+			return fmt.Errorf("error joining %s: %s", i.Channel, msg.trailing())
+
+		case "ERROR":
+			return fmt.Errorf("IRC server closed the connection: %s", msg.trailing())
+		}
+	}
+}
+
+// parseIRCMessage splits a raw line into prefix, command and parameters
+func parseIRCMessage(line string) ircMessage {
+	var msg ircMessage
+
+	// Drop IRCv3 message tags
+	if strings.HasPrefix(line, "@") {
+		if _, rest, ok := strings.Cut(line, " "); ok {
+			line = rest
+		}
+	}
+	if strings.HasPrefix(line, ":") {
+		msg.Prefix, line, _ = strings.Cut(line[1:], " ")
+	}
+
+	var trailing string
+	hasTrailing := false
+	if before, after, ok := strings.Cut(line, " :"); ok {
+		line, trailing, hasTrailing = before, after, true
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		msg.Command = strings.ToUpper(fields[0])
+		msg.Params = fields[1:]
+	}
+	if hasTrailing {
+		msg.Params = append(msg.Params, trailing)
+	}
+	return msg
+}
+
+// trailing returns the last parameter of the message
+func (m ircMessage) trailing() string {
+	if len(m.Params) == 0 {
+		return ""
+	}
+	return m.Params[len(m.Params)-1]
+}
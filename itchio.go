@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// itchIOOnSaleURL is the itch.io browse page of the games on sale, returned
+// as an HTML fragment in JSON with format=json
+const itchIOOnSaleURL = "https://itch.io/games/on-sale?format=json"
+
+// itchIOPages is the number of on-sale pages scanned, 100%-off sales are
+// mixed with the other sales
+const itchIOPages = 3
+
+var (
+	// includeItchIO adds itch.io's 100%-off sales to every fetch, set by -itch-io
+	includeItchIO bool
+	// itchIOMinRating skips itch.io games rated lower, set by -itch-io-min-rating
+	itchIOMinRating float64
+)
+
+// Patterns reading the game cells of the browse page
+var (
+	itchIOCellPattern   = regexp.MustCompile(`<div[^>]*data-game_id="(\d+)"[^>]*class="game_cell`)
+	itchIOTitlePattern  = regexp.MustCompile(`<a[^>]*class="title game_link"[^>]*>([^<]+)</a>`)
+	itchIOLinkPattern   = regexp.MustCompile(`<a[^>]*class="title game_link"[^>]*href="([^"]+)"|<a[^>]*href="([^"]+)"[^>]*class="title game_link"`)
+	itchIOSalePattern   = regexp.MustCompile(`class="sale_tag"[^>]*>\s*-?(\d+)%`)
+	itchIOImagePattern  = regexp.MustCompile(`data-lazy_src="([^"]+)"`)
+	itchIOAuthorPattern = regexp.MustCompile(`class="game_author"[^>]*><a[^>]*>([^<]+)</a>`)
+	itchIOGenrePattern  = regexp.MustCompile(`class="game_genre"[^>]*>([^<]+)<`)
+	itchIORatingPattern = regexp.MustCompile(`class="star_fill"[^>]*style="width:\s*([\d.]+)%`)
+	itchIODescPattern   = regexp.MustCompile(`class="game_text"[^>]*title="([^"]*)"`)
+)
+
+// itchIOPage is a page of the browse page in JSON
+type itchIOPage struct {
+	Content string `json:"content"`
+}
+
+// fetchItchIOGiveaways returns the itch.io games on a 100%-off sale rated at
+// least minRating out of 5. itch.io doesn't list when sales end on the
+// browse page, so their dates are unknown.
+func fetchItchIOGiveaways(ctx context.Context, minRating float64) ([]Game, error) {
+	var games []Game
+	seen := map[string]bool{}
+	for page := 1; page <= itchIOPages; page++ {
+		content, err := fetchItchIOPage(ctx, page)
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if content == "" {
+			break
+		}
+		for _, game := range parseItchIOGames(content, minRating) {
+			if !seen[game.ID] {
+				seen[game.ID] = true
+				games = append(games, game)
+			}
+		}
+	}
+	return games, nil
+}
+
+// fetchItchIOPage fetches the HTML of a page of the games on sale
+func fetchItchIOPage(ctx context.Context, page int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", itchIOOnSaleURL+"&page="+strconv.Itoa(page), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var onSale itchIOPage
+	if err := json.NewDecoder(resp.Body).Decode(&onSale); err != nil {
+		return "", fmt.Errorf("error decoding response: %v", err)
+	}
+	return onSale.Content, nil
+}
+
+// parseItchIOGames reads the 100%-off games out of the game cells of a page
+func parseItchIOGames(content string, minRating float64) []Game {
+	var games []Game
+	cells := itchIOCellPattern.FindAllStringSubmatchIndex(content, -1)
+	for i, cell := range cells {
+		end := len(content)
+		if i+1 < len(cells) {
+			end = cells[i+1][0]
+		}
+		id := content[cell[2]:cell[3]]
+		text := content[cell[0]:end]
+
+		sale := itchIOSalePattern.FindStringSubmatch(text)
+		if sale == nil || sale[1] != "100" {
+			continue
+		}
+		if minRating > 0 {
+			rating := itchIORatingPattern.FindStringSubmatch(text)
+			if rating == nil {
+				continue
+			}
+			if percent, err := strconv.ParseFloat(rating[1], 64); err != nil || percent/20 < minRating {
+				continue
+			}
+		}
+
+		game := Game{
+			ID:            gameID(storeItchIO, id),
+			Title:         itchIOText(itchIOTitlePattern, text),
+			Description:   itchIOText(itchIODescPattern, text),
+			ImageURL:      itchIOText(itchIOImagePattern, text),
+			Status:        "free",
+			StartDate:     "Unknown",
+			EndDate:       "Unknown",
+			DatePrecision: "unknown",
+			Publisher:     itchIOText(itchIOAuthorPattern, text),
+			DiscountPrice: "0",
+			OfferType:     "BASE_GAME",
+			OfferKind:     offerKindGiveaway,
+			Store:         storeItchIO,
+			namespace:     storeItchIO,
+			offerID:       id,
+		}
+		if link := itchIOLinkPattern.FindStringSubmatch(text); link != nil {
+			game.URL = html.UnescapeString(link[1] + link[2])
+		}
+		if genre := itchIOText(itchIOGenrePattern, text); genre != "" {
+			game.Genres = []string{genre}
+		}
+		if game.Title == "" {
+			continue
+		}
+		games = append(games, game)
+	}
+	return games
+}
+
+// itchIOText returns the first group matched by pattern, unescaped
+func itchIOText(pattern *regexp.Regexp, text string) string {
+	match := pattern.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(match[1]))
+}
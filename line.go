@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LINE allows at most 12 bubbles in a carousel
+const lineMaxBubbles = 12
+
+// LINENotifier pushes a flex message carousel of the games to a LINE user,
+// group or room
+type LINENotifier struct {
+	ChannelAccessToken string
+	TargetID           string
+	tracker            *SeenTracker
+	client             *http.Client
+}
+
+// NewLINENotifier creates a LINE Messaging API notifier. Games already pushed
+// are remembered by tracker.
+func NewLINENotifier(channelAccessToken, targetID string, tracker *SeenTracker) *LINENotifier {
+	return &LINENotifier{
+		ChannelAccessToken: channelAccessToken,
+		TargetID:           targetID,
+		tracker:            tracker,
+		client:             newHTTPClient(10 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (l *LINENotifier) Name() string {
+	return "line"
+}
+
+// pendingGames returns the games not announced yet
+func (l *LINENotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return l.tracker.Unseen(games), l.tracker
+}
+
+// Notify pushes one flex carousel with a bubble per game not announced yet.
+// Games beyond the bubbles of a carousel are pushed next time.
+func (l *LINENotifier) Notify(ctx context.Context, games []Game) error {
+	return notifyUnseen(l.tracker, games, lineMaxBubbles, func(games []Game) error {
+		return l.push(ctx, games)
+	})
+}
+
+// push sends one flex carousel with a bubble per game
+func (l *LINENotifier) push(ctx context.Context, games []Game) error {
+	var bubbles []map[string]interface{}
+	var titles []string
+	for i, game := range games {
+		if i >= lineMaxBubbles {
+			break
+		}
+		bubbles = append(bubbles, createGameBubble(game))
+		titles = append(titles, game.Title)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"to": l.TargetID,
+		"messages": []map[string]interface{}{
+			{
+				"type": "flex",
+				// Shown in notifications and chat lists
+				"altText": truncateRunes("Free on "+storesName(games)+": "+strings.Join(titles, ", "), 400),
+				"contents": map[string]interface{}{
+					"type":     "carousel",
+					"contents": bubbles,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling LINE message: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.line.me/v2/bot/message/push", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("error creating LINE request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+l.ChannelAccessToken)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending LINE request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("LINE returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// createGameBubble creates a flex bubble with the cover image, title, status,
+// dates and a button linking to the store page
+func createGameBubble(game Game) map[string]interface{} {
+	statusText := "Currently Free"
+	statusColor := "#2ECC71"
+	if isUpcoming(game) {
+		statusText = "Coming Soon"
+		statusColor = "#F1C40F"
+	}
+
+	body := []map[string]interface{}{
+		{
+			"type":   "text",
+			"text":   game.Title,
+			"weight": "bold",
+			"size":   "lg",
+			"wrap":   true,
+		},
+		{
+			"type":   "text",
+			"text":   statusText,
+			"color":  statusColor,
+			"size":   "sm",
+			"weight": "bold",
+		},
+	}
+	if game.StartDate != "Unknown" && game.EndDate != "Unknown" {
+		body = append(body, map[string]interface{}{
+			"type":  "text",
+			"text":  fmt.Sprintf("%s\n→ %s", game.StartDate, game.EndDate),
+			"size":  "xs",
+			"color": "#888888",
+			"wrap":  true,
+		})
+	}
+	if game.Publisher != "" {
+		body = append(body, map[string]interface{}{
+			"type":  "text",
+			"text":  game.Publisher,
+			"size":  "xs",
+			"color": "#888888",
+		})
+	}
+
+	bubble := map[string]interface{}{
+		"type": "bubble",
+		"body": map[string]interface{}{
+			"type":     "box",
+			"layout":   "vertical",
+			"spacing":  "sm",
+			"contents": body,
+		},
+	}
+
+	// Flex images must be served over HTTPS
+	if strings.HasPrefix(game.ImageURL, "https://") {
+		bubble["hero"] = map[string]interface{}{
+			"type":        "image",
+			"url":         game.ImageURL,
+			"size":        "full",
+			"aspectRatio": "3:4",
+			"aspectMode":  "cover",
+		}
+	}
+
+	if game.URL != "" {
+		bubble["footer"] = map[string]interface{}{
+			"type":   "box",
+			"layout": "vertical",
+			"contents": []map[string]interface{}{
+				{
+					"type":  "button",
+					"style": "primary",
+					"color": "#0078F2",
+					"action": map[string]interface{}{
+						"type":  "uri",
+						"label": "Open in Store",
+						"uri":   game.URL,
+					},
+				},
+			},
+		}
+	}
+
+	return bubble
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listOptions are the sorting and pagination parameters of list endpoints
+type listOptions struct {
+	Sort   string // "end_date", "start_date", "title" or empty for the store order
+	Desc   bool
+	Limit  int // 0 returns every game
+	Offset int
+}
+
+// parseListOptions reads sort, order, limit and offset from the query
+func parseListOptions(query url.Values) (listOptions, error) {
+	var opts listOptions
+
+	switch opts.Sort = query.Get("sort"); opts.Sort {
+	case "", "end_date", "start_date", "title":
+	default:
+		return opts, fmt.Errorf("invalid sort %q, expected end_date, start_date or title", opts.Sort)
+	}
+
+	switch order := strings.ToLower(query.Get("order")); order {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{
+		{"limit", &opts.Limit},
+		{"offset", &opts.Offset},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid %s %q, expected a non-negative number", param.name, value)
+		}
+		*param.target = n
+	}
+
+	return opts, nil
+}
+
+// sortGames sorts the games in place. Games with unknown dates go last.
+func sortGames(games []Game, opts listOptions) {
+	if opts.Sort == "" {
+		return
+	}
+
+	byTime := func(a, b time.Time) (less, ok bool) {
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero(), false
+		}
+		return a.Before(b), true
+	}
+
+	sort.SliceStable(games, func(i, j int) bool {
+		var less, ok bool
+		switch opts.Sort {
+		case "end_date":
+			less, ok = byTime(games[i].endTime, games[j].endTime)
+		case "start_date":
+			less, ok = byTime(games[i].startTime, games[j].startTime)
+		case "title":
+			less, ok = strings.ToLower(games[i].Title) < strings.ToLower(games[j].Title), true
+		}
+		if ok && opts.Desc {
+			return !less && !sameSortKey(games[i], games[j], opts.Sort)
+		}
+		return less
+	})
+}
+
+// sameSortKey reports whether two games sort equally, keeping descending sorts stable
+func sameSortKey(a, b Game, field string) bool {
+	switch field {
+	case "end_date":
+		return a.endTime.Equal(b.endTime)
+	case "start_date":
+		return a.startTime.Equal(b.startTime)
+	}
+	return strings.EqualFold(a.Title, b.Title)
+}
+
+// paginateGames returns the page of games selected by limit and offset
+func paginateGames(games []Game, opts listOptions) []Game {
+	if opts.Offset >= len(games) {
+		return []Game{}
+	}
+	games = games[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(games) {
+		games = games[:opts.Limit]
+	}
+	return games
+}
@@ -1,210 +1,120 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	webpush "github.com/SherClockHolmes/webpush-go"
 	"github.com/joho/godotenv"
 	"github.com/robfig/cron/v3"
+
+	"epic-games-api/pkg/epicgames"
+	"epic-games-api/storage"
 )
 
-// Game represents a free game from Epic Games Store
+// Game represents a free game from one of the tracked stores
 type Game struct {
-	Title         string `json:"title"`
-	Description   string `json:"description,omitempty"`
-	ImageURL      string `json:"image_url,omitempty"`
-	URL           string `json:"url,omitempty"`
-	Status        string `json:"status"` // "free" or "coming soon"
-	StartDate     string `json:"start_date"`
-	EndDate       string `json:"end_date"`
-	DatePrecision string `json:"date_precision"` // "exact", "estimated", or "unknown"
-	Publisher     string `json:"publisher,omitempty"`
+	ID             string   `json:"id,omitempty"` // Stable offer ID, "{namespace}:{offerID}" or "gog:{productID}"
+	Title          string   `json:"title"`
+	Description    string   `json:"description,omitempty"`
+	ImageURL       string   `json:"image_url,omitempty"`
+	URL            string   `json:"url,omitempty"`
+	Status         string   `json:"status"` // "free", "coming soon" or "mystery" (an unrevealed placeholder)
+	StartDate      string   `json:"start_date"`
+	EndDate        string   `json:"end_date"`
+	DatePrecision  string   `json:"date_precision"`      // "exact", "estimated", or "unknown"
+	StartsIn       string   `json:"starts_in,omitempty"` // e.g. "2 days 5 hours", set for upcoming games
+	EndsIn         string   `json:"ends_in,omitempty"`
+	StartDateISO   string   `json:"start_date_iso,omitempty"` // RFC3339, empty when unknown
+	EndDateISO     string   `json:"end_date_iso,omitempty"`
+	Publisher      string   `json:"publisher,omitempty"`
+	OriginalPrice  string   `json:"original_price,omitempty"` // Formatted regular price, e.g. "$29.99"
+	DiscountPrice  string   `json:"discount_price,omitempty"` // Formatted current price
+	OfferType      string   `json:"offer_type,omitempty"`     // e.g. "BASE_GAME", "ADD_ON", "DLC"
+	Genres         []string `json:"genres,omitempty"`
+	Tags           []string `json:"tags,omitempty"`          // Other store tags, e.g. features such as "Single Player"
+	RevealedFrom   string   `json:"revealed_from,omitempty"` // Title of the mystery placeholder the game was revealed from
+	OfferKind      string   `json:"offer_kind,omitempty"`    // "giveaway", "free_week" (temporary trial) or "always_free"
+	Store          string   `json:"store"`                   // e.g. "epic", "gog", "steam" or "ps_plus"
+	Platforms      []string `json:"platforms,omitempty"`     // e.g. "PC", "PS5" or "Xbox"
+	CriticScore    int      `json:"critic_score,omitempty"`  // OpenCritic top critic average, 0-100
+	CriticTier     string   `json:"critic_tier,omitempty"`   // OpenCritic tier, e.g. "Mighty"
+	CriticURL      string   `json:"critic_url,omitempty"`
+	ReleaseYear    int      `json:"release_year,omitempty"` // From IGDB
+	Developer      string   `json:"developer,omitempty"`
+	Screenshots    []string `json:"screenshots,omitempty"`
+	PlaytimeMain   float64  `json:"playtime_main,omitempty"`          // HowLongToBeat hours, main story
+	Playtime100    float64  `json:"playtime_completionist,omitempty"` // Hours to complete everything
+	ProtonDBTier   string   `json:"protondb_tier,omitempty"`          // e.g. "platinum", "gold" or "borked"
+	SteamDeck      string   `json:"steam_deck,omitempty"`             // "verified", "playable" or "unsupported"
+	TrailerURL     string   `json:"trailer_url,omitempty"`
+	AvailableIn    []string `json:"available_countries,omitempty"`   // Verified countries the giveaway is free in
+	UnavailableIn  []string `json:"unavailable_countries,omitempty"` // Verified countries it isn't, e.g. geo-restricted
+	BundleContents []string `json:"bundle_contents,omitempty"`       // Titles included in a bundle
+
+	// Regular price in the store currency, used by filters and statistics
+	originalPrice float64
+	currency      string
+
+	// Raw offer data for the detail endpoint
+	promotions []PromoWindow
+	keyImages  []KeyImage
+
+	// Set for notification channels whose region the game isn't free in
+	regionWarning string
+
+	// Epic catalog identifiers of the offer
+	namespace string
+	offerID   string
+
+	// Parsed promotion window, zero when the dates are unknown
+	startTime time.Time
+	endTime   time.Time
 }
 
 type APIResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message,omitempty"`
-	Count   int    `json:"count"`
-	Data    []Game `json:"data"`
-}
+	Success       bool   `json:"success"`
+	SchemaVersion int    `json:"schema_version"`
+	Code          string `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string `json:"message,omitempty"`
+	RequestID     string `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	Count         int    `json:"count"`                // Games in this response
+	Total         int    `json:"total"`                // Games before pagination
+	Offset        int    `json:"offset,omitempty"`     // Pagination parameters, if set
+	Limit         int    `json:"limit,omitempty"`
+	Source        string `json:"source,omitempty"`   // Data source of the games, "promotions" or "graphql"
+	Stale         bool   `json:"stale,omitempty"`    // Fetching failed, the games are from an earlier fetch
+	Degraded      bool   `json:"degraded,omitempty"` // Epic's circuit is open, only cached games are served
+	Age           int    `json:"age,omitempty"`      // Seconds since the games were fetched
+	Data          []Game `json:"data"`
 
-const freeGamesQuery = `
-query searchStoreQuery(
-  $category: String,
-  $count: Int,
-  $country: String!,
-  $locale: String,
-  $freeGame: Boolean,
-  $onSale: Boolean,
-  $withPrice: Boolean = true
-) {
-  Catalog {
-    searchStore(
-      category: $category
-      count: $count
-      country: $country
-      freeGame: $freeGame
-      onSale: $onSale
-      locale: $locale
-    ) {
-      elements {
-        title
-        description
-        seller {
-          name
-        }
-        keyImages {
-          type
-          url
-        }
-        productSlug
-        urlSlug
-        url
-        offerMappings {
-          pageSlug
-          pageType
-        }
-        catalogNs {
-          mappings(pageType: "productHome") {
-            pageSlug
-            pageType
-          }
-        }
-        linkedOffer {
-          effectiveDate
-          customAttributes{
-            key
-            value
-          }
-        }
-        categories {
-          path
-        }
-        namespace
-        id
-        price(country: $country) @include(if: $withPrice) {
-          totalPrice {
-            fmtPrice(locale: $locale) {
-              discountPrice
-              originalPrice
-            }
-          }
-        }
-        promotions {
-          promotionalOffers {
-            promotionalOffers {
-              startDate
-              endDate
-              discountSetting {
-                discountType
-                discountPercentage
-              }
-            }
-          }
-          upcomingPromotionalOffers {
-            promotionalOffers {
-              startDate
-              endDate
-              discountSetting {
-                discountType
-                discountPercentage
-              }
-            }
-          }
-        }
-      }
-    }
-  }
+	fields fieldMask // Game fields to return, all when nil
 }
-`
 
-type GraphQLRequest struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
-}
+// Epic's catalog types, shared with the epicgames package
+type (
+	GraphQLRequest        = epicgames.GraphQLRequest
+	GraphQLError          = epicgames.GraphQLError
+	PromotionalOfferGroup = epicgames.PromotionalOfferGroup
+)
 
+// GraphQLResponse is a searchStore or freeGamesPromotions response
 type GraphQLResponse struct {
-	Data struct {
-		Catalog struct {
-			SearchStore struct {
-				Elements []struct {
-					Title       string `json:"title"`
-					Description string `json:"description"`
-					Seller      struct {
-						Name string `json:"name"`
-					} `json:"seller"`
-					KeyImages []struct {
-						Type string `json:"type"`
-						URL  string `json:"url"`
-					} `json:"keyImages"`
-					ProductSlug string `json:"productSlug"`
-					URL         string `json:"url"`
-					UrlSlug     string `json:"urlSlug"`
-					OfferMappings []struct {
-						PageSlug string `json:"pageSlug"`
-						PageType string `json:"pageType"`
-					} `json:"offerMappings"`
-					CatalogNs struct {
-						Mappings []struct {
-							PageSlug string `json:"pageSlug"`
-							PageType string `json:"pageType"`
-						} `json:"mappings"`
-					} `json:"catalogNs"`
-					LinkedOffer struct {
-						EffectiveDate string `json:"effectiveDate"`
-						CustomAttributes []struct {
-							Key   string `json:"key"`
-							Value string `json:"value"`
-						} `json:"customAttributes"`
-					} `json:"linkedOffer"`
-					Categories []struct {
-						Path string `json:"path"`
-					} `json:"categories"`
-					Namespace string `json:"namespace"`
-					ID        string `json:"id"`
-					Price       struct {
-						TotalPrice struct {
-							FmtPrice struct {
-								OriginalPrice string `json:"originalPrice"`
-								DiscountPrice string `json:"discountPrice"`
-							} `json:"fmtPrice"`
-						} `json:"totalPrice"`
-					} `json:"price"`
-					Promotions struct {
-						PromotionalOffers []struct {
-							PromotionalOffers []struct {
-								StartDate       string `json:"startDate"`
-								EndDate         string `json:"endDate"`
-								DiscountSetting struct {
-									DiscountType       string `json:"discountType"`
-									DiscountPercentage int    `json:"discountPercentage"`
-								} `json:"discountSetting"`
-							} `json:"promotionalOffers"`
-						} `json:"promotionalOffers"`
-						UpcomingPromotionalOffers []struct {
-							PromotionalOffers []struct {
-								StartDate       string `json:"startDate"`
-								EndDate         string `json:"endDate"`
-								DiscountSetting struct {
-									DiscountType       string `json:"discountType"`
-									DiscountPercentage int    `json:"discountPercentage"`
-								} `json:"discountSetting"`
-							} `json:"promotionalOffers"`
-						} `json:"upcomingPromotionalOffers"`
-					} `json:"promotions"`
-				} `json:"elements"`
-			} `json:"searchStore"`
-		} `json:"Catalog"`
-	} `json:"data"`
+	epicgames.CatalogResponse
+
+	rawElements map[string]json.RawMessage // By game ID, only set when archiving raw payloads
 }
 
 func getEnvString(key, defaultValue string) string {
@@ -241,6 +151,43 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return boolValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: Environment variable %s is not a valid number, using default: %v\n", key, defaultValue)
+		return defaultValue
+	}
+	return floatValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	durationValue, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: Environment variable %s is not a valid duration, using default: %v\n", key, defaultValue)
+		return defaultValue
+	}
+	return durationValue
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
 	// Load .env file
 	err := godotenv.Load()
@@ -249,156 +196,826 @@ func main() {
 	}
 	
 	port := flag.Int("port", getEnvInt("PORT", 8080), "Port for the API server to listen on")
+	grpcPort := flag.Int("grpc-port", getEnvInt("GRPC_PORT", 0), "Port for the gRPC FreeGames service (disabled if 0)")
+	tlsCert := flag.String("tls-cert", os.Getenv("TLS_CERT"), "Certificate file (PEM) to serve HTTPS with, loaded again when it changes")
+	tlsKey := flag.String("tls-key", os.Getenv("TLS_KEY"), "Private key file (PEM) of -tls-cert")
+	autocertHost := flag.String("autocert-host", os.Getenv("AUTOCERT_HOST"), "Comma-separated hostnames to serve HTTPS for with certificates from Let's Encrypt, accepting its terms of service")
+	autocertCache := flag.String("autocert-cache", getEnvString("AUTOCERT_CACHE", "autocert-cache"), "Directory keeping the Let's Encrypt account key and certificates")
+	autocertEmail := flag.String("autocert-email", os.Getenv("AUTOCERT_EMAIL"), "Contact email for Let's Encrypt expiry notices (optional)")
+	httpRedirectPort := flag.Int("http-redirect-port", getEnvInt("HTTP_REDIRECT_PORT", 0), "With HTTPS, plain HTTP port redirecting to it and answering Let's Encrypt HTTP-01 challenges (disabled if 0)")
+	adminPort := flag.Int("admin-port", getEnvInt("ADMIN_PORT", 0), "Port for pprof and the /v1/admin/debug runtime snapshot (disabled if 0)")
+	adminAddr := flag.String("admin-addr", getEnvString("ADMIN_ADDR", "127.0.0.1"), "Address the admin port listens on, other than loopback only with API keys")
+	debugEndpoints := flag.Bool("debug-endpoints", getEnvBool("DEBUG_ENDPOINTS", false), "Serve pprof and /v1/admin/debug on the API port, behind the API keys")
+	apiKeysList := flag.String("api-keys", os.Getenv("API_KEYS"), "Comma-separated API keys required to trigger notifications (no authentication if empty)")
+	apiKeysFile := flag.String("api-keys-file", os.Getenv("API_KEYS_FILE"), "File with one API key per line, in addition to -api-keys")
+	rateLimit := flag.String("rate-limit", getEnvString("RATE_LIMIT", "60/min"), "Requests per client IP allowed on API endpoints, e.g. 60/min (off disables)")
+	notifyRateLimit := flag.String("notify-rate-limit", getEnvString("NOTIFY_RATE_LIMIT", "5/hour"), "Requests per client IP allowed on /notify and with notify=true or refresh=true, e.g. 5/hour (off disables)")
+	trustProxyHeaders := flag.Bool("trust-proxy-headers", getEnvBool("TRUST_PROXY_HEADERS", false), "Identify clients by X-Forwarded-For/X-Real-IP from any address, prefer -trusted-proxies")
+	trustedProxies := flag.String("trusted-proxies", os.Getenv("TRUSTED_PROXIES"), "Comma-separated CIDRs and addresses of the reverse proxies whose X-Forwarded-For/X-Real-IP identify clients")
+	accessLog := flag.Bool("access-log", getEnvBool("ACCESS_LOG", true), "Log the method, path, status, latency, client and request ID of every HTTP request")
+	compressMinSize := flag.Int("compress-min-size", getEnvInt("COMPRESS_MIN_SIZE", 1024), "Minimum size in bytes of JSON and HTML responses compressed with gzip/deflate (-1 disables compression)")
+	streamRefreshInterval := flag.Duration("stream-refresh-interval", getEnvDuration("STREAM_REFRESH_INTERVAL", 5*time.Minute), "How often games are refreshed for /v1/stream while clients are connected (0 disables the stream)")
+	streamExpiringWithin := flag.Duration("stream-expiring-within", getEnvDuration("STREAM_EXPIRING_WITHIN", 24*time.Hour), "Send game_expiring stream events for free games ending within this duration")
 	
 	discordWebhook := flag.String("discord-webhook", os.Getenv("DISCORD_WEBHOOK_URL"), "Discord webhook URL for notifications")
+	discordMentionFree := flag.String("discord-mention-free", os.Getenv("DISCORD_MENTION_FREE"), "Mention added to Discord notifications with currently free games, e.g. <@&ROLE_ID> or @everyone")
+	discordMentionUpcoming := flag.String("discord-mention-upcoming", os.Getenv("DISCORD_MENTION_UPCOMING"), "Mention added to Discord notifications with upcoming games")
+	discordEditInPlace := flag.Bool("discord-edit-in-place", getEnvBool("DISCORD_EDIT_IN_PLACE", false), "Keep a single Discord message with the current free games and edit it when the lineup changes")
+	discordMessageStateFile := flag.String("discord-message-state-file", os.Getenv("DISCORD_MESSAGE_STATE_FILE"), "File used to store the ID of the Discord message edited in place (in-memory if empty)")
+	discordTemplateFile := flag.String("discord-template", os.Getenv("DISCORD_TEMPLATE_FILE"), "JSON template file restyling Discord notifications")
+	messageTemplatesDir := flag.String("message-templates", os.Getenv("MESSAGE_TEMPLATES_DIR"), "Directory of .tmpl files replacing the built-in message templates of the channels")
+	discordThreadID := flag.String("discord-thread-id", os.Getenv("DISCORD_THREAD_ID"), "Post Discord notifications into this thread of the webhook's channel")
+	discordForumPosts := flag.Bool("discord-forum-posts", getEnvBool("DISCORD_FORUM_POSTS", false), "Create a Discord forum post per game (for forum channel webhooks)")
+	discordBotToken := flag.String("discord-bot-token", os.Getenv("DISCORD_BOT_TOKEN"), "Discord bot token, posts notifications with link buttons to -discord-channel-id")
+	discordChannelID := flag.String("discord-channel-id", os.Getenv("DISCORD_CHANNEL_ID"), "Discord channel the bot posts notifications to")
+	discordMoreGamesURL := flag.String("discord-more-games-url", os.Getenv("DISCORD_MORE_GAMES_URL"), "URL of the \"More free games\" button on bot messages, e.g. this API instance")
+	discordBotStateFile := flag.String("discord-bot-state-file", os.Getenv("DISCORD_BOT_STATE_FILE"), "File used to remember games already posted by the Discord bot (in-memory if empty)")
+	discordStateFile := flag.String("discord-state-file", os.Getenv("DISCORD_STATE_FILE"), "File used to remember games already sent to Discord (in-memory if empty)")
 	
 	countryCode := flag.String("country", getEnvString("COUNTRY_CODE", "PH"), "Country code for Epic Games Store")
 	locale := flag.String("locale", getEnvString("LOCALE", "en-PH"), "Locale for Epic Games Store")
 	timezone := flag.String("timezone", getEnvString("TIMEZONE", "Asia/Manila"), "Timezone for date/time formatting")
+	flag.BoolVar(&includeAddons, "include-addons", getEnvBool("INCLUDE_ADDONS", false), "Also look for free DLC and add-ons, not just base games and bundles")
+	flag.BoolVar(&includeEpicMobile, "epic-mobile", getEnvBool("EPIC_MOBILE", false), "Also track the free games of the Epic Games Store on Android and iOS")
+	flag.BoolVar(&includeGOG, "gog-giveaways", getEnvBool("GOG_GIVEAWAYS", false), "Also track the games GOG gives away")
+	flag.BoolVar(&includePrimeGaming, "prime-gaming", getEnvBool("PRIME_GAMING", false), "Also track the monthly free games of Prime Gaming")
+	flag.BoolVar(&includeSteam, "steam-giveaways", getEnvBool("STEAM_GIVEAWAYS", false), "Also track limited-time free-to-keep promotions on Steam")
+	flag.BoolVar(&includeItchIO, "itch-io", getEnvBool("ITCH_IO", false), "Also track 100%-off sales on itch.io")
+	flag.BoolVar(&includeUbisoft, "ubisoft-giveaways", getEnvBool("UBISOFT_GIVEAWAYS", false), "Also track Ubisoft Connect's free game events")
+	flag.BoolVar(&includePSPlus, "ps-plus", getEnvBool("PS_PLUS", false), "Also track the PlayStation Plus monthly games")
+	flag.BoolVar(&includeGamePass, "xbox-game-pass", getEnvBool("XBOX_GAME_PASS", false), "Also track the games recently added to Xbox Game Pass")
+	flag.Float64Var(&itchIOMinRating, "itch-io-min-rating", getEnvFloat("ITCH_IO_MIN_RATING", 0), "Minimum rating (0-5) of the itch.io games tracked, unrated games are skipped when set")
+	verifyCountriesList := flag.String("verify-countries", os.Getenv("VERIFY_COUNTRIES"), "Comma-separated country codes every Epic giveaway is checked in, e.g. US,DE,RU (disabled if empty)")
+	flag.StringVar(&dataSource, "data-source", getEnvString("DATA_SOURCE", sourceAuto), "Where to fetch free games from: auto (freeGamesPromotions with GraphQL fallback), promotions or graphql")
+	flag.DurationVar(&freeGamesTTL, "cache-ttl", getEnvDuration("CACHE_TTL", freeGamesTTL), "How long fetched free games are reused before asking Epic again (0 disables the cache)")
 	
 	enableCron := flag.Bool("enable-cron", getEnvBool("ENABLE_CRON", false), "Enable built-in cron job to check for free games")
 	cronSchedule := flag.String("cron-schedule", getEnvString("CRON_SCHEDULE", "0 0 0 * * *"), "Cron schedule expression for checking free games")
+	cronJitter := flag.Duration("cron-jitter", getEnvDuration("CRON_JITTER", 2*time.Minute), "Random wait before each cron scheduled check, so instances don't all hit Epic at once (0 disables)")
+	outboundProxy := flag.String("outbound-proxy", os.Getenv("OUTBOUND_PROXY"), "http, https, socks5 or socks5h proxy URL for every outgoing request, e.g. socks5h://127.0.0.1:9050 for Tor (HTTP_PROXY/HTTPS_PROXY apply if empty)")
+	fixtureDir := flag.String("fixture", os.Getenv("EPIC_FIXTURE_DIR"), "Directory of recorded Epic responses served instead of the live store, for testing without network (disabled if empty)")
+	fixtureMode := flag.String("fixture-mode", getEnvString("EPIC_FIXTURE_MODE", fixtureReplay), "What -fixture does: replay serves the recorded responses, record saves the live responses into the directory")
+	flag.StringVar(&userAgent, "user-agent", getEnvString("USER_AGENT", userAgent), "User-Agent of outgoing requests, except those to the Epic store which look like a browser")
+	flag.IntVar(&fetchConcurrency, "fetch-concurrency", getEnvInt("FETCH_CONCURRENCY", fetchConcurrency), "Stores and verified countries fetched at once")
+	flag.DurationVar(&fetchTimeout, "fetch-timeout", getEnvDuration("FETCH_TIMEOUT", fetchTimeout), "Longest wait for the free games from Epic and the other stores, retries included (0 disables the timeout)")
+	flag.DurationVar(&enrichTimeout, "enrich-timeout", getEnvDuration("ENRICH_TIMEOUT", enrichTimeout), "Longest wait for critic scores, playtimes and the other enrichments of fetched games (0 disables the timeout)")
+	flag.DurationVar(&notifyTimeout, "notify-timeout", getEnvDuration("NOTIFY_TIMEOUT", notifyTimeout), "Longest wait for a notification on each channel (0 disables the timeout)")
+	flag.IntVar(&epicRetries, "epic-retries", getEnvInt("EPIC_RETRIES", epicRetries), "How many times a failed request to Epic (network error, 429 or 5xx) is retried (0 disables retries)")
+	flag.DurationVar(&epicRetryDelay, "epic-retry-delay", getEnvDuration("EPIC_RETRY_DELAY", epicRetryDelay), "Wait before retrying a failed request to Epic, doubled for every next retry, with jitter")
+	flag.IntVar(&circuitThreshold, "circuit-threshold", getEnvInt("CIRCUIT_THRESHOLD", circuitThreshold), "Failures in a row that open the circuit of Epic or a notification channel (0 disables circuit breakers)")
+	flag.DurationVar(&circuitCooldown, "circuit-cooldown", getEnvDuration("CIRCUIT_COOLDOWN", circuitCooldown), "How long an open circuit fails fast before a recovery probe is let through")
+	checkRetries := flag.Int("check-retries", getEnvInt("CHECK_RETRIES", 3), "How many times a failed scheduled check is retried (0 disables retries)")
+	checkRetryDelay := flag.Duration("check-retry-delay", getEnvDuration("CHECK_RETRY_DELAY", time.Minute), "Wait before retrying a failed scheduled check, doubled for every next retry")
+	opsWebhookURL := flag.String("ops-webhook-url", os.Getenv("OPS_WEBHOOK_URL"), "Slack, Mattermost, Rocket.Chat or Discord webhook alerted when a scheduled check failed every retry")
+	catchUp := flag.Bool("catch-up", getEnvBool("CATCH_UP", false), "Run the check on startup when a cron scheduled check was missed since the last successful one")
+	scheduleMode := flag.String("schedule-mode", getEnvString("SCHEDULE_MODE", scheduleModeCron), "When the enabled check runs: cron, on -cron-schedule, or smart, shortly after the next giveaway starts or ends")
+	smartScheduleDelay := flag.Duration("smart-schedule-delay", getEnvDuration("SMART_SCHEDULE_DELAY", 2*time.Minute), "Wait after a giveaway starts or ends before a smart scheduled check")
+	smartScheduleJitter := flag.Duration("smart-schedule-jitter", getEnvDuration("SMART_SCHEDULE_JITTER", 3*time.Minute), "Random extra wait before a smart scheduled check")
+	smartSchedulePoll := flag.Duration("smart-schedule-poll", getEnvDuration("SMART_SCHEDULE_POLL", 6*time.Hour), "Longest wait between smart scheduled checks, when no giveaway date is known")
+	historyFile := flag.String("history-file", os.Getenv("HISTORY_FILE"), "File used to archive every detected giveaway for /v1/history (in-memory if empty)")
+	databaseURL := flag.String("database-url", getEnvString("DATABASE_URL", os.Getenv("DATABASE_FILE")), "Database persisting seen games, deliveries, history and push subscriptions instead of their state files: memory://, sqlite://path (or a plain path), postgres://... or redis://...")
+	stateFilePath := flag.String("state-file", os.Getenv("STATE_FILE"), "JSON file persisting the games announced on every channel and the last scheduled check, for channels without their own state file when there's no database")
+	deliveryRetries := flag.Int("delivery-retries", getEnvInt("DELIVERY_RETRIES", 3), "How many times a failed notification is retried (0 disables retries)")
+	deliveryRetryDelay := flag.Duration("delivery-retry-delay", getEnvDuration("DELIVERY_RETRY_DELAY", 10*time.Minute), "Delay before retrying a failed notification, multiplied by the attempts made")
+	deliveryRetention := flag.Duration("delivery-retention", getEnvDuration("DELIVERY_RETENTION", 90*24*time.Hour), "How long notification attempts are kept in the delivery log (0 keeps them forever)")
+	historyCompactAfter := flag.Duration("history-compact-after", getEnvDuration("HISTORY_COMPACT_AFTER", 30*24*time.Hour), "Drop the images of giveaways that ended this long ago from the history, which keeps the rest forever (0 disables)")
+	archiveRaw := flag.Bool("archive-raw", getEnvBool("ARCHIVE_RAW", false), "Archive the compressed catalog payload of every detected giveaway for /v1/admin/raw/{offerID}, to debug parsing after a promotion ends")
+	rawRetention := flag.Duration("raw-retention", getEnvDuration("RAW_RETENTION", 30*24*time.Hour), "How long archived catalog payloads are kept after they were last fetched (0 keeps them forever)")
+	maintenanceSchedule := flag.String("maintenance-schedule", getEnvString("MAINTENANCE_SCHEDULE", "0 30 4 * * *"), "Cron schedule of the job applying the retention policy and compacting the database (off disables it)")
+	openCriticAPIKey := flag.String("opencritic-api-key", os.Getenv("OPENCRITIC_API_KEY"), "RapidAPI key for the OpenCritic API, adds critic scores to games (disabled if empty)")
+	openCriticCacheFile := flag.String("opencritic-cache-file", os.Getenv("OPENCRITIC_CACHE_FILE"), "File used to cache critic scores (in-memory if empty)")
+	igdbClientID := flag.String("igdb-client-id", os.Getenv("IGDB_CLIENT_ID"), "Twitch client ID used for IGDB, adds release year, developer, screenshots and summaries to games (disabled if empty)")
+	igdbClientSecret := flag.String("igdb-client-secret", os.Getenv("IGDB_CLIENT_SECRET"), "Twitch client secret used for IGDB")
+	igdbCacheFile := flag.String("igdb-cache-file", os.Getenv("IGDB_CACHE_FILE"), "File used to cache IGDB metadata (in-memory if empty)")
+	howLongToBeat := flag.Bool("howlongtobeat", getEnvBool("HOWLONGTOBEAT", false), "Add HowLongToBeat playtimes to games")
+	howLongToBeatCacheFile := flag.String("howlongtobeat-cache-file", os.Getenv("HOWLONGTOBEAT_CACHE_FILE"), "File used to cache HowLongToBeat playtimes (in-memory if empty)")
+	protonDB := flag.Bool("protondb", getEnvBool("PROTONDB", false), "Add ProtonDB and Steam Deck compatibility hints to PC games")
+	protonDBCacheFile := flag.String("protondb-cache-file", os.Getenv("PROTONDB_CACHE_FILE"), "File used to cache compatibility hints (in-memory if empty)")
+	trailers := flag.Bool("trailers", getEnvBool("TRAILERS", false), "Add trailer links to games and notifications")
+	trailersCacheFile := flag.String("trailers-cache-file", os.Getenv("TRAILERS_CACHE_FILE"), "File used to cache trailer links (in-memory if empty)")
+	mysteryStateFile := flag.String("mystery-state-file", os.Getenv("MYSTERY_STATE_FILE"), "File used to remember mystery game placeholders, to announce their reveal (in-memory if empty)")
+	goLiveAlerts := flag.Bool("go-live-alerts", getEnvBool("GO_LIVE_ALERTS", false), "Send an alert when an upcoming game becomes free, independent of the cron schedule")
+	
+	mastodonInstance := flag.String("mastodon-instance", os.Getenv("MASTODON_INSTANCE_URL"), "Mastodon instance URL for posting new free games")
+	mastodonToken := flag.String("mastodon-token", os.Getenv("MASTODON_ACCESS_TOKEN"), "Mastodon access token with write:statuses and write:media scopes")
+	mastodonVisibility := flag.String("mastodon-visibility", getEnvString("MASTODON_VISIBILITY", "public"), "Visibility of Mastodon posts (public, unlisted, private, direct)")
+	mastodonStateFile := flag.String("mastodon-state-file", os.Getenv("MASTODON_STATE_FILE"), "File used to remember games already posted to Mastodon (in-memory if empty)")
+	
+	twitterConsumerKey := flag.String("twitter-consumer-key", os.Getenv("TWITTER_CONSUMER_KEY"), "X/Twitter API consumer key")
+	twitterConsumerSecret := flag.String("twitter-consumer-secret", os.Getenv("TWITTER_CONSUMER_SECRET"), "X/Twitter API consumer secret")
+	twitterAccessToken := flag.String("twitter-access-token", os.Getenv("TWITTER_ACCESS_TOKEN"), "X/Twitter access token of the posting account")
+	twitterAccessSecret := flag.String("twitter-access-secret", os.Getenv("TWITTER_ACCESS_TOKEN_SECRET"), "X/Twitter access token secret of the posting account")
+	twitterAttachImage := flag.Bool("twitter-attach-image", getEnvBool("TWITTER_ATTACH_IMAGE", true), "Attach the game's key image to tweets")
+	twitterMaxPerRun := flag.Int("twitter-max-per-run", getEnvInt("TWITTER_MAX_PER_RUN", 3), "Maximum number of tweets per run (0 for unlimited)")
+	twitterStateFile := flag.String("twitter-state-file", os.Getenv("TWITTER_STATE_FILE"), "File used to remember games already tweeted (in-memory if empty)")
+	
+	blueskyService := flag.String("bluesky-service", getEnvString("BLUESKY_SERVICE", "https://bsky.social"), "Bluesky PDS URL")
+	blueskyIdentifier := flag.String("bluesky-identifier", os.Getenv("BLUESKY_IDENTIFIER"), "Bluesky handle or DID of the posting account")
+	blueskyPassword := flag.String("bluesky-password", os.Getenv("BLUESKY_APP_PASSWORD"), "Bluesky app password of the posting account")
+	blueskyStateFile := flag.String("bluesky-state-file", os.Getenv("BLUESKY_STATE_FILE"), "File used to remember games already posted to Bluesky (in-memory if empty)")
+	
+	webhookURL := flag.String("webhook-url", os.Getenv("WEBHOOK_URL"), "Generic webhook URL that receives the free games")
+	webhookMethod := flag.String("webhook-method", getEnvString("WEBHOOK_METHOD", "POST"), "HTTP method used for the generic webhook")
+	webhookContentType := flag.String("webhook-content-type", getEnvString("WEBHOOK_CONTENT_TYPE", "application/json"), "Content type of the generic webhook payload")
+	webhookHeaders := flag.String("webhook-headers", os.Getenv("WEBHOOK_HEADERS"), "JSON object of extra headers for the generic webhook")
+	webhookTemplate := flag.String("webhook-template", os.Getenv("WEBHOOK_TEMPLATE_FILE"), "Go template file used to render the generic webhook payload")
+	
+	natsURL := flag.String("nats-url", os.Getenv("NATS_URL"), "NATS server URL for publishing game events")
+	natsSubjectPrefix := flag.String("nats-subject-prefix", getEnvString("NATS_SUBJECT_PREFIX", "epicgames"), "Subject prefix for NATS game events")
+	natsStream := flag.String("nats-stream", os.Getenv("NATS_STREAM"), "JetStream stream name for persisting events (core NATS if empty)")
+	natsExpiringWithin := flag.Duration("nats-expiring-within", getEnvDuration("NATS_EXPIRING_WITHIN", 24*time.Hour), "Emit game.expiring events for free games ending within this duration")
+	
+	notifyURLs := flag.String("notify-urls", os.Getenv("NOTIFY_URLS"), "Apprise-style notification URLs separated by spaces or commas")
+	notifyFilters := flag.String("notify-filters", os.Getenv("NOTIFY_FILTERS"), "JSON object of per-channel game filters, keyed by channel name")
+	notifyOfferKinds := flag.String("notify-offer-kinds", getEnvString("NOTIFY_OFFER_KINDS", offerKindGiveaway+","+offerKindSubscription), "Comma-separated offer kinds to notify about by default (giveaway, free_week, always_free, subscription or all)")
+	
+	vapidPublicKey := flag.String("vapid-public-key", os.Getenv("VAPID_PUBLIC_KEY"), "VAPID public key for browser push notifications")
+	vapidPrivateKey := flag.String("vapid-private-key", os.Getenv("VAPID_PRIVATE_KEY"), "VAPID private key for browser push notifications")
+	vapidSubject := flag.String("vapid-subject", os.Getenv("VAPID_SUBJECT"), "Contact URL or email (mailto:) sent to push services")
+	pushSubscriptionsFile := flag.String("push-subscriptions-file", os.Getenv("PUSH_SUBSCRIPTIONS_FILE"), "File used to store browser push subscriptions (in-memory if empty)")
+	pushStateFile := flag.String("push-state-file", os.Getenv("PUSH_STATE_FILE"), "File used to remember games already pushed to browsers (in-memory if empty)")
+	generateVAPIDKeys := flag.Bool("generate-vapid-keys", false, "Generate a VAPID key pair for browser push notifications and exit")
+	
+	fcmServiceAccount := flag.String("fcm-service-account", os.Getenv("FCM_SERVICE_ACCOUNT_FILE"), "Firebase service account JSON key file for FCM notifications")
+	fcmTopic := flag.String("fcm-topic", getEnvString("FCM_TOPIC", "free-games"), "FCM topic that receives new free game alerts")
+	fcmStateFile := flag.String("fcm-state-file", os.Getenv("FCM_STATE_FILE"), "File used to remember games already sent to FCM (in-memory if empty)")
+	
+	twilioAccountSID := flag.String("twilio-account-sid", os.Getenv("TWILIO_ACCOUNT_SID"), "Twilio account SID for SMS alerts")
+	twilioAuthToken := flag.String("twilio-auth-token", os.Getenv("TWILIO_AUTH_TOKEN"), "Twilio auth token for SMS alerts")
+	twilioFrom := flag.String("twilio-from", os.Getenv("TWILIO_FROM"), "Twilio phone number SMS alerts are sent from")
+	twilioTo := flag.String("twilio-to", os.Getenv("TWILIO_TO"), "Comma-separated phone numbers that receive SMS alerts")
+	twilioMaxLength := flag.Int("twilio-max-length", getEnvInt("TWILIO_MAX_LENGTH", 160), "Maximum characters per SMS")
+	twilioMaxPerRun := flag.Int("twilio-max-per-run", getEnvInt("TWILIO_MAX_PER_RUN", 5), "Maximum SMS sent per run across all recipients (0 for unlimited)")
+	twilioStateFile := flag.String("twilio-state-file", os.Getenv("TWILIO_STATE_FILE"), "File used to remember games already texted (in-memory if empty)")
+	
+	ircServer := flag.String("irc-server", os.Getenv("IRC_SERVER"), "IRC server address (host:port) for announcing free games")
+	ircTLS := flag.Bool("irc-tls", getEnvBool("IRC_TLS", true), "Connect to the IRC server using TLS")
+	ircNick := flag.String("irc-nick", getEnvString("IRC_NICK", "EpicFreeGames"), "IRC nickname")
+	ircChannel := flag.String("irc-channel", os.Getenv("IRC_CHANNEL"), "IRC channel to announce free games in")
+	ircChannelKey := flag.String("irc-channel-key", os.Getenv("IRC_CHANNEL_KEY"), "Key (password) of the IRC channel")
+	ircSASLUser := flag.String("irc-sasl-user", os.Getenv("IRC_SASL_USER"), "IRC account name for SASL PLAIN authentication")
+	ircSASLPassword := flag.String("irc-sasl-password", os.Getenv("IRC_SASL_PASSWORD"), "IRC account password for SASL PLAIN authentication")
+	ircStateFile := flag.String("irc-state-file", os.Getenv("IRC_STATE_FILE"), "File used to remember games already announced on IRC (in-memory if empty)")
+	
+	mattermostWebhook := flag.String("mattermost-webhook", os.Getenv("MATTERMOST_WEBHOOK_URL"), "Mattermost incoming webhook URL")
+	mattermostChannel := flag.String("mattermost-channel", os.Getenv("MATTERMOST_CHANNEL"), "Mattermost channel overriding the webhook default")
+	rocketChatWebhook := flag.String("rocketchat-webhook", os.Getenv("ROCKETCHAT_WEBHOOK_URL"), "Rocket.Chat incoming webhook URL")
+	rocketChatChannel := flag.String("rocketchat-channel", os.Getenv("ROCKETCHAT_CHANNEL"), "Rocket.Chat channel overriding the webhook default")
+	
+	lineAccessToken := flag.String("line-access-token", os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"), "LINE Messaging API channel access token")
+	lineTargetID := flag.String("line-target-id", os.Getenv("LINE_TARGET_ID"), "LINE user, group or room ID that receives the games")
+	
+	zulipSite := flag.String("zulip-site", os.Getenv("ZULIP_SITE"), "Zulip organization URL, e.g. https://example.zulipchat.com")
+	zulipEmail := flag.String("zulip-email", os.Getenv("ZULIP_BOT_EMAIL"), "Email of the Zulip bot")
+	zulipAPIKey := flag.String("zulip-api-key", os.Getenv("ZULIP_API_KEY"), "API key of the Zulip bot")
+	zulipStream := flag.String("zulip-stream", os.Getenv("ZULIP_STREAM"), "Zulip stream to post free games to")
+	zulipTopic := flag.String("zulip-topic", getEnvString("ZULIP_TOPIC", "Epic Games free games"), "Zulip topic to post free games under")
 	
+	desktopNotifications := flag.Bool("desktop-notifications", getEnvBool("DESKTOP_NOTIFICATIONS", false), "Show native desktop notifications for new free games")
+	desktopStateFile := flag.String("desktop-state-file", os.Getenv("DESKTOP_STATE_FILE"), "File used to remember games already shown on the desktop (in-memory if empty)")
+	
+	flag.Usage = usage
 	flag.Parse()
+	command, commandArgs, err := parseCommand(flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch command {
+	case commandCompletion:
+		if err := runCompletionCommand(commandArgs, os.Stdout); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	case commandMan:
+		writeManPage(os.Stdout)
+		return
+	}
 
-	http.HandleFunc("/api/free-games", func(w http.ResponseWriter, r *http.Request) {
-		freeGamesHandler(w, r, *countryCode, *locale, *timezone, *discordWebhook)
-	})
-	http.HandleFunc("/", indexHandler)
-	
-	// Set up Discord webhook notification route (for manual triggering)
-	http.HandleFunc("/notify", func(w http.ResponseWriter, r *http.Request) {
-		if *discordWebhook == "" {
-			http.Error(w, "Discord webhook URL not configured", http.StatusInternalServerError)
-			return
+	if *generateVAPIDKeys {
+		privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+		if err != nil {
+			log.Fatalf("Error generating VAPID keys: %v", err)
 		}
-		
-		// Get free games
-		games, err := fetchFreeGames(*countryCode, *locale, true, *timezone)
+		fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", publicKey, privateKey)
+		return
+	}
+
+	if err := validateSource(dataSource); err != nil {
+		log.Fatalf("Error in data source: %v", err)
+	}
+	config := Config{
+		Timezone:            *timezone,
+		Country:             *countryCode,
+		Locale:              *locale,
+		MaintenanceSchedule: *maintenanceSchedule,
+		TLSCert:             *tlsCert,
+		TLSKey:              *tlsKey,
+		AutocertHosts:       splitList(*autocertHost),
+		URLs: map[string]string{
+			"discord-webhook":        *discordWebhook,
+			"discord-more-games-url": *discordMoreGamesURL,
+			"mastodon-instance":      *mastodonInstance,
+			"bluesky-service":        *blueskyService,
+			"webhook-url":            *webhookURL,
+			"mattermost-webhook":     *mattermostWebhook,
+			"rocketchat-webhook":     *rocketChatWebhook,
+			"zulip-site":             *zulipSite,
+			"ops-webhook-url":        *opsWebhookURL,
+		},
+	}
+	if *enableCron && *scheduleMode == scheduleModeCron {
+		config.CronSchedule = *cronSchedule
+	}
+	config.ScheduleMode = *scheduleMode
+	config.SmartSchedulePoll = *smartSchedulePoll
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if err := configureOutboundProxy(*outboundProxy); err != nil {
+		log.Fatalf("Invalid -outbound-proxy: %v", err)
+	}
+	if err := configureFixtures(*fixtureDir, *fixtureMode); err != nil {
+		log.Fatalf("Error loading fixtures: %v", err)
+	}
+	if *messageTemplatesDir != "" {
+		templates, err := LoadMessageTemplates(*messageTemplatesDir)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Error fetching games: %v", err), http.StatusInternalServerError)
-			return
+			log.Fatalf("Error loading message templates: %v", err)
 		}
-		
-		// Send notification to Discord
-		err = SendDiscordNotification(*discordWebhook, games)
+		messageTemplates = templates
+	}
+	countries, err := parseCountries(*verifyCountriesList)
+	if err != nil {
+		log.Fatalf("Error in verified countries: %v", err)
+	}
+	verifyCountries = countries
+
+	// Keep the requested free games fresh in the background
+	if command == commandServe && freeGamesTTL > 0 {
+		go runFreeGamesRefresher()
+	}
+
+	if *databaseURL != "" {
+		db, err := storage.Open(*databaseURL)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Error sending Discord notification: %v", err), http.StatusInternalServerError)
-			return
+			log.Fatalf("Error opening database: %v", err)
 		}
-		
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"message": fmt.Sprintf("Notification sent for %d games", len(games)),
-		})
-	})
+		defer db.Close()
+		stateDB = db
+		deliveryLog = db
+		if *stateFilePath != "" {
+			log.Println("Warning: STATE_FILE is ignored, the state is kept in DATABASE_URL")
+		}
+	} else if *stateFilePath != "" {
+		f, err := OpenStateFile(*stateFilePath)
+		if err != nil {
+			log.Fatalf("Error opening state file: %v", err)
+		}
+		stateFile = f
+		if run := f.LastRun(); run != nil {
+			log.Printf("Last scheduled check at %s found %d games", run.StartedAt.Format(time.RFC3339), run.Games)
+		}
+	}
+	if *archiveRaw {
+		rawArchive = deliveryLog
+		if stateDB == nil {
+			log.Println("Warning: Raw payloads are archived in memory, set DATABASE_URL to keep them across restarts")
+		}
+	}
+
+	// Archive every giveaway the service sees
+	history := NewHistoryStore(*historyFile)
+	pushStore := NewPushSubscriptionStore(*pushSubscriptionsFile)
+	// The games announced on every channel, by channel
+	seenStateFiles := map[string]string{
+		"discord":       *discordStateFile,
+		"discord-bot":   *discordBotStateFile,
+		"mastodon":      *mastodonStateFile,
+		"twitter":       *twitterStateFile,
+		"bluesky":       *blueskyStateFile,
+		"nats":          "",
+		"nats-expiring": "",
+		"webpush":       *pushStateFile,
+		"fcm":           *fcmStateFile,
+		"twilio":        *twilioStateFile,
+		"irc":           *ircStateFile,
+		"desktop":       *desktopStateFile,
+		"webhook":       "",
+		"mattermost":    "",
+		"rocketchat":    "",
+		"line":          "",
+		"zulip":         "",
+	}
+	for _, rawURL := range splitNotifyURLs(*notifyURLs) {
+		seenStateFiles[notifyURLChannel(rawURL)] = ""
+	}
+	seenTrackers := make(map[string]*SeenTracker, len(seenStateFiles))
+	for channel, path := range seenStateFiles {
+		if command == commandValidate {
+			// Test messages must not mark anything as announced
+			seenTrackers[channel] = NewSeenTracker("", "")
+			continue
+		}
+		seenTrackers[channel] = NewSeenTracker(channel, path)
+	}
+	state := &stateStores{history: history, push: pushStore, seen: seenTrackers}
 
-	// Set up cron job if enabled
-	if *enableCron {
-		setupCronJob(*cronSchedule, *countryCode, *locale, *timezone, *discordWebhook)
+	// export and import move the state between hosts, history prints it
+	switch command {
+	case commandExport, commandImport:
+		if err := runStateCommand(flag.Args(), state); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	case commandHistory:
+		if err := runHistoryCommand(commandArgs, os.Stdout, history); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
 	}
 
-	fmt.Printf("Epic Games API server listening on port %d...\n", *port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
-}
+	mysteries = NewMysteryTracker(*mysteryStateFile)
+	if *openCriticAPIKey != "" {
+		enrichers = append(enrichers, NewOpenCriticClient(*openCriticAPIKey, *openCriticCacheFile))
+	}
+	if *igdbClientID != "" {
+		if *igdbClientSecret == "" {
+			log.Fatalf("Invalid IGDB configuration: -igdb-client-secret is required with a client ID")
+		}
+		enrichers = append(enrichers, NewIGDBClient(*igdbClientID, *igdbClientSecret, *igdbCacheFile))
+	}
+	if *howLongToBeat {
+		enrichers = append(enrichers, NewHowLongToBeatClient(*howLongToBeatCacheFile))
+	}
+	if *protonDB {
+		enrichers = append(enrichers, NewProtonDBClient(*protonDBCacheFile))
+	}
+	if *trailers {
+		enrichers = append(enrichers, NewTrailerClient(*trailersCacheFile))
+	}
+
+	serverOptions := ServerOptions{
+		Country:  *countryCode,
+		Locale:   *locale,
+		Timezone: *timezone,
+		History:  history,
+	}
+
+	// check only prints the games, without connecting to any channel
+	if command == commandCheck {
+		status, err := runCheckCommand(commandArgs, os.Stdout, serverOptions)
+		if err != nil {
+			log.Printf("Error: %v", err)
+		}
+		os.Exit(status)
+	}
+
+	// Set up notification channels
+	var notifiers []Notifier
+	if *discordWebhook != "" || *discordBotToken != "" {
+		discordOptions := DiscordOptions{
+			MentionFree:      *discordMentionFree,
+			MentionUpcoming:  *discordMentionUpcoming,
+			EditInPlace:      *discordEditInPlace,
+			MessageStateFile: *discordMessageStateFile,
+			ThreadID:         *discordThreadID,
+			ForumPosts:       *discordForumPosts,
+			MoreGamesURL:     *discordMoreGamesURL,
+		}
+		if err := discordOptions.validate(); err != nil {
+			log.Fatalf("Invalid Discord configuration: %v", err)
+		}
+		if *discordTemplateFile != "" {
+			tmpl, err := LoadDiscordTemplate(*discordTemplateFile)
+			if err != nil {
+				log.Fatalf("Error loading Discord template: %v", err)
+			}
+			discordOptions.Template = tmpl
+		}
+
+		if *discordWebhook != "" {
+			notifiers = append(notifiers, NewDiscordNotifier(*discordWebhook, discordOptions, seenTrackers["discord"]))
+		}
+		if *discordBotToken != "" {
+			if *discordChannelID == "" {
+				log.Fatalf("Invalid Discord configuration: -discord-channel-id is required with a bot token")
+			}
+			if discordOptions.EditInPlace {
+				log.Printf("Warning: Edit-in-place mode is only supported for Discord webhooks")
+			}
+			notifiers = append(notifiers, NewDiscordBotNotifier(*discordBotToken, *discordChannelID, discordOptions, seenTrackers["discord-bot"]))
+		}
+	}
+	if *mastodonInstance != "" && *mastodonToken != "" {
+		notifiers = append(notifiers, NewMastodonNotifier(*mastodonInstance, *mastodonToken, *mastodonVisibility, seenTrackers["mastodon"]))
+	}
+	if *twitterConsumerKey != "" && *twitterAccessToken != "" {
+		credentials := TwitterCredentials{
+			ConsumerKey:       *twitterConsumerKey,
+			ConsumerSecret:    *twitterConsumerSecret,
+			AccessToken:       *twitterAccessToken,
+			AccessTokenSecret: *twitterAccessSecret,
+		}
+		notifiers = append(notifiers, NewTwitterNotifier(credentials, *twitterAttachImage, *twitterMaxPerRun, seenTrackers["twitter"]))
+	}
+	if *blueskyIdentifier != "" && *blueskyPassword != "" {
+		notifiers = append(notifiers, NewBlueskyNotifier(*blueskyService, *blueskyIdentifier, *blueskyPassword, seenTrackers["bluesky"]))
+	}
+	if *webhookURL != "" {
+		webhook, err := NewWebhookNotifier(*webhookURL, *webhookMethod, *webhookContentType, *webhookHeaders, *webhookTemplate, seenTrackers["webhook"])
+		if err != nil {
+			log.Fatalf("Error setting up generic webhook: %v", err)
+		}
+		notifiers = append(notifiers, webhook)
+	}
+	if *natsURL != "" {
+		natsNotifier, err := NewNATSNotifier(*natsURL, *natsSubjectPrefix, *natsStream, *natsExpiringWithin, seenTrackers["nats"], seenTrackers["nats-expiring"])
+		if err != nil {
+			log.Fatalf("Error setting up NATS: %v", err)
+		}
+		notifiers = append(notifiers, natsNotifier)
+	}
+	if *vapidPublicKey != "" && *vapidPrivateKey != "" {
+		notifiers = append(notifiers, NewWebPushNotifier(pushStore, *vapidPublicKey, *vapidPrivateKey, *vapidSubject, seenTrackers["webpush"]))
+
+		handleAPI("/v1/push/subscribe", "/api/push/subscribe", func(w http.ResponseWriter, r *http.Request) {
+			pushSubscribeHandler(w, r, pushStore)
+		})
+		handleAPI("/v1/push/vapid-public-key", "/api/push/vapid-public-key", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"schema_version": apiSchemaVersion, "public_key": *vapidPublicKey})
+		})
+		http.HandleFunc("/sw.js", serviceWorkerHandler)
+	}
+	if *fcmServiceAccount != "" {
+		fcmNotifier, err := NewFCMNotifier(*fcmServiceAccount, *fcmTopic, seenTrackers["fcm"])
+		if err != nil {
+			log.Fatalf("Error setting up FCM: %v", err)
+		}
+		notifiers = append(notifiers, fcmNotifier)
+	}
+	if *twilioAccountSID != "" && *twilioAuthToken != "" {
+		notifiers = append(notifiers, NewTwilioNotifier(*twilioAccountSID, *twilioAuthToken, *twilioFrom, splitList(*twilioTo),
+			*twilioMaxLength, *twilioMaxPerRun, seenTrackers["twilio"]))
+	}
+	if *ircServer != "" && *ircChannel != "" {
+		notifiers = append(notifiers, NewIRCNotifier(*ircServer, *ircTLS, *ircNick, *ircChannel, *ircChannelKey,
+			*ircSASLUser, *ircSASLPassword, seenTrackers["irc"]))
+	}
+	if *mattermostWebhook != "" {
+		notifiers = append(notifiers, NewMattermostNotifier(*mattermostWebhook, *mattermostChannel, seenTrackers["mattermost"]))
+	}
+	if *rocketChatWebhook != "" {
+		notifiers = append(notifiers, NewRocketChatNotifier(*rocketChatWebhook, *rocketChatChannel, seenTrackers["rocketchat"]))
+	}
+	if *lineAccessToken != "" && *lineTargetID != "" {
+		notifiers = append(notifiers, NewLINENotifier(*lineAccessToken, *lineTargetID, seenTrackers["line"]))
+	}
+	if *zulipSite != "" && *zulipAPIKey != "" && *zulipStream != "" {
+		notifiers = append(notifiers, NewZulipNotifier(*zulipSite, *zulipEmail, *zulipAPIKey, *zulipStream, *zulipTopic, seenTrackers["zulip"]))
+	}
+	if *desktopNotifications {
+		notifiers = append(notifiers, NewDesktopNotifier(seenTrackers["desktop"]))
+	}
+	if *notifyURLs != "" {
+		urlNotifiers, err := parseNotifyURLs(*notifyURLs, seenTrackers)
+		if err != nil {
+			log.Fatalf("Error parsing notification URLs: %v", err)
+		}
+		for _, n := range urlNotifiers {
+			log.Printf("Notification URL of %s is channel %s", n.Name(), channelID(n))
+		}
+		notifiers = append(notifiers, urlNotifiers...)
+	}
+	if *notifyFilters != "" {
+		filters, err := parseNotifyFilters(*notifyFilters)
+		if err != nil {
+			log.Fatalf("Error parsing notification filters: %v", err)
+		}
+		notifiers = applyNotifyFilters(notifiers, filters)
+	}
+	defaultOfferKinds, err := parseOfferKinds(*notifyOfferKinds)
+	if err != nil {
+		log.Fatalf("Error parsing notification offer kinds: %v", err)
+	}
+	notifiers = applyDefaultOfferKinds(notifiers, defaultOfferKinds)
+	notifiers = applyCircuitBreakers(notifiers)
+	serverOptions.Notifiers = notifiers
 
-func indexHandler(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+	if command == commandValidate {
+		if err := runValidateCommand(commandArgs, os.Stdout, notifiers); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+	if command == commandNotify {
+		if err := runNotifyCommand(commandArgs, serverOptions); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
 		return
 	}
 
-	html := `
-	<!DOCTYPE html>
-	<html lang="en">
-	<head>
-		<meta charset="UTF-8">
-		<meta name="viewport" content="width=device-width, initial-scale=1.0">
-		<title>Epic Games Free Games API</title>
-		<style>
-			body {
-				font-family: Arial, sans-serif;
-				line-height: 1.6;
-				margin: 0 auto;
-				max-width: 800px;
-				padding: 20px;
+	apiKeys, err := LoadAPIKeys(*apiKeysList, *apiKeysFile)
+	if err != nil {
+		log.Fatalf("Error loading API keys: %v", err)
+	}
+
+	handleAPI("/v1/free-games", "/api/free-games", func(w http.ResponseWriter, r *http.Request) {
+		// Only authenticated requests may trigger notifications
+		opts := serverOptions
+		if !apiKeys.Allowed(r) {
+			if notify, _ := strconv.ParseBool(r.URL.Query().Get("notify")); notify {
+				writeUnauthorized(w)
+				return
 			}
-			h1 {
-				color: #0078f2;
+			opts.Notifiers, opts.Targets = nil, nil
+		}
+		freeGamesHandler(w, r, opts)
+	})
+	handleAPI("/v1/free-games/", "/api/free-games/", func(w http.ResponseWriter, r *http.Request) {
+		gameDetailHandler(w, r, serverOptions)
+	})
+	handleAPI("/v1/history", "/api/history", func(w http.ResponseWriter, r *http.Request) {
+		historyHandler(w, r, history)
+	})
+	handleAPI("/v1/history/", "/api/history/", func(w http.ResponseWriter, r *http.Request) {
+		historyPriceHandler(w, r, history)
+	})
+	handleAPI("/v1/calendar", "/api/calendar", func(w http.ResponseWriter, r *http.Request) {
+		calendarJSONHandler(w, r, serverOptions)
+	})
+	handleAPI("/v1/stats", "/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		statsHandler(w, r, serverOptions)
+	})
+	var stream *GameStream
+	if *streamRefreshInterval > 0 {
+		stream = NewGameStream(*streamExpiringWithin)
+		go stream.Run(func() ([]Game, error) {
+			games, err := serverOptions.latestGames(context.Background(), true)
+			if err == nil {
+				history.Record(games, *countryCode)
 			}
-			pre {
-				background-color: #f5f5f5;
-				border-radius: 5px;
-				padding: 15px;
-				overflow-x: auto;
+			return games, err
+		}, *streamRefreshInterval)
+		handleAPI("/v1/stream", "/api/stream", func(w http.ResponseWriter, r *http.Request) {
+			streamHandler(w, r, stream)
+		})
+	}
+	http.HandleFunc("/badge.svg", func(w http.ResponseWriter, r *http.Request) {
+		badgeHandler(w, r, serverOptions)
+	})
+	http.HandleFunc("/og/", func(w http.ResponseWriter, r *http.Request) {
+		ogImageHandler(w, r, serverOptions)
+	})
+	http.HandleFunc("/calendar.ics", func(w http.ResponseWriter, r *http.Request) {
+		calendarHandler(w, r, serverOptions)
+	})
+	http.HandleFunc("/calendar", func(w http.ResponseWriter, r *http.Request) {
+		calendarPageHandler(w, r, serverOptions)
+	})
+	http.HandleFunc("/openapi.json", openAPIHandler)
+	http.HandleFunc("/docs", docsHandler)
+	http.HandleFunc("/docs/", docsAssetHandler)
+	http.HandleFunc("/assets/", dashboardAssetsHandler)
+	http.HandleFunc("/", indexHandler)
+	
+	// Set up notification route (for manual triggering)
+	handleAPI("/v1/notify", "/notify", apiKeys.Require(func(w http.ResponseWriter, r *http.Request) {
+		notifyHandler(w, r, serverOptions)
+	}))
+
+	// The admin endpoints expose credentials or post to the channels, never
+	// serve them publicly
+	requireAdmin := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !apiKeys.Enabled() {
+				httpError(w, "This endpoint requires API_KEYS", http.StatusForbidden)
+				return
 			}
-			code {
-				font-family: monospace;
+			apiKeys.Require(next)(w, r)
+		}
+	}
+
+	handleAPI("/v1/admin/export", "/api/admin/export", func(w http.ResponseWriter, r *http.Request) {
+		// The snapshot holds the push subscriptions' keys, never serve it publicly
+		if !apiKeys.Enabled() {
+			httpError(w, "Exporting the state requires API_KEYS", http.StatusForbidden)
+			return
+		}
+		apiKeys.Require(func(w http.ResponseWriter, r *http.Request) {
+			stateExportHandler(w, r, state)
+		})(w, r)
+	})
+
+	handleAPI("/v1/admin/raw/", "/api/admin/raw/", requireAdmin(rawOfferHandler))
+
+	// Audit and retry notification attempts
+	latestGames := func(ctx context.Context) ([]Game, error) {
+		return serverOptions.latestGames(ctx, true)
+	}
+	scheduler := cron.New(cron.WithSeconds())
+
+	// Notification targets managed from /admin, behind the API keys
+	targets := NewAdminTargets(scheduler, func(ctx context.Context) ([]Game, error) {
+		games, err := latestGames(ctx)
+		if err == nil {
+			history.Record(games, *countryCode)
+		}
+		return games, err
+	}, defaultOfferKinds, notifiers)
+	serverOptions.Targets = targets
+	handleAPI("/v1/admin/targets", "/api/admin/targets", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		adminTargetsHandler(w, r, targets)
+	}))
+	handleAPI("/v1/admin/targets/", "/api/admin/targets/", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		adminTargetHandler(w, r, targets, latestGames)
+	}))
+	http.HandleFunc("/admin", adminPageHandler)
+	http.HandleFunc("/admin/", adminPageHandler)
+
+	allNotifiers := func() []Notifier {
+		return append(slices.Clip(notifiers), targets.All()...)
+	}
+	handleAPI("/v1/deliveries", "/api/deliveries", requireAdmin(deliveriesHandler))
+	handleAPI("/v1/deliveries/", "/api/deliveries/", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		deliveryRetryHandler(w, r, allNotifiers(), latestGames)
+	}))
+	if *deliveryRetries > 0 && *deliveryRetryDelay > 0 {
+		go runDeliveryRetries(allNotifiers, latestGames, *deliveryRetries, *deliveryRetryDelay)
+	}
+
+	// Watch upcoming games to announce them the moment they go live
+	var goLive *GoLiveWatcher
+	if *goLiveAlerts {
+		goLive = NewGoLiveWatcher(func() ([]Game, error) {
+			games, err := serverOptions.latestGames(context.Background(), true)
+			if err == nil {
+				history.Record(games, *countryCode)
 			}
-		</style>
-	</head>
-	<body>
-		<h1>Epic Games Free Games API</h1>
-		<p>Use this API to get information about free games available on the Epic Games Store.</p>
-		
-		<h2>Endpoints</h2>
-		<h3>GET /api/free-games</h3>
-		<p>Returns all free games currently available and upcoming free games.</p>
-		
-		<h4>Query Parameters</h4>
-		<ul>
-			<li><code>upcoming</code> - Include upcoming free games (true/false, default: true)</li>
-			<li><code>country</code> - Country code for the store (default: PH)</li>
-			<li><code>locale</code> - Locale for text formatting (default: en-PH)</li>
-			<li><code>timezone</code> - Timezone for dates (default: Asia/Manila). Use standard IANA timezone names like "America/New_York", "Europe/London", or UTC offsets like "UTC+1"</li>
-		</ul>
-		
-		<h4>Example Request</h4>
-		<pre><code>GET /api/free-games?upcoming=false&timezone=America/New_York</code></pre>
-		
-		<h4>Example Response</h4>
-		<pre><code>{
-  "success": true,
-  "count": 1,
-  "data": [
-    {
-      "title": "Game Title",
-      "description": "Game description",
-      "image_url": "https://example.com/image.jpg",
-      "url": "https://store.epicgames.com/en-US/p/game-slug",
-      "status": "free",
-      "start_date": "2025-04-04 15:00:00 PHT",
-      "end_date": "2025-04-11 15:00:00 PHT",
-      "date_precision": "exact",
-      "publisher": "Publisher Name"
-    }
-  ]
-}</code></pre>
-
-        <h4>Date Fields</h4>
-        <p>The <code>start_date</code> and <code>end_date</code> fields show when a game is or will be available for free. Times are displayed in the requested timezone or Philippine Time (UTC+8) by default.</p>
-        
-        <h4>Date Precision Field</h4>
-        <p>The <code>date_precision</code> field indicates how accurate the start and end dates are:</p>
-        <ul>
-            <li><strong>exact</strong>: Dates are directly from Epic Games' API</li>
-            <li><strong>estimated</strong>: Dates are estimated based on typical free game periods</li>
-            <li><strong>unknown</strong>: Unable to determine accurate dates</li>
-        </ul>
-	</body>
-	</html>
-	`
-
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, html)
+			return games, err
+		}, allNotifiers, 30*time.Second)
+		go func() {
+			games, err := serverOptions.latestGames(context.Background(), true)
+			if err != nil {
+				log.Printf("Error fetching upcoming games for go-live alerts: %v", err)
+				return
+			}
+			goLive.Watch(games)
+		}()
+	}
+
+	// Set up cron job if enabled, and the maintenance job
+	scheduledCheck := func() ([]Game, error) {
+		return retryCheck(func() ([]Game, error) {
+			return runScheduledCheck(serverOptions, goLive)
+		}, CheckRetryPolicy{Retries: *checkRetries, Delay: *checkRetryDelay}, NewOpsAlerter(*opsWebhookURL))
+	}
+	if *enableCron && *scheduleMode == scheduleModeSmart {
+		if len(notifiers) == 0 {
+			log.Println("Warning: No notification channels configured. Scheduled checks will run but no notifications will be sent.")
+		}
+		log.Println("Setting up smart scheduled checks around giveaway start and end dates")
+		go NewSmartScheduler(scheduledCheck, *smartScheduleDelay, *smartScheduleJitter, *smartSchedulePoll).Run()
+	} else if *enableCron {
+		setupCronJob(scheduler, *cronSchedule, *cronJitter, notifiers, scheduledCheck)
+		if *catchUp {
+			go catchUpCheck(*cronSchedule, scheduledCheck)
+		}
+	}
+	if *maintenanceSchedule != "" && *maintenanceSchedule != "off" {
+		setupMaintenanceJob(scheduler, *maintenanceSchedule, history, RetentionPolicy{
+			Deliveries:          *deliveryRetention,
+			HistoryCompactAfter: *historyCompactAfter,
+			RawOffers:           *rawRetention,
+		})
+	}
+	if len(scheduler.Entries()) > 0 {
+		scheduler.Start()
+		log.Println("Cron scheduler started")
+	}
+
+	apiLimiter, err := parseRateLimit(*rateLimit)
+	if err != nil {
+		log.Fatalf("Invalid -rate-limit: %v", err)
+	}
+	notifyLimiter, err := parseRateLimit(*notifyRateLimit)
+	if err != nil {
+		log.Fatalf("Invalid -notify-rate-limit: %v", err)
+	}
+	proxies, err := ParseTrustedProxies(*trustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid -trusted-proxies: %v", err)
+	}
+	if *trustProxyHeaders && !proxies.Enabled() {
+		log.Println("Warning: TRUST_PROXY_HEADERS believes the forwarding headers of any client, set TRUSTED_PROXIES to the addresses of the reverse proxies")
+		proxies = trustAllProxies
+	}
+
+	// pprof and the runtime snapshot, on their own port or behind the API keys
+	var debugRoutes http.Handler
+	if *adminPort > 0 {
+		// pprof shows the command line, with any secret passed as a flag
+		if !isLoopbackHost(*adminAddr) && !apiKeys.Enabled() {
+			log.Fatal("Error: -admin-addr other than loopback requires API_KEYS")
+		}
+		if err := startAdminServer(*adminAddr, *adminPort, requestLogHandler(newAdminMux(apiKeys), nil, *accessLog)); err != nil {
+			log.Fatalf("Error starting admin server: %v", err)
+		}
+	} else if *debugEndpoints {
+		if !apiKeys.Enabled() {
+			log.Fatal("Error: -debug-endpoints requires API_KEYS, or serve them on -admin-port")
+		}
+		debugRoutes = newAdminMux(apiKeys)
+	}
+	handler := debugHandler(http.DefaultServeMux, debugRoutes)
+	handler = rateLimitHandler(handler, apiLimiter, notifyLimiter, proxies)
+	handler = compressHandler(handler, *compressMinSize)
+	handler = requestLogHandler(handler, proxies, *accessLog)
+
+	if *grpcPort > 0 {
+		err := startGRPCServer(*grpcPort, &freeGamesServer{
+			opts:    serverOptions,
+			keys:    apiKeys,
+			limiter: apiLimiter,
+			stream:  stream,
+		})
+		if err != nil {
+			log.Fatalf("Error starting gRPC server: %v", err)
+		}
+	}
+
+	// HTTPS from certificate files or Let's Encrypt, loaded before listening
+	// so a bad certificate fails the start
+	tlsOptions := TLSOptions{
+		CertFile:      *tlsCert,
+		KeyFile:       *tlsKey,
+		AutocertHosts: splitList(*autocertHost),
+		AutocertCache: *autocertCache,
+		AutocertEmail: *autocertEmail,
+		RedirectPort:  *httpRedirectPort,
+	}
+	var tlsConfig *tls.Config
+	if tlsOptions.Enabled() {
+		var redirect http.Handler
+		if tlsConfig, redirect, err = tlsOptions.serverTLS(*port); err != nil {
+			log.Fatalf("Error setting up TLS: %v", err)
+		}
+		if tlsOptions.RedirectPort > 0 {
+			if err := startRedirectServer(tlsOptions.RedirectPort, redirect); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+	// systemd socket activation passes the listening socket
+	listener, err := systemdListener()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if listener != nil {
+		fmt.Printf("Epic Games API server listening on the systemd socket %s...\n", listener.Addr())
+	} else {
+		if listener, err = net.Listen("tcp", fmt.Sprintf(":%d", *port)); err != nil {
+			log.Fatalf("Error listening on port %d: %v", *port, err)
+		}
+		fmt.Printf("Epic Games API server listening on port %d...\n", *port)
+	}
+
+	// Type=notify units start once the server accepts requests, and
+	// WatchdogSec restarts it when the background refresher gets stuck
+	if err := sdNotify("READY=1"); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if interval, ok := systemdWatchdogInterval(); ok {
+		go runSystemdWatchdog(interval, refresherHealthy)
+	}
+	if tlsConfig != nil {
+		server := &http.Server{Handler: handler, TLSConfig: tlsConfig}
+		log.Fatal(server.ServeTLS(listener, "", ""))
+	}
+	log.Fatal(http.Serve(listener, handler))
 }
 
-func freeGamesHandler(w http.ResponseWriter, r *http.Request, countryCode, locale, timezone, 
-					  webhookURL string) {
+// writeFreeGamesError writes an error response of the free games endpoint,
+// with the status and code of the error's kind
+func writeFreeGamesError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIResponse{
+		Success:       false,
+		SchemaVersion: apiSchemaVersion,
+		Code:          code,
+		RequestID:     requestID(w),
+		Message:       err.Error(),
+	})
+}
+
+func freeGamesHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
 	// Set default values
 	includeUpcoming := true
-	sendNotification := false // Flag to determine if we should send Discord notification
+	sendNotification := false // Flag to determine if we should send notifications
 
 	// Get query parameters
 	if upcoming := r.URL.Query().Get("upcoming"); upcoming != "" {
@@ -406,135 +1023,260 @@ func freeGamesHandler(w http.ResponseWriter, r *http.Request, countryCode, local
 			includeUpcoming = upcomingBool
 		}
 	}
+	withAddons := includeAddons
+	if addons := r.URL.Query().Get("include_addons"); addons != "" {
+		if addonsBool, err := strconv.ParseBool(addons); err == nil {
+			withAddons = addonsBool
+		}
+	}
 	
-	// Check if this request should trigger a notification
+	// Check if this request should trigger a notification. Only an explicit
+	// notify=true, authenticated when API keys are set, fetches fresh data.
+	explicitNotify := false
 	if notify := r.URL.Query().Get("notify"); notify != "" {
 		if notifyBool, err := strconv.ParseBool(notify); err == nil {
-			sendNotification = notifyBool && webhookURL != ""
+			sendNotification = notifyBool && len(opts.notifiers()) > 0
+			explicitNotify = sendNotification
 		}
 	} else {
-		sendNotification = webhookURL != ""
+		sendNotification = len(opts.notifiers()) > 0
 	}
 
-	games, err := fetchFreeGames(countryCode, locale, includeUpcoming, timezone)
-	
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	listOpts, err := parseListOptions(r.URL.Query())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		response := APIResponse{
-			Success: false,
-			Message: fmt.Sprintf("Error fetching games: %v", err),
-			Count:   0,
-			Data:    nil,
-		}
-		json.NewEncoder(w).Encode(response)
+		writeFreeGamesError(w, withKind(ErrBadParameter, err))
 		return
 	}
+	fields, err := parseFieldMask(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeFreeGamesError(w, withKind(ErrBadParameter, err))
+		return
+	}
+	// offer_kinds, store, platform and genre limit the games to some offer
+	// kinds, stores, platforms and genres
+	var gameFilter GameFilter
+	gameFilter.OfferKinds, err = parseOfferKinds(r.URL.Query().Get("offer_kinds"))
+	if err == nil {
+		gameFilter.Stores = splitList(r.URL.Query().Get("store"))
+		gameFilter.Platforms = splitList(r.URL.Query().Get("platform"))
+		gameFilter.Genres = splitList(r.URL.Query().Get("genre"))
+		err = gameFilter.validate()
+	}
+	if err != nil {
+		writeFreeGamesError(w, withKind(ErrBadParameter, err))
+		return
+	}
+
+	// refresh=true bypasses the cache, the notifications of plain requests are
+	// sent from the snapshot the background refresher keeps fresh
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	result, hit, err := opts.cachedGames(r.Context(), opts.request(includeUpcoming, withAddons), refresh || explicitNotify)
+	if err != nil {
+		writeFreeGamesError(w, fmt.Errorf("Error fetching games: %w", err))
+		return
+	}
+	games, warnings, source := result.games, result.warnings, result.source
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	age := int(result.age().Seconds())
+	w.Header().Set("Age", strconv.Itoa(age))
+
+	opts.History.Record(games, opts.Country)
 
 	if sendNotification {
-		if webhookURL != "" {
-	
-			err := SendDiscordNotification(webhookURL, games)
-			if err != nil {
-				log.Printf("Error sending Discord notification: %v", err)
-			} else {
-				log.Printf("Discord notification sent for %d games", len(games))
+		notifyAll(r.Context(), opts.notifiers(), games)
+	}
+
+	if len(gameFilter.OfferKinds) > 0 || len(gameFilter.Stores) > 0 || len(gameFilter.Platforms) > 0 || len(gameFilter.Genres) > 0 {
+		games = gameFilter.Apply(games)
+	}
+
+	// format=iso makes the RFC3339 dates the primary ones
+	if r.URL.Query().Get("format") == "iso" {
+		for i := range games {
+			if games[i].StartDateISO != "" {
+				games[i].StartDate = games[i].StartDateISO
+			}
+			if games[i].EndDateISO != "" {
+				games[i].EndDate = games[i].EndDateISO
 			}
-		} else {
-			log.Printf("Discord webhook URL not configured")
 		}
 	}
 
+	sortGames(games, listOpts)
+	page := paginateGames(games, listOpts)
+
 	response := APIResponse{
-		Success: true,
-		Count:   len(games),
-		Data:    games,
+		Success:       true,
+		SchemaVersion: apiSchemaVersion,
+		Count:         len(page),
+		Total:         len(games),
+		Offset:        listOpts.Offset,
+		Limit:         listOpts.Limit,
+		Source:        source,
+		Stale:         result.stale(),
+		Degraded:      epicDegraded(),
+		Age:           age,
+		Data:          page,
+		fields:        fields,
+	}
+	if len(warnings) > 0 {
+		response.Message = "Epic returned partial data: " + strings.Join(warnings, "; ")
+	}
+	if response.Stale {
+		response.Message = fmt.Sprintf("Epic is unavailable, serving the games fetched at %s: %v", result.fetchedAt.UTC().Format(time.RFC3339), result.refreshErr)
 	}
 	
-	jsonData, _ := json.MarshalIndent(response, "", "  ")
-	w.Write(jsonData)
+	// format=csv/xml or the Accept header select another representation
+	format := negotiateFormat(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Add("Vary", "Accept")
+
+	var body []byte
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Disposition", `attachment; filename="free-games.csv"`)
+		body, err = encodeCSV(page, fields)
+	case formatXML:
+		body, err = encodeXML(response)
+	default:
+		body, err = json.MarshalIndent(response, "", "  ")
+	}
+	if err != nil {
+		httpError(w, fmt.Sprintf("Error encoding games: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	stable := response
+	stable.Data = withoutRelativeTimes(page)
+	stable.Age = 0
+	version, _ := json.Marshal(stable)
+	writeConditional(w, r, body, append([]byte(format+"\n"), version...))
 }
 
-func fetchFreeGames(countryCode, locale string, includeUpcoming bool, timezone string) ([]Game, error) {
-	variables := map[string]interface{}{
-		"category": "games/edition/base|bundles/games|editors",
-		"count":    100,
-		"country":  countryCode,
-		"locale":   locale,
-		"freeGame": true,
-		"onSale":   true,
+// notifyHandler serves POST /v1/notify, sending the latest free games to
+// every channel
+func notifyHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	if len(opts.notifiers()) == 0 {
+		writeError(w, errorf(ErrNotConfigured, "No notification channels configured"))
+		return
 	}
 
-	requestBody, err := json.Marshal(GraphQLRequest{
-		Query:     freeGamesQuery,
-		Variables: variables,
-	})
+	games, err := opts.latestGames(r.Context(), true)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+		writeError(w, fmt.Errorf("Error fetching games: %w", err))
+		return
 	}
+	opts.History.Record(games, opts.Country)
 
-	req, err := http.NewRequest("POST", "https://graphql.epicgames.com/graphql", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
+	if err := notifyAll(r.Context(), opts.notifiers(), games); err != nil {
+		writeError(w, fmt.Errorf("Error sending notification: %w", err))
+		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"schema_version": apiSchemaVersion,
+		"message":        fmt.Sprintf("Notification sent for %d games", len(games)),
+	})
+}
+
+// includeAddons makes every fetch also look for free DLC and add-ons, set by
+// -include-addons. The free games endpoint can override it per request.
+var includeAddons bool
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+// loadFreeGames fetches the free games from Epic and the other stores, within
+// fetchTimeout, then enriches them
+func loadFreeGames(ctx context.Context, countryCode, locale string, includeUpcoming, withAddons bool, timezone string) ([]Game, []string, string, error) {
+	fetchCtx, cancel := withStageTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	graphQLResp, source, err := fetchCatalog(fetchCtx, countryCode, locale, withAddons)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+		return nil, nil, "", withKind(ErrUpstreamUnavailable, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	var warnings []string
+	for _, graphQLErr := range graphQLResp.Errors {
+		warnings = append(warnings, graphQLErr.String())
 	}
-
-	var graphQLResp GraphQLResponse
-	if err := json.NewDecoder(resp.Body).Decode(&graphQLResp); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	if len(warnings) > 0 {
+		log.Printf("Warning: %s source returned errors with partial data: %s", source, strings.Join(warnings, "; "))
 	}
 
 	var games []Game
 	for _, element := range graphQLResp.Data.Catalog.SearchStore.Elements {
 		game := Game{
-			Title:       element.Title,
-			Description: element.Description,
-			Publisher:   element.Seller.Name,
+			ID:            gameID(element.Namespace, element.ID),
+			Store:         storeEpic,
+			Title:         element.Title,
+			Description:   element.Description,
+			Publisher:     element.Seller.Name,
+			OriginalPrice: element.Price.TotalPrice.FmtPrice.OriginalPrice,
+			DiscountPrice: element.Price.TotalPrice.FmtPrice.DiscountPrice,
+			OfferType:     element.OfferType,
+			namespace:     element.Namespace,
+			offerID:       element.ID,
+			originalPrice: float64(element.Price.TotalPrice.OriginalPrice) / math.Pow10(element.Price.TotalPrice.CurrencyInfo.Decimals),
+			currency:      element.Price.TotalPrice.CurrencyCode,
 		}
 
-		for _, img := range element.KeyImages {
-			if img.Type == "Thumbnail" || img.Type == "DieselGameBox" {
-				game.ImageURL = img.URL
-				break
+		for _, tag := range element.Tags {
+			name, group := tag.Name, tag.GroupName
+			if name == "" && tag.ID != "" {
+				// The promotions endpoint only returns tag IDs
+				known := lookupEpicTags(fetchCtx, locale)[tag.ID]
+				name, group = known.Name, known.GroupName
+			}
+			switch {
+			case name == "":
+			case group == "genre":
+				game.Genres = append(game.Genres, name)
+			default:
+				game.Tags = append(game.Tags, name)
 			}
 		}
-
-		pageSlug := ""
-		if len(element.OfferMappings) > 0 {
-			for _, mapping := range element.OfferMappings {
-				if mapping.PageSlug != "" {
-					pageSlug = mapping.PageSlug
-					break
-				}
+		for _, category := range element.Categories {
+			if (strings.HasPrefix(category.Path, "addons") || strings.HasPrefix(category.Path, "digitalextras")) && game.OfferType == "" {
+				game.OfferType = "ADD_ON"
+			}
+			if strings.HasPrefix(category.Path, "bundles") && game.OfferType == "" {
+				game.OfferType = "BUNDLE"
 			}
 		}
-		
-		if pageSlug == "" && len(element.CatalogNs.Mappings) > 0 {
-			for _, mapping := range element.CatalogNs.Mappings {
-				if mapping.PageSlug != "" {
-					pageSlug = mapping.PageSlug
-					break
+
+		for _, img := range element.KeyImages {
+			game.keyImages = append(game.keyImages, KeyImage{Type: img.Type, URL: img.URL})
+		}
+		for _, offers := range []struct {
+			upcoming bool
+			list     []PromotionalOfferGroup
+		}{
+			{false, element.Promotions.PromotionalOffers},
+			{true, element.Promotions.UpcomingPromotionalOffers},
+		} {
+			for _, offer := range offers.list {
+				for _, promo := range offer.PromotionalOffers {
+					game.promotions = append(game.promotions, PromoWindow{
+						StartDate:          promo.StartDate,
+						EndDate:            promo.EndDate,
+						Upcoming:           offers.upcoming,
+						DiscountType:       promo.DiscountSetting.DiscountType,
+						DiscountPercentage: promo.DiscountSetting.DiscountPercentage,
+					})
 				}
 			}
 		}
 
-		game.URL = fmt.Sprintf("https://store.epicgames.com/en-US/p/%s", pageSlug)
+		game.ImageURL = element.ImageURL()
+		game.URL = element.StoreURL()
 
 		isCurrentlyFree := false
 		hasUpcomingFree := false
@@ -583,6 +1325,8 @@ func fetchFreeGames(countryCode, locale string, includeUpcoming bool, timezone s
 							game.StartDate = formatDate(promo.StartDate)
 							game.EndDate = formatDate(promo.EndDate)
 							game.DatePrecision = "exact"
+							game.startTime = parseEpicDate(promo.StartDate)
+							game.endTime = parseEpicDate(promo.EndDate)
 						}
 					}
 				}
@@ -599,15 +1343,31 @@ func fetchFreeGames(countryCode, locale string, includeUpcoming bool, timezone s
 							game.StartDate = formatDate(promo.StartDate)
 							game.EndDate = formatDate(promo.EndDate)
 							game.DatePrecision = "exact"
+							game.startTime = parseEpicDate(promo.StartDate)
+							game.endTime = parseEpicDate(promo.EndDate)
 						}
 					}
 				}
 			}
 		}
 
+		offerTexts := []string{element.Title, element.Description}
+		for _, attribute := range element.LinkedOffer.CustomAttributes {
+			offerTexts = append(offerTexts, attribute.Value)
+		}
+		for _, attribute := range element.CustomAttributes {
+			offerTexts = append(offerTexts, attribute.Value)
+		}
+		game.OfferKind = classifyOffer(isCurrentlyFree || hasUpcomingFree, game.OriginalPrice, offerTexts...)
+		if isCurrentlyFree || hasUpcomingFree {
+			archiveRawOffer(element.ID, graphQLResp.rawElements[game.ID])
+		}
+
 		if !isCurrentlyFree && !hasUpcomingFree {
-			price := element.Price.TotalPrice.FmtPrice.DiscountPrice
-			if price == "$0.00" || price == "0" || price == "" || strings.Contains(strings.ToLower(price), "free") {
+			if isFreePrice(element.Price.TotalPrice.FmtPrice.DiscountPrice) && game.OfferKind == offerKindAlwaysFree {
+				// Free-to-play games have no promotion window to estimate
+				game.Status = "free"
+			} else if isFreePrice(element.Price.TotalPrice.FmtPrice.DiscountPrice) {
 				game.Status = "free"
 				
 				location, err := time.LoadLocation(timezone)
@@ -623,13 +1383,15 @@ func fetchFreeGames(countryCode, locale string, includeUpcoming bool, timezone s
 				game.StartDate = now.Format("2006-01-02 15:04:05 MST")
 				game.EndDate = endDate.Format("2006-01-02 15:04:05 MST")
 				game.DatePrecision = "estimated"
+				game.startTime = now
+				game.endTime = endDate
 			} else {
 				// Skip non-free games
 				continue
 			}
 		}
 
-		if !includeUpcoming && game.Status == "coming soon" {
+		if !includeUpcoming && isUpcoming(game) {
 			continue
 		}
 		
@@ -644,6 +1406,8 @@ func fetchFreeGames(countryCode, locale string, includeUpcoming bool, timezone s
 							game.StartDate = formatDate(promo.StartDate)
 							game.EndDate = formatDate(promo.EndDate)
 							game.DatePrecision = "exact"
+							game.startTime = parseEpicDate(promo.StartDate)
+							game.endTime = parseEpicDate(promo.EndDate)
 							break
 						}
 					}
@@ -661,48 +1425,140 @@ func fetchFreeGames(countryCode, locale string, includeUpcoming bool, timezone s
 			game.DatePrecision = "unknown"
 		}
 
+		if !game.startTime.IsZero() {
+			game.StartDateISO = game.startTime.Format(time.RFC3339)
+		}
+		if !game.endTime.IsZero() {
+			game.EndDateISO = game.endTime.Format(time.RFC3339)
+		}
+
+		// Relative times, only meaningful when the promotion window is known
+		if game.DatePrecision == "exact" {
+			now := time.Now()
+			if game.startTime.After(now) {
+				game.StartsIn = humanizeDuration(game.startTime.Sub(now))
+			}
+			if game.endTime.After(now) {
+				game.EndsIn = humanizeDuration(game.endTime.Sub(now))
+			}
+		}
+
 		games = append(games, game)
 	}
 
-	return games, nil
+	mysteries.Observe(games)
+	verifyRegionalAvailability(fetchCtx, games, countryCode, locale, withAddons)
+	resolveBundles(fetchCtx, games, locale)
+
+	if includeEpicMobile {
+		mobileGames, err := fetchEpicMobileGames(fetchCtx, countryCode, locale, includeUpcoming, timezone)
+		if err != nil {
+			log.Printf("Warning: Error fetching Epic mobile games: %v", err)
+		}
+		games = append(games, mobileGames...)
+	}
+	games = append(games, fetchOtherStores(fetchCtx, countryCode, locale, includeUpcoming, timezone)...)
+	// Only the mobile and console games have platforms set, the others are PC games
+	for i := range games {
+		if len(games[i].Platforms) == 0 {
+			games[i].Platforms = []string{"PC"}
+		}
+	}
+	enrichGames(ctx, games)
+	return games, warnings, source, nil
 }
 
-func setupCronJob(schedule, countryCode, locale, timezone, webhookURL string) {
-	if webhookURL == "" {
-		log.Println("Warning: Discord webhook URL not configured. Cron job will run but no notifications will be sent.")
+// humanizeDuration formats a duration with its two largest units, e.g.
+// "2 days 5 hours" or "45 minutes"
+func humanizeDuration(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
 	}
 
-	c := cron.New(cron.WithSeconds())
-	
-	log.Printf("Setting up cron job with schedule: %s", schedule)
-	
-	_, err := c.AddFunc(schedule, func() {
-		log.Println("Running scheduled free games check...")
-		
-		games, err := fetchFreeGames(countryCode, locale, true, timezone)
-		if err != nil {
-			log.Printf("Error fetching free games: %v", err)
-			return
+	var parts []string
+	for _, unit := range units {
+		if len(parts) == 2 {
+			break
 		}
-			
-		log.Printf("Found %d free game(s)", len(games))
-		
-		// Send notification to Discord if webhook URL is configured
-		if webhookURL != "" {
-			err = SendDiscordNotification(webhookURL, games)
-			if err != nil {
-				log.Printf("Error sending Discord notification: %v", err)
-			} else {
-					log.Printf("Discord notification sent for %d games", len(games))
+		n := int(d / unit.size)
+		d -= time.Duration(n) * unit.size
+		if n == 0 {
+			if len(parts) > 0 {
+				break // Don't skip to a smaller unit, "2 days 3 minutes" reads oddly
 			}
+			continue
+		}
+		if n == 1 {
+			parts = append(parts, "1 "+unit.name)
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit.name))
 		}
+	}
+	if len(parts) == 0 {
+		return "less than a minute"
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseEpicDate parses an RFC3339 date from Epic's API, returning the zero time
+// if it can't be parsed
+func parseEpicDate(dateStr string) time.Time {
+	t, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// isFreePrice reports whether a formatted price from Epic's API means free
+func isFreePrice(price string) bool {
+	return price == "$0.00" || price == "0" || price == "" || strings.Contains(strings.ToLower(price), "free")
+}
+
+func setupCronJob(c *cron.Cron, schedule string, jitter time.Duration, notifiers []Notifier, check func() ([]Game, error)) {
+	if len(notifiers) == 0 {
+		log.Println("Warning: No notification channels configured. Cron job will run but no notifications will be sent.")
+	}
+
+	log.Printf("Setting up cron job with schedule: %s", schedule)
+	
+	_, err := c.AddFunc(schedule, func() {
+		sleepJitter(jitter)
+		check()
 	})
 	
 	if err != nil {
 		log.Printf("Error setting up cron job: %v", err)
-		return
 	}
-	
-	c.Start()
-	log.Println("Cron scheduler started")
+}
+
+// runScheduledCheck fetches the free games, archives them and notifies every
+// channel, returning the games fetched
+func runScheduledCheck(opts ServerOptions, goLive *GoLiveWatcher) ([]Game, error) {
+	log.Println("Running scheduled free games check...")
+	startedAt := time.Now()
+
+	games, err := opts.latestGames(context.Background(), true)
+	if err != nil {
+		log.Printf("Error fetching free games: %v", err)
+		recordRun(startedAt, nil, err)
+		return nil, err
+	}
+
+	log.Printf("Found %d free game(s)", len(games))
+	opts.History.Record(games, opts.Country)
+
+	// Send notification to every configured channel
+	recordRun(startedAt, games, notifyAll(context.Background(), opts.notifiers(), games))
+
+	// Pick up newly announced upcoming games
+	if goLive != nil {
+		goLive.Watch(games)
+	}
+	return games, nil
 }
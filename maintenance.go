@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// RetentionPolicy says how long stored state is kept. Zero durations keep
+// everything.
+type RetentionPolicy struct {
+	Deliveries          time.Duration // Notification attempts are deleted after this
+	HistoryCompactAfter time.Duration // Ended giveaways are compacted after this, never deleted
+	RawOffers           time.Duration // Archived catalog payloads are deleted this long after their last fetch
+}
+
+// runMaintenance applies the retention policy and reclaims the space freed
+func runMaintenance(history *HistoryStore, policy RetentionPolicy) {
+	if policy.Deliveries > 0 {
+		deleted, err := deliveryLog.DeleteDeliveries(time.Now().Add(-policy.Deliveries))
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d notification attempts older than %s", deleted, humanizeDuration(policy.Deliveries))
+		}
+	}
+
+	if policy.HistoryCompactAfter > 0 {
+		if compacted := history.Compact(time.Now().Add(-policy.HistoryCompactAfter)); compacted > 0 {
+			log.Printf("Compacted %d history entries", compacted)
+		}
+	}
+
+	if policy.RawOffers > 0 && rawArchive != nil {
+		deleted, err := rawArchive.DeleteRawOffers(time.Now().Add(-policy.RawOffers))
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d raw payloads older than %s", deleted, humanizeDuration(policy.RawOffers))
+		}
+	}
+
+	if stateDB != nil {
+		if err := stateDB.Compact(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// setupMaintenanceJob schedules runMaintenance on the cron scheduler
+func setupMaintenanceJob(c *cron.Cron, schedule string, history *HistoryStore, policy RetentionPolicy) {
+	log.Printf("Setting up maintenance job with schedule: %s", schedule)
+	if _, err := c.AddFunc(schedule, func() {
+		log.Println("Running scheduled maintenance...")
+		runMaintenance(history, policy)
+	}); err != nil {
+		log.Printf("Error setting up maintenance job: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// MastodonNotifier posts a status ("toot") for every newly detected free game
+type MastodonNotifier struct {
+	InstanceURL string
+	AccessToken string
+	Visibility  string // "public", "unlisted", "private" or "direct"
+	tracker     *SeenTracker
+	client      *http.Client
+}
+
+// mastodonMedia is the subset of the Mastodon media attachment we need
+type mastodonMedia struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// NewMastodonNotifier creates a Mastodon notifier for the given instance.
+// Games already posted are remembered by tracker.
+func NewMastodonNotifier(instanceURL, accessToken, visibility string, tracker *SeenTracker) *MastodonNotifier {
+	if visibility == "" {
+		visibility = "public"
+	}
+	return &MastodonNotifier{
+		InstanceURL: strings.TrimRight(instanceURL, "/"),
+		AccessToken: accessToken,
+		Visibility:  visibility,
+		tracker:     tracker,
+		client:      newHTTPClient(30 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (m *MastodonNotifier) Name() string {
+	return "mastodon"
+}
+
+// pendingGames returns the games not announced yet
+func (m *MastodonNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return m.tracker.Unseen(games), m.tracker
+}
+
+// Notify posts a status for each game that hasn't been posted before
+func (m *MastodonNotifier) Notify(ctx context.Context, games []Game) error {
+	for _, game := range m.tracker.Unseen(games) {
+		status, err := messageTemplates.RenderGame(m.Name(), game)
+		if err != nil {
+			return err
+		}
+
+		var mediaIDs []string
+		if game.ImageURL != "" {
+			mediaID, err := m.uploadMedia(ctx, game)
+			if err != nil {
+				// The toot is still useful without the cover image
+				log.Printf("Warning: Error uploading Mastodon media for %s: %v", game.Title, err)
+			} else {
+				mediaIDs = append(mediaIDs, mediaID)
+			}
+		}
+
+		if err := m.postStatus(ctx, game, status, mediaIDs); err != nil {
+			return fmt.Errorf("error posting %s: %v", game.Title, err)
+		}
+
+		if err := m.tracker.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+func (m *MastodonNotifier) postStatus(ctx context.Context, game Game, status string, mediaIDs []string) error {
+	form := url.Values{}
+	form.Set("status", status)
+	form.Set("visibility", m.Visibility)
+	for _, id := range mediaIDs {
+		form.Add("media_ids[]", id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", m.InstanceURL+"/api/v1/statuses", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating status request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+	// Protects against double posting if a request is retried
+	req.Header.Set("Idempotency-Key", gameKey(game))
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending status request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Mastodon returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// uploadMedia downloads the game's cover image and uploads it to the instance,
+// returning the media attachment ID
+func (m *MastodonNotifier) uploadMedia(ctx context.Context, game Game) (string, error) {
+	imgResp, err := httpGet(ctx, m.client, game.ImageURL)
+	if err != nil {
+		return "", fmt.Errorf("error downloading image: %v", err)
+	}
+	defer imgResp.Body.Close()
+
+	if imgResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading image: status %d", imgResp.StatusCode)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	filename := path.Base(imgResp.Request.URL.Path)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("error creating multipart body: %v", err)
+	}
+	if _, err := io.Copy(part, imgResp.Body); err != nil {
+		return "", fmt.Errorf("error reading image: %v", err)
+	}
+	writer.WriteField("description", game.Title)
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("error creating multipart body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", m.InstanceURL+"/api/v2/media", &body)
+	if err != nil {
+		return "", fmt.Errorf("error creating media request: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending media request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Mastodon returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var media mastodonMedia
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return "", fmt.Errorf("error decoding media response: %v", err)
+	}
+
+	// 202 means the instance is still processing the file; statuses can't
+	// reference it until the URL is available
+	if resp.StatusCode == http.StatusAccepted {
+		if err := m.waitForMedia(ctx, media.ID); err != nil {
+			return "", err
+		}
+	}
+
+	return media.ID, nil
+}
+
+// waitForMedia polls the instance until an uploaded attachment is processed
+func (m *MastodonNotifier) waitForMedia(ctx context.Context, id string) error {
+	for attempt := 0; attempt < 10; attempt++ {
+		time.Sleep(time.Second)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", m.InstanceURL+"/api/v1/media/"+url.PathEscape(id), nil)
+		if err != nil {
+			return fmt.Errorf("error creating media status request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error checking media status: %v", err)
+		}
+		var media mastodonMedia
+		decodeErr := json.NewDecoder(resp.Body).Decode(&media)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK && decodeErr == nil && media.URL != "" {
+			return nil
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			return fmt.Errorf("Mastodon returned status %d while processing media", resp.StatusCode)
+		}
+	}
+	return fmt.Errorf("timed out waiting for media %s to be processed", id)
+}
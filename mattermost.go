@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ChatAttachment is a Slack-style message attachment, understood by both
+// Mattermost and Rocket.Chat incoming webhooks
+type ChatAttachment struct {
+	Fallback  string                `json:"fallback,omitempty"`
+	Color     string                `json:"color,omitempty"`
+	Title     string                `json:"title"`
+	TitleLink string                `json:"title_link,omitempty"`
+	Text      string                `json:"text,omitempty"`
+	ImageURL  string                `json:"image_url,omitempty"`
+	Fields    []ChatAttachmentField `json:"fields,omitempty"`
+	Footer    string                `json:"footer,omitempty"`
+}
+
+// ChatAttachmentField is a field in a ChatAttachment
+type ChatAttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// ChatWebhookMessage is the payload of a Mattermost or Rocket.Chat incoming webhook
+type ChatWebhookMessage struct {
+	Text        string           `json:"text"`
+	Channel     string           `json:"channel,omitempty"`
+	Username    string           `json:"username,omitempty"`
+	IconURL     string           `json:"icon_url,omitempty"`
+	Attachments []ChatAttachment `json:"attachments"`
+}
+
+// ChatWebhookNotifier posts attachment-formatted messages to a Mattermost or
+// Rocket.Chat incoming webhook
+type ChatWebhookNotifier struct {
+	Platform   string // "mattermost" or "rocketchat"
+	WebhookURL string
+	Channel    string // Optional channel override
+	tracker    *SeenTracker
+	client     *http.Client
+}
+
+// NewMattermostNotifier creates a notifier for a Mattermost incoming webhook.
+// Games already posted are remembered by tracker.
+func NewMattermostNotifier(webhookURL, channel string, tracker *SeenTracker) *ChatWebhookNotifier {
+	return &ChatWebhookNotifier{
+		Platform:   "mattermost",
+		WebhookURL: webhookURL,
+		Channel:    channel,
+		tracker:    tracker,
+		client:     newHTTPClient(10 * time.Second),
+	}
+}
+
+// NewRocketChatNotifier creates a notifier for a Rocket.Chat incoming
+// webhook. Games already posted are remembered by tracker.
+func NewRocketChatNotifier(webhookURL, channel string, tracker *SeenTracker) *ChatWebhookNotifier {
+	return &ChatWebhookNotifier{
+		Platform:   "rocketchat",
+		WebhookURL: webhookURL,
+		Channel:    channel,
+		tracker:    tracker,
+		client:     newHTTPClient(10 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (c *ChatWebhookNotifier) Name() string {
+	return c.Platform
+}
+
+// pendingGames returns the games not announced yet
+func (c *ChatWebhookNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return c.tracker.Unseen(games), c.tracker
+}
+
+// Notify posts one message with an attachment per game not announced yet
+func (c *ChatWebhookNotifier) Notify(ctx context.Context, games []Game) error {
+	return notifyUnseen(c.tracker, games, 0, func(games []Game) error {
+		return c.post(ctx, games)
+	})
+}
+
+// post sends one message with an attachment per game
+func (c *ChatWebhookNotifier) post(ctx context.Context, games []Game) error {
+	message := ChatWebhookMessage{
+		Text:     "🎮 Free Games from " + storesName(games) + " 🎮",
+		Channel:  c.Channel,
+		Username: "Epic Games Free Games",
+	}
+	for _, game := range games {
+		message.Attachments = append(message.Attachments, createGameAttachment(game))
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling %s message: %v", c.Platform, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.WebhookURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("error creating %s request: %v", c.Platform, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending %s request: %v", c.Platform, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s webhook returned status %d: %s", c.Platform, resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// createGameAttachment creates an attachment for a game, colored by status
+// like the Discord embeds
+func createGameAttachment(game Game) ChatAttachment {
+	color := "#0078F2"
+	statusText := "Currently Free"
+	if game.Status == "free" {
+		color = "#2ECC71"
+	} else if isUpcoming(game) {
+		color = "#F1C40F"
+		statusText = "Coming Soon"
+	}
+
+	attachment := ChatAttachment{
+		Fallback:  fmt.Sprintf("%s (%s) %s", game.Title, statusText, game.URL),
+		Color:     color,
+		Title:     game.Title,
+		TitleLink: game.URL,
+		Text:      game.Description,
+		ImageURL:  game.ImageURL,
+	}
+
+	if game.Publisher != "" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Publisher",
+			Value: game.Publisher,
+			Short: true,
+		})
+	}
+	attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+		Title: "Status",
+		Value: statusText,
+		Short: true,
+	})
+	if len(game.BundleContents) > 0 {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Includes",
+			Value: strings.Join(game.BundleContents, ", "),
+			Short: false,
+		})
+	}
+	if score := criticScoreText(game); score != "" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Score",
+			Value: score,
+			Short: true,
+		})
+	}
+	if playtime := playtimeText(game); playtime != "" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Length",
+			Value: playtime,
+			Short: true,
+		})
+	}
+	if game.regionWarning != "" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Region",
+			Value: "⚠️ " + game.regionWarning,
+			Short: true,
+		})
+	}
+	if game.TrailerURL != "" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Trailer",
+			Value: fmt.Sprintf("[Watch Trailer](%s)", game.TrailerURL),
+			Short: true,
+		})
+	}
+	if game.StartDate != "Unknown" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Available From",
+			Value: game.StartDate,
+			Short: true,
+		})
+	}
+	if game.EndDate != "Unknown" {
+		attachment.Fields = append(attachment.Fields, ChatAttachmentField{
+			Title: "Available Until",
+			Value: game.EndDate,
+			Short: true,
+		})
+	}
+
+	switch game.DatePrecision {
+	case "exact":
+		attachment.Footer = "Dates are exact"
+	case "estimated":
+		attachment.Footer = "Dates are estimated"
+	case "unknown":
+		attachment.Footer = "Dates are unknown"
+	}
+
+	return attachment
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// epicMobileDiscoverURL is the discover API of the Epic Games Store mobile app
+const epicMobileDiscoverURL = "https://egs-platform-service.store.epicgames.com/api/v2/public/discover/home"
+
+// epicMobilePlatforms maps the platforms of the mobile store to the names
+// used in Game.Platforms
+var epicMobilePlatforms = map[string]string{
+	"android": "Android",
+	"ios":     "iOS",
+}
+
+// includeEpicMobile adds the weekly free games of the Epic mobile store to
+// every fetch, set by -epic-mobile
+var includeEpicMobile bool
+
+// epicMobileDiscover is the part of the discover response the service uses
+type epicMobileDiscover struct {
+	Data []struct {
+		Offers []struct {
+			OfferID   string `json:"offerId"`
+			SandboxID string `json:"sandboxId"`
+			Content   struct {
+				Title   string `json:"title"`
+				Mapping struct {
+					Slug string `json:"slug"`
+				} `json:"mapping"`
+				Media struct {
+					Card16x9 struct {
+						ImageSrc string `json:"imageSrc"`
+					} `json:"card16x9"`
+				} `json:"media"`
+				Purchase []struct {
+					PurchaseStateEffectiveDate string `json:"purchaseStateEffectiveDate"`
+					Price                      struct {
+						DecimalPrice float64 `json:"decimalPrice"`
+					} `json:"price"`
+					Discount struct {
+						DiscountAmountDisplay string `json:"discountAmountDisplay"`
+						DiscountEndDate       string `json:"discountEndDate"`
+					} `json:"discount"`
+				} `json:"purchase"`
+			} `json:"content"`
+		} `json:"offers"`
+	} `json:"data"`
+}
+
+// fetchEpicMobileGames returns the free games of the Epic mobile store on
+// Android and iOS. A game free on both is returned once with both platforms.
+func fetchEpicMobileGames(ctx context.Context, countryCode, locale string, includeUpcoming bool, timezone string) ([]Game, error) {
+	language, _, _ := strings.Cut(locale, "-")
+	now := time.Now()
+
+	var games []Game
+	byID := map[string]int{}
+	for _, platform := range []string{"android", "ios"} {
+		query := url.Values{
+			"count":    {"10"},
+			"country":  {strings.ToUpper(countryCode)},
+			"locale":   {language},
+			"platform": {platform},
+			"start":    {"0"},
+			"store":    {"EGS"},
+		}
+		var discover epicMobileDiscover
+		if err := getJSON(ctx, epicMobileDiscoverURL+"?"+query.Encode(), &discover); err != nil {
+			return nil, err
+		}
+
+		for _, module := range discover.Data {
+			for _, offer := range module.Offers {
+				for _, purchase := range offer.Content.Purchase {
+					if purchase.Price.DecimalPrice != 0 || purchase.Discount.DiscountAmountDisplay != "-100%" {
+						continue
+					}
+
+					id := gameID(offer.SandboxID, offer.OfferID)
+					if i, ok := byID[id]; ok {
+						games[i].Platforms = append(games[i].Platforms, epicMobilePlatforms[platform])
+						break
+					}
+
+					start, end := parseEpicDate(purchase.PurchaseStateEffectiveDate), parseEpicDate(purchase.Discount.DiscountEndDate)
+					game := Game{
+						ID:        id,
+						Title:     offer.Content.Title,
+						ImageURL:  offer.Content.Media.Card16x9.ImageSrc,
+						URL:       "https://store.epicgames.com/" + locale + "/p/" + offer.Content.Mapping.Slug,
+						Status:    "free",
+						OfferType: "BASE_GAME",
+						OfferKind: offerKindGiveaway,
+						Store:     storeEpic,
+						Platforms: []string{epicMobilePlatforms[platform]},
+						namespace: offer.SandboxID,
+						offerID:   offer.OfferID,
+					}
+					if start.After(now) {
+						if !includeUpcoming {
+							break
+						}
+						game.Status = "coming soon"
+					}
+					setPromotionWindow(&game, start, end, timezone)
+					byID[id] = len(games)
+					games = append(games, game)
+					break
+				}
+			}
+		}
+	}
+	return games, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// mysteryTitlePattern matches the placeholders Epic lists during holiday
+// events, e.g. "Mystery Game", "Mystery Game 07" or "Mystery Gift"
+var mysteryTitlePattern = regexp.MustCompile(`(?i)^\s*mystery\s+(game|gift)\b`)
+
+// isMysteryGame reports whether the offer is a placeholder for a game that
+// hasn't been revealed yet
+func isMysteryGame(game Game) bool {
+	return mysteryTitlePattern.MatchString(game.Title) || strings.Contains(strings.ToLower(game.URL), "/mystery-game")
+}
+
+// isUpcoming reports whether the game isn't free yet, announced or as a mystery
+func isUpcoming(game Game) bool {
+	return game.Status == "coming soon" || game.Status == "mystery"
+}
+
+// mysteryEntry is a mystery placeholder seen by the tracker
+type mysteryEntry struct {
+	Placeholder string `json:"placeholder"`        // Title of the placeholder
+	Revealed    string `json:"revealed,omitempty"` // Title of the revealed game
+	Announced   bool   `json:"announced,omitempty"`
+}
+
+// MysteryTracker remembers the offers listed as mystery placeholders, so the
+// game revealed under the same offer ID can be announced as a reveal. When a
+// path is set the state is persisted as JSON so it survives restarts.
+type MysteryTracker struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*mysteryEntry // By game ID
+}
+
+// mysteries tracks the placeholders of every fetch, replaced in main when
+// -mystery-state-file is set
+var mysteries = NewMysteryTracker("")
+
+// NewMysteryTracker creates a tracker, loading its state from path if it
+// exists. An empty path keeps the state in memory only.
+func NewMysteryTracker(path string) *MysteryTracker {
+	m := &MysteryTracker{
+		path:    path,
+		entries: make(map[string]*mysteryEntry),
+	}
+
+	if path == "" {
+		return m
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Error reading mystery games file %s: %v", path, err)
+		}
+		return m
+	}
+	if err := json.Unmarshal(data, &m.entries); err != nil {
+		log.Printf("Warning: Error parsing mystery games file %s: %v", path, err)
+	}
+	return m
+}
+
+// Observe marks upcoming placeholders with the "mystery" status and sets
+// RevealedFrom on the games revealed under a placeholder's offer ID
+func (m *MysteryTracker) Observe(games []Game) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := false
+	for i := range games {
+		game := &games[i]
+		if game.ID == "" {
+			continue
+		}
+
+		if isMysteryGame(*game) {
+			if game.Status == "coming soon" {
+				game.Status = "mystery"
+			}
+			if _, ok := m.entries[game.ID]; !ok {
+				m.entries[game.ID] = &mysteryEntry{Placeholder: game.Title}
+				changed = true
+			}
+			continue
+		}
+
+		if entry, ok := m.entries[game.ID]; ok {
+			game.RevealedFrom = entry.Placeholder
+			if entry.Revealed != game.Title {
+				log.Printf("%s revealed as %s", entry.Placeholder, game.Title)
+				entry.Revealed = game.Title
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		if err := m.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// announceReveals titles the games revealed since the last notification run
+// as a reveal, and marks them as announced
+func (m *MysteryTracker) announceReveals(games []Game) []Game {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	announced := make([]Game, len(games))
+	changed := false
+	for i, game := range games {
+		if entry, ok := m.entries[game.ID]; ok && game.ID != "" && entry.Revealed != "" && !entry.Announced {
+			game.Title = fmt.Sprintf("Mystery game revealed: %s", game.Title)
+			entry.Announced = true
+			changed = true
+		}
+		announced[i] = game
+	}
+
+	if changed {
+		if err := m.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return announced
+}
+
+// save persists the state, the caller must hold the lock
+func (m *MysteryTracker) save() error {
+	if m.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling mystery games: %v", err)
+	}
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing mystery games file: %v", err)
+	}
+	return nil
+}
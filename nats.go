@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// GameEvent is the message published to NATS for game lifecycle events
+type GameEvent struct {
+	Type      string    `json:"type"` // "game.found" or "game.expiring"
+	Game      Game      `json:"game"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NATSNotifier emits game-found and game-expiring events onto NATS subjects,
+// optionally persisting them in a JetStream stream
+type NATSNotifier struct {
+	SubjectPrefix  string
+	StreamName     string
+	ExpiringWithin time.Duration
+
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	streamMu sync.Mutex
+	streamOK bool
+	found    *SeenTracker
+	expiring *SeenTracker
+}
+
+// NewNATSNotifier connects to the NATS server at url. When streamName is set,
+// events are published through JetStream into that stream.
+func NewNATSNotifier(url, subjectPrefix, streamName string, expiringWithin time.Duration, found, expiring *SeenTracker) (*NATSNotifier, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("epic-games-api"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS: %v", err)
+	}
+
+	n := &NATSNotifier{
+		SubjectPrefix:  subjectPrefix,
+		StreamName:     streamName,
+		ExpiringWithin: expiringWithin,
+		conn:           conn,
+		found:          found,
+		expiring:       expiring,
+	}
+
+	if streamName != "" {
+		js, err := jetstream.New(conn)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error creating JetStream context: %v", err)
+		}
+		n.js = js
+	}
+
+	return n, nil
+}
+
+// Name returns the channel identifier
+func (n *NATSNotifier) Name() string {
+	return "nats"
+}
+
+// pendingGames returns the games without a game.found event yet
+func (n *NATSNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return n.found.Unseen(games), n.found
+}
+
+// Notify publishes a game.found event for every game not announced before and
+// a game.expiring event for free games ending within ExpiringWithin
+func (n *NATSNotifier) Notify(ctx context.Context, games []Game) error {
+	for _, game := range n.found.Unseen(games) {
+		if err := n.publish(ctx, "game.found", game); err != nil {
+			return err
+		}
+		if err := n.found.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	now := time.Now()
+	var expiring []Game
+	for _, game := range games {
+		if game.Status == "free" && !game.endTime.IsZero() && game.endTime.After(now) &&
+			game.endTime.Sub(now) <= n.ExpiringWithin {
+			expiring = append(expiring, game)
+		}
+	}
+	for _, game := range n.expiring.Unseen(expiring) {
+		if err := n.publish(ctx, "game.expiring", game); err != nil {
+			return err
+		}
+		if err := n.expiring.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// publish sends a single event to <prefix>.<eventType>
+func (n *NATSNotifier) publish(ctx context.Context, eventType string, game Game) error {
+	data, err := json.Marshal(GameEvent{
+		Type:      eventType,
+		Game:      game,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling %s event: %v", eventType, err)
+	}
+
+	subject := n.SubjectPrefix + "." + eventType
+	// FlushWithContext needs a deadline
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if n.js == nil {
+		if err := n.conn.Publish(subject, data); err != nil {
+			return fmt.Errorf("error publishing to %s: %v", subject, err)
+		}
+		return n.conn.FlushWithContext(ctx)
+	}
+
+	if err := n.ensureStream(ctx); err != nil {
+		return err
+	}
+
+	// The message ID lets JetStream drop duplicates within its dedup window
+	_, err = n.js.Publish(ctx, subject, data, jetstream.WithMsgID(eventType+"|"+gameKey(game)))
+	if err != nil {
+		return fmt.Errorf("error publishing to %s: %v", subject, err)
+	}
+	return nil
+}
+
+// ensureStream creates the JetStream stream on first use so the service can
+// start before the NATS server is reachable
+func (n *NATSNotifier) ensureStream(ctx context.Context) error {
+	n.streamMu.Lock()
+	defer n.streamMu.Unlock()
+
+	if n.streamOK {
+		return nil
+	}
+
+	_, err := n.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     n.StreamName,
+		Subjects: []string{n.SubjectPrefix + ".>"},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating JetStream stream %s: %v", n.StreamName, err)
+	}
+	n.streamOK = true
+	return nil
+}
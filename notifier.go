@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notifier is implemented by every notification channel (Discord, Mastodon, ...)
+type Notifier interface {
+	// Name returns a short identifier for the channel, used in logs
+	Name() string
+	// Notify announces the given games on the channel, giving up when ctx
+	// ends
+	Notify(ctx context.Context, games []Game) error
+}
+
+// notifyTimeout bounds a notification on each channel, set by
+// -notify-timeout
+var notifyTimeout = time.Minute
+
+// DiscordNotifier sends notifications to a Discord webhook, skipping games
+// that were already announced
+type DiscordNotifier struct {
+	WebhookURL string
+	Options    DiscordOptions
+	tracker    *SeenTracker
+	message    *discordMessageState // Set in edit-in-place mode
+}
+
+// NewDiscordNotifier creates a Discord notifier. Games already sent are
+// remembered by tracker.
+func NewDiscordNotifier(webhookURL string, options DiscordOptions, tracker *SeenTracker) *DiscordNotifier {
+	d := &DiscordNotifier{
+		WebhookURL: webhookURL,
+		Options:    options,
+		tracker:    tracker,
+	}
+	if options.EditInPlace {
+		d.message = loadDiscordMessageState(options.MessageStateFile)
+	}
+	return d
+}
+
+// Name returns the channel identifier
+func (d *DiscordNotifier) Name() string {
+	return "discord"
+}
+
+// pendingGames returns the games not announced yet. In edit-in-place mode
+// that's every game when the lineup changed, and none otherwise.
+func (d *DiscordNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	if d.message != nil {
+		if d.message.current(games) {
+			return nil, nil
+		}
+		return games, nil
+	}
+	return d.tracker.Unseen(games), d.tracker
+}
+
+// Notify sends the games that haven't been announced yet to the Discord
+// webhook, or updates the single lineup message in edit-in-place mode
+func (d *DiscordNotifier) Notify(ctx context.Context, games []Game) error {
+	if d.message != nil {
+		return UpdateDiscordMessage(ctx, d.WebhookURL, games, d.Options, d.message)
+	}
+
+	result, err := SendDiscordNotification(ctx, d.WebhookURL, d.tracker.Unseen(games), d.Options)
+
+	// Games that weren't delivered are left for the next run
+	if markErr := d.tracker.MarkSeen(result.Delivered...); markErr != nil {
+		log.Printf("Warning: %v", markErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%d of %d games delivered: %v", len(result.Delivered), len(result.Delivered)+len(result.Failed), err)
+	}
+	return nil
+}
+
+// notifyUnseen sends the games tracker hasn't seen in a single message with
+// send, at most limit of them if limit isn't 0, and marks them seen once
+// sent. The games left out are sent next time.
+func notifyUnseen(tracker *SeenTracker, games []Game, limit int, send func([]Game) error) error {
+	unseen := tracker.Unseen(games)
+	if len(unseen) == 0 {
+		return nil
+	}
+	if limit > 0 && len(unseen) > limit {
+		unseen = unseen[:limit]
+	}
+	if err := send(unseen); err != nil {
+		return err
+	}
+	if err := tracker.MarkSeen(unseen...); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return nil
+}
+
+// channelNotifier is a notifier with an ID of its own, for the channels
+// whose name can be shared, like two tgram:// URLs or a discord:// URL next
+// to DISCORD_WEBHOOK_URL
+type channelNotifier struct {
+	Notifier
+	id string
+}
+
+// channelID returns the unique ID deliveries, NOTIFY_FILTERS and circuit
+// breakers know a notifier by: its name unless it was given another
+func channelID(n Notifier) string {
+	switch n := n.(type) {
+	case *channelNotifier:
+		return n.id
+	case *namedNotifier:
+		return n.name
+	case *FilteredNotifier:
+		return channelID(n.Notifier)
+	case *CircuitNotifier:
+		return channelID(n.Notifier)
+	}
+	return n.Name()
+}
+
+// pendingNotifier is implemented by the notifiers that skip the games they
+// already announced
+type pendingNotifier interface {
+	// pendingGames returns the games Notify would send, and the tracker
+	// remembering the sent ones, nil if there's none
+	pendingGames(games []Game) ([]Game, *SeenTracker)
+}
+
+// pendingGames returns the games a notifier would send, after its filters,
+// and the tracker remembering the sent ones. Notifiers without a tracker
+// send every game.
+func pendingGames(n Notifier, games []Game) ([]Game, *SeenTracker) {
+	switch n := n.(type) {
+	case *FilteredNotifier:
+		return pendingGames(n.Notifier, n.Filter.Apply(games))
+	case *CircuitNotifier:
+		return pendingGames(n.Notifier, games)
+	case *namedNotifier:
+		return pendingGames(n.Notifier, games)
+	case *channelNotifier:
+		return pendingGames(n.Notifier, games)
+	case pendingNotifier:
+		return n.pendingGames(games)
+	}
+	return games, nil
+}
+
+// notifyAll sends the games to every notifier, continuing past failures so one
+// broken channel doesn't prevent the others from being notified. Each channel
+// gets notifyTimeout. Games just revealed from a mystery placeholder are
+// titled as a reveal. Only the games a channel actually sent are logged as
+// delivered, and the games it failed to send as failed.
+func notifyAll(ctx context.Context, notifiers []Notifier, games []Game) error {
+	games = mysteries.announceReveals(games)
+
+	var errs []error
+	for _, n := range notifiers {
+		pending, tracker := pendingGames(n, games)
+		err := notifyChannel(ctx, n, games)
+
+		sent, failed := pending, pending
+		if tracker != nil {
+			failed = tracker.Unseen(pending)
+			sent = withoutGames(pending, failed)
+		}
+		if err == nil || tracker != nil {
+			if len(sent) > 0 {
+				recordDelivery(channelID(n), sent, nil, nil)
+				log.Printf("%s notification sent for %d games", n.Name(), len(sent))
+			}
+		}
+		if err != nil {
+			recordDelivery(channelID(n), failed, err, nil)
+			log.Printf("Error sending %s notification: %v", n.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %v", n.Name(), err))
+		}
+	}
+	return withKind(ErrNotifierFailed, errors.Join(errs...))
+}
+
+// withoutGames returns the games that aren't in exclude
+func withoutGames(games, exclude []Game) []Game {
+	excluded := make(map[string]bool, len(exclude))
+	for _, game := range exclude {
+		excluded[gameKey(game)] = true
+	}
+	var kept []Game
+	for _, game := range games {
+		if !excluded[gameKey(game)] {
+			kept = append(kept, game)
+		}
+	}
+	return kept
+}
+
+// notifyChannel sends the games to a notifier within notifyTimeout
+func notifyChannel(ctx context.Context, n Notifier, games []Game) error {
+	ctx, cancel := withStageTimeout(ctx, notifyTimeout)
+	defer cancel()
+	return n.Notify(ctx, games)
+}
+
+// httpGet is client.Get, giving up when ctx ends
+func httpGet(ctx context.Context, client *http.Client, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of free offers. Only giveaways can be kept forever; free weeks are
+// temporary trials, always-free games are free-to-play and subscription games
+// are part of a console subscription such as PS Plus.
+const (
+	offerKindGiveaway     = "giveaway"
+	offerKindFreeWeek     = "free_week"
+	offerKindAlwaysFree   = "always_free"
+	offerKindSubscription = "subscription"
+)
+
+// freeWeekPattern matches the wording of temporary free-to-play promotions
+var freeWeekPattern = regexp.MustCompile(`(?i)\bfree[ -](weekend|week|trial)\b|\bfree to play (this weekend|until)\b|\bplay (it )?for free (this weekend|until)\b`)
+
+// classifyOffer tells giveaways apart from free weeks and always-free games.
+// promoted is whether the offer has a 100% discount promotion, texts are the
+// title, description and custom attributes of the offer.
+func classifyOffer(promoted bool, originalPrice string, texts ...string) string {
+	if freeWeekPattern.MatchString(strings.Join(texts, "\n")) {
+		return offerKindFreeWeek
+	}
+	if !promoted && isFreePrice(originalPrice) {
+		return offerKindAlwaysFree
+	}
+	return offerKindGiveaway
+}
+
+// parseOfferKinds parses a comma-separated list of offer kinds. "all" or an
+// empty value returns nil, meaning every kind.
+func parseOfferKinds(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "all") {
+		return nil, nil
+	}
+	var kinds []string
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" {
+			continue
+		}
+		if err := validateOfferKind(kind); err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// validateOfferKind rejects unknown offer kinds
+func validateOfferKind(kind string) error {
+	switch kind {
+	case offerKindGiveaway, offerKindFreeWeek, offerKindAlwaysFree, offerKindSubscription:
+		return nil
+	}
+	return fmt.Errorf("unknown offer kind %q, expected giveaway, free_week, always_free or subscription", kind)
+}
+
+// hasOfferKind reports whether kinds is empty or contains the game's kind
+func hasOfferKind(kinds []string, game Game) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, kind := range kinds {
+		if strings.EqualFold(kind, game.OfferKind) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDefaultOfferKinds restricts the notifiers without an offer_kinds filter
+// to kinds, so only true giveaways are announced unless configured otherwise
+func applyDefaultOfferKinds(notifiers []Notifier, kinds []string) []Notifier {
+	if len(kinds) == 0 {
+		return notifiers
+	}
+	wrapped := make([]Notifier, 0, len(notifiers))
+	for _, n := range notifiers {
+		if filtered, ok := n.(*FilteredNotifier); ok {
+			if len(filtered.Filter.OfferKinds) == 0 {
+				filtered.Filter.OfferKinds = kinds
+			}
+		} else {
+			n = &FilteredNotifier{Notifier: n, Filter: GameFilter{OfferKinds: kinds}}
+		}
+		wrapped = append(wrapped, n)
+	}
+	return wrapped
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Key images are mostly JPEGs
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Open Graph images use the size recommended for link previews
+const (
+	ogImageWidth  = 1200
+	ogImageHeight = 630
+	ogImagePad    = 60
+)
+
+// ogImageMaxDownload bounds the size of a downloaded key image
+const ogImageMaxDownload = 20 << 20
+
+// ogImageCacheSize is the number of rendered images kept in memory
+const ogImageCacheSize = 50
+
+// ogKeyImageTypes are the key images used as background, in order of preference
+var ogKeyImageTypes = []string{"OfferImageWide", "DieselStoreFrontWide", "featuredMedia", "Thumbnail", "DieselGameBox"}
+
+var (
+	ogCacheMu sync.Mutex
+	ogCache   = map[string][]byte{}
+
+	// Font faces aren't safe for concurrent use, ogFontsMu guards drawing text
+	ogFontsOnce            sync.Once
+	ogFontsMu              sync.Mutex
+	ogTitleFace, ogSubFace font.Face
+	ogFontsErr             error
+)
+
+// ogImageHandler serves GET /og/{id}.png, a share image of one of the current
+// or upcoming free games with its key image, title and promotion dates
+func ogImageHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	rawID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/og/"), ".png")
+	id, err := url.PathUnescape(rawID)
+	if !ok || err != nil || id == "" || strings.Contains(id, "/") {
+		httpError(w, "Invalid game ID", http.StatusBadRequest)
+		return
+	}
+
+	games, err := opts.freeGames(r.Context(), true)
+	if err != nil {
+		httpError(w, fmt.Sprintf("Error fetching games: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	for _, game := range games {
+		if game.ID != id {
+			continue
+		}
+
+		subtitle := ogSubtitle(game, opts.Timezone)
+		background := ogBackgroundURL(game)
+		version := []byte(strings.Join([]string{game.ID, game.Title, subtitle, background}, "\n"))
+		sum := sha256.Sum256(version)
+		key := string(sum[:])
+
+		ogCacheMu.Lock()
+		data, cached := ogCache[key]
+		ogCacheMu.Unlock()
+		if !cached {
+			data, err = renderOGImage(game.Title, subtitle, background)
+			if err != nil {
+				httpError(w, fmt.Sprintf("Error rendering image: %v", err), http.StatusInternalServerError)
+				return
+			}
+			ogCacheMu.Lock()
+			if len(ogCache) >= ogImageCacheSize {
+				ogCache = map[string][]byte{}
+			}
+			ogCache[key] = data
+			ogCacheMu.Unlock()
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		writeConditional(w, r, data, version)
+		return
+	}
+
+	httpError(w, fmt.Sprintf("No current or upcoming free game with ID %q", id), http.StatusNotFound)
+}
+
+// ogSubtitle describes the promotion window, e.g. "Free until April 11, 2025"
+func ogSubtitle(game Game, timezone string) string {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	switch {
+	case isUpcoming(game) && !game.startTime.IsZero():
+		return "Free from " + game.startTime.In(location).Format("January 2, 2006")
+	case isUpcoming(game):
+		return "Free soon"
+	case !game.endTime.IsZero():
+		return "Free until " + game.endTime.In(location).Format("January 2, 2006")
+	default:
+		return "Free now"
+	}
+}
+
+// ogBackgroundURL picks the widest key image of the game
+func ogBackgroundURL(game Game) string {
+	for _, imageType := range ogKeyImageTypes {
+		for _, keyImage := range game.keyImages {
+			if keyImage.Type == imageType {
+				return keyImage.URL
+			}
+		}
+	}
+	return game.ImageURL
+}
+
+// renderOGImage draws the background image scaled to cover the canvas, with
+// the title and subtitle on a dark gradient at the bottom
+func renderOGImage(title, subtitle, backgroundURL string) ([]byte, error) {
+	ogFontsOnce.Do(loadOGFonts)
+	if ogFontsErr != nil {
+		return nil, ogFontsErr
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, ogImageWidth, ogImageHeight))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.RGBA{0x12, 0x12, 0x12, 0xff}), image.Point{}, draw.Src)
+
+	if backgroundURL != "" {
+		background, err := downloadImage(backgroundURL)
+		if err != nil {
+			// A plain background still makes a usable preview
+			log.Printf("Warning: Error downloading key image for share image: %v", err)
+		} else {
+			draw.CatmullRom.Scale(canvas, canvas.Bounds(), background, coverRect(background.Bounds(), canvas.Bounds()), draw.Src, nil)
+		}
+	}
+
+	// Darken the lower half so the text stays readable on bright images
+	for y := ogImageHeight / 2; y < ogImageHeight; y++ {
+		alpha := uint8(230 * (y - ogImageHeight/2) / (ogImageHeight / 2))
+		row := image.Rect(0, y, ogImageWidth, y+1)
+		draw.Draw(canvas, row, image.NewUniform(color.RGBA{0, 0, 0, alpha}), image.Point{}, draw.Over)
+	}
+
+	ogFontsMu.Lock()
+	defer ogFontsMu.Unlock()
+	maxWidth := fixed.I(ogImageWidth - 2*ogImagePad)
+	lines := wrapText(ogTitleFace, title, maxWidth, 2)
+	titleHeight := ogTitleFace.Metrics().Height.Ceil()
+	y := ogImageHeight - ogImagePad - ogSubFace.Metrics().Height.Ceil() - 16 - (len(lines)-1)*titleHeight
+	for _, line := range lines {
+		drawText(canvas, ogTitleFace, line, ogImagePad, y, color.White)
+		y += titleHeight
+	}
+	drawText(canvas, ogSubFace, subtitle, ogImagePad, ogImageHeight-ogImagePad, color.RGBA{0x7f, 0xe0, 0x5f, 0xff})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("error encoding image: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func loadOGFonts() {
+	newFace := func(ttf []byte, size float64) (font.Face, error) {
+		parsed, err := opentype.Parse(ttf)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing font: %v", err)
+		}
+		return opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	}
+	if ogTitleFace, ogFontsErr = newFace(gobold.TTF, 64); ogFontsErr == nil {
+		ogSubFace, ogFontsErr = newFace(goregular.TTF, 36)
+	}
+}
+
+// downloadImage fetches and decodes a JPEG or PNG
+func downloadImage(imageURL string) (image.Image, error) {
+	client := newHTTPClient(15 * time.Second)
+	resp, err := client.Get(imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading image: status %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(io.LimitReader(resp.Body, ogImageMaxDownload))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %v", err)
+	}
+	return img, nil
+}
+
+// coverRect is the centered part of src with the aspect ratio of dst
+func coverRect(src, dst image.Rectangle) image.Rectangle {
+	srcW, srcH := src.Dx(), src.Dy()
+	if srcW*dst.Dy() > srcH*dst.Dx() {
+		w := srcH * dst.Dx() / dst.Dy()
+		x := src.Min.X + (srcW-w)/2
+		return image.Rect(x, src.Min.Y, x+w, src.Max.Y)
+	}
+	h := srcW * dst.Dy() / dst.Dx()
+	y := src.Min.Y + (srcH-h)/2
+	return image.Rect(src.Min.X, y, src.Max.X, y+h)
+}
+
+// wrapText breaks text into at most maxLines lines no wider than maxWidth,
+// ending the last line with an ellipsis if the text doesn't fit
+func wrapText(face font.Face, text string, maxWidth fixed.Int26_6, maxLines int) []string {
+	var lines []string
+	line := ""
+	words := strings.Fields(text)
+	for i, word := range words {
+		candidate := strings.TrimSpace(line + " " + word)
+		if font.MeasureString(face, candidate) <= maxWidth || line == "" {
+			line = candidate
+			continue
+		}
+		if len(lines) == maxLines-1 {
+			line = strings.Join(append([]string{line}, words[i:]...), " ")
+			break
+		}
+		lines = append(lines, line)
+		line = word
+	}
+
+	for font.MeasureString(face, line) > maxWidth && line != "" {
+		runes := []rune(strings.TrimSuffix(line, "…"))
+		line = strings.TrimSpace(string(runes[:len(runes)-1])) + "…"
+	}
+	return append(lines, line)
+}
+
+// drawText draws text with its baseline at y
+func drawText(dst draw.Image, face font.Face, text string, x, y int, c color.Color) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(text)
+}
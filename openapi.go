@@ -0,0 +1,66 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openAPISpec describes every endpoint, keep it in sync when adding routes
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// openAPIHandler serves the OpenAPI document at /openapi.json
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(openAPISpec)
+}
+
+// docsHandler serves Swagger UI for the OpenAPI document at /docs
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, swaggerUI)
+}
+
+// swaggerAssets are the Swagger UI files served under /docs/, embedded in the
+// binary so the docs don't load scripts from a CDN
+var swaggerAssets = map[string]bool{
+	"swagger-ui.css":       true,
+	"swagger-ui-bundle.js": true,
+}
+
+// docsAssetHandler serves the embedded Swagger UI files at /docs/
+func docsAssetHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path[len("/docs/"):]
+	if !swaggerAssets[name] {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeFileFS(w, r, swaggerFiles.FS, name)
+}
+
+const swaggerUI = `<!DOCTYPE html>
+<html>
+	<head>
+		<title>Epic Games Free Games API - Docs</title>
+		<meta charset="utf-8">
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<link rel="stylesheet" href="/docs/swagger-ui.css">
+	</head>
+	<body>
+		<div id="swagger-ui"></div>
+		<script src="/docs/swagger-ui-bundle.js"></script>
+		<script>
+			window.ui = SwaggerUIBundle({
+				url: '/openapi.json',
+				dom_id: '#swagger-ui'
+			});
+		</script>
+	</body>
+</html>
+`
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// openCriticAPIURL is the OpenCritic API, served through RapidAPI
+const (
+	openCriticAPIURL  = "https://opencritic-api.p.rapidapi.com"
+	openCriticAPIHost = "opencritic-api.p.rapidapi.com"
+)
+
+// Scores hardly change once a game is out, so they're looked up again after
+// a month. Titles OpenCritic doesn't know are retried after a week.
+const (
+	criticScoreRefresh = 30 * 24 * time.Hour
+	criticMissRefresh  = 7 * 24 * time.Hour
+)
+
+// criticScore is a cached OpenCritic lookup. A zero Score means the title
+// wasn't found or isn't rated yet.
+type criticScore struct {
+	Score   int       `json:"score,omitempty"`
+	Tier    string    `json:"tier,omitempty"` // "Mighty", "Strong", "Fair" or "Weak"
+	URL     string    `json:"url,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// OpenCriticClient adds critic scores to games, caching them by title
+type OpenCriticClient struct {
+	APIKey string
+
+	cache *titleCache[criticScore]
+}
+
+// NewOpenCriticClient creates a client, loading the cache from path if it
+// exists. An empty path keeps the cache in memory only.
+func NewOpenCriticClient(apiKey, path string) *OpenCriticClient {
+	return &OpenCriticClient{
+		APIKey: apiKey,
+		cache:  newTitleCache[criticScore]("OpenCritic", path),
+	}
+}
+
+// Name implements Enricher
+func (c *OpenCriticClient) Name() string {
+	return "OpenCritic"
+}
+
+// Enrich sets the critic score fields of the games that OpenCritic rated.
+// Failed lookups are logged and leave the game without a score.
+func (c *OpenCriticClient) Enrich(ctx context.Context, games []Game) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	changed := false
+	for i := range games {
+		key := titleKey(games[i].Title)
+		if key == "" || isMysteryGame(games[i]) {
+			continue
+		}
+
+		cached, ok := c.cache.entries[key]
+		refresh := criticScoreRefresh
+		if ok && cached.Score == 0 {
+			refresh = criticMissRefresh
+		}
+		if !ok || time.Since(cached.Checked) > refresh {
+			score, err := c.lookup(ctx, games[i].Title)
+			if err != nil {
+				log.Printf("Warning: Error looking up critic score of %s: %v", games[i].Title, err)
+				if !ok {
+					continue
+				}
+			} else {
+				cached = score
+				c.cache.entries[key] = score
+				changed = true
+			}
+		}
+
+		games[i].CriticScore = cached.Score
+		games[i].CriticTier = cached.Tier
+		games[i].CriticURL = cached.URL
+	}
+
+	if changed {
+		if err := c.cache.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// lookup searches OpenCritic for the title and fetches the score of the
+// closest match
+func (c *OpenCriticClient) lookup(ctx context.Context, title string) (*criticScore, error) {
+	score := &criticScore{Checked: time.Now()}
+
+	var results []struct {
+		ID   int     `json:"id"`
+		Name string  `json:"name"`
+		Dist float64 `json:"dist"` // Distance to the search, 0 is an exact match
+	}
+	if err := c.get(ctx, "/game/search?"+url.Values{"criteria": {title}}.Encode(), &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || results[0].Dist > 0.3 {
+		return score, nil
+	}
+
+	var details struct {
+		TopCriticScore float64 `json:"topCriticScore"`
+		Tier           string  `json:"tier"`
+		URL            string  `json:"url"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/game/%d", results[0].ID), &details); err != nil {
+		return nil, err
+	}
+	if details.TopCriticScore > 0 {
+		score.Score = int(details.TopCriticScore + 0.5)
+		score.Tier = details.Tier
+		score.URL = details.URL
+	}
+	return score, nil
+}
+
+// get calls an OpenCritic API path and decodes the JSON response into v
+func (c *OpenCriticClient) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", openCriticAPIURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("X-RapidAPI-Key", c.APIKey)
+	req.Header.Set("X-RapidAPI-Host", openCriticAPIHost)
+
+	client := newHTTPClient(15 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// criticScoreText formats the score of a game for notifications, e.g.
+// "84 (Mighty)", empty when the game has no score
+func criticScoreText(game Game) string {
+	if game.CriticScore == 0 {
+		return ""
+	}
+	if game.CriticTier == "" {
+		return fmt.Sprint(game.CriticScore)
+	}
+	return fmt.Sprintf("%d (%s)", game.CriticScore, game.CriticTier)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// OpsAlerter posts operational alerts, like a scheduled check failing, to a
+// webhook watched by whoever runs the instance
+type OpsAlerter struct {
+	URL    string
+	client *http.Client
+}
+
+// NewOpsAlerter creates an alerter posting to url, nil when url is empty
+func NewOpsAlerter(url string) *OpsAlerter {
+	if url == "" {
+		return nil
+	}
+	return &OpsAlerter{URL: url, client: newHTTPClient(10 * time.Second)}
+}
+
+// Alert logs the message and posts it to the webhook. The payload sets both
+// text and content, so Slack, Mattermost, Rocket.Chat and Discord webhooks
+// all show it.
+func (o *OpsAlerter) Alert(message string) {
+	log.Printf("Alert: %s", message)
+	if o == nil {
+		return
+	}
+	if err := o.post(message); err != nil {
+		log.Printf("Warning: Error sending ops alert: %v", err)
+	}
+}
+
+func (o *OpsAlerter) post(message string) error {
+	payload, err := json.Marshal(map[string]string{
+		"text":    message,
+		"content": message,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling alert: %v", err)
+	}
+
+	resp, err := o.client.Post(o.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error sending alert: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ops webhook returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+// Package client calls the REST API of a running epic-games-api instance,
+// for Go bots that consume an instance instead of fetching Epic themselves:
+//
+//	c := client.New("https://games.example.com")
+//	resp, err := c.FreeGames(ctx, client.FreeGamesOptions{Country: "GB"})
+//
+// Reads are retried when the instance is unavailable or rate limits them,
+// honoring Retry-After. Subscribe follows the /v1/stream events.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryAfter is the longest Retry-After waited for, the request fails
+// when the instance asks to wait longer
+const maxRetryAfter = time.Minute
+
+// Doer sends HTTP requests, *http.Client implements it
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client calls an instance's API. Bound calls with their context, the
+// default HTTP client has no timeout so Subscribe can stay connected.
+type Client struct {
+	BaseURL    string // e.g. "https://games.example.com", without /v1
+	APIKey     string // Sent as X-API-Key when set
+	HTTPClient Doer
+	Retries    int           // Retries of failed reads
+	RetryDelay time.Duration // Backoff before the first retry, doubled for every other
+}
+
+// New creates a client of the instance at baseURL, retrying failed reads
+// twice
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{},
+		Retries:    2,
+		RetryDelay: time.Second,
+	}
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Code       string // Machine-readable code, e.g. "bad_parameter" or "rate_limited"
+	Message    string
+	RequestID  string // Quote it when reporting a bug to the instance's operator
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("API error %d", e.StatusCode)
+	if e.Code != "" {
+		message += " " + e.Code
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request ID %s)", e.RequestID)
+	}
+	return message
+}
+
+// Game is a free game, as returned by /v1/free-games
+type Game struct {
+	ID             string    `json:"id,omitempty"` // "{namespace}:{offerID}" for Epic, e.g. "gog:{productID}" for other stores
+	Title          string    `json:"title"`
+	Description    string    `json:"description,omitempty"`
+	ImageURL       string    `json:"image_url,omitempty"`
+	URL            string    `json:"url,omitempty"`
+	Status         string    `json:"status"` // "free", "coming soon" or "mystery"
+	StartDate      string    `json:"start_date"`
+	EndDate        string    `json:"end_date"`
+	DatePrecision  string    `json:"date_precision"` // "exact", "estimated", or "unknown"
+	StartsIn       string    `json:"starts_in,omitempty"`
+	EndsIn         string    `json:"ends_in,omitempty"`
+	StartDateISO   time.Time `json:"start_date_iso,omitzero"`
+	EndDateISO     time.Time `json:"end_date_iso,omitzero"`
+	Publisher      string    `json:"publisher,omitempty"`
+	OriginalPrice  string    `json:"original_price,omitempty"`
+	DiscountPrice  string    `json:"discount_price,omitempty"`
+	OfferType      string    `json:"offer_type,omitempty"`
+	Genres         []string  `json:"genres,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	RevealedFrom   string    `json:"revealed_from,omitempty"`
+	OfferKind      string    `json:"offer_kind,omitempty"` // "giveaway", "free_week", "always_free" or "subscription"
+	Store          string    `json:"store"`
+	Platforms      []string  `json:"platforms,omitempty"`
+	CriticScore    int       `json:"critic_score,omitempty"`
+	CriticTier     string    `json:"critic_tier,omitempty"`
+	CriticURL      string    `json:"critic_url,omitempty"`
+	ReleaseYear    int       `json:"release_year,omitempty"`
+	Developer      string    `json:"developer,omitempty"`
+	Screenshots    []string  `json:"screenshots,omitempty"`
+	PlaytimeMain   float64   `json:"playtime_main,omitempty"`
+	Playtime100    float64   `json:"playtime_completionist,omitempty"`
+	ProtonDBTier   string    `json:"protondb_tier,omitempty"`
+	SteamDeck      string    `json:"steam_deck,omitempty"`
+	TrailerURL     string    `json:"trailer_url,omitempty"`
+	AvailableIn    []string  `json:"available_countries,omitempty"`
+	UnavailableIn  []string  `json:"unavailable_countries,omitempty"`
+	BundleContents []string  `json:"bundle_contents,omitempty"`
+}
+
+// FreeGamesOptions are the query parameters of /v1/free-games, zero values
+// leave the instance's defaults
+type FreeGamesOptions struct {
+	Country       string
+	Locale        string
+	CurrentOnly   bool // Leave out the upcoming games
+	IncludeAddons bool
+	OfferKinds    []string // e.g. "giveaway" or "free_week"
+	Stores        []string // e.g. "epic" or "gog"
+	Platforms     []string
+	Genres        []string
+	Sort          string // "end_date", "start_date" or "title"
+	Descending    bool
+	Limit         int
+	Offset        int
+	Refresh       bool // Fetch from Epic instead of the instance's cache
+}
+
+// FreeGamesResponse is the response of /v1/free-games
+type FreeGamesResponse struct {
+	SchemaVersion int    `json:"schema_version"`
+	Message       string `json:"message,omitempty"` // Errors Epic reported alongside partial data
+	Count         int    `json:"count"`
+	Total         int    `json:"total"` // Games before pagination
+	Source        string `json:"source,omitempty"`
+	Stale         bool   `json:"stale,omitempty"`    // Fetching failed, the games are from an earlier fetch
+	Degraded      bool   `json:"degraded,omitempty"` // Epic's circuit is open, only cached games are served
+	Age           int    `json:"age,omitempty"`      // Seconds since the games were fetched
+	Data          []Game `json:"data"`
+}
+
+// FreeGames returns the current and upcoming free games
+func (c *Client) FreeGames(ctx context.Context, opts FreeGamesOptions) (*FreeGamesResponse, error) {
+	query := url.Values{}
+	setParam(query, "country", opts.Country)
+	setParam(query, "locale", opts.Locale)
+	if opts.CurrentOnly {
+		query.Set("upcoming", "false")
+	}
+	if opts.IncludeAddons {
+		query.Set("include_addons", "true")
+	}
+	setParam(query, "offer_kinds", strings.Join(opts.OfferKinds, ","))
+	setParam(query, "store", strings.Join(opts.Stores, ","))
+	setParam(query, "platform", strings.Join(opts.Platforms, ","))
+	setParam(query, "genre", strings.Join(opts.Genres, ","))
+	setParam(query, "sort", opts.Sort)
+	if opts.Descending {
+		query.Set("order", "desc")
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.Refresh {
+		query.Set("refresh", "true")
+	}
+
+	var resp FreeGamesResponse
+	if err := c.get(ctx, "/v1/free-games", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// HistoryEntry is a past giveaway, as returned by /v1/history
+type HistoryEntry struct {
+	ID            string    `json:"id,omitempty"`
+	Title         string    `json:"title"`
+	URL           string    `json:"url,omitempty"`
+	ImageURL      string    `json:"image_url,omitempty"`
+	Publisher     string    `json:"publisher,omitempty"`
+	OriginalPrice string    `json:"original_price,omitempty"`
+	PriceValue    float64   `json:"price_value,omitempty"` // Regular price in Currency
+	Currency      string    `json:"currency,omitempty"`
+	Country       string    `json:"country"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	DatePrecision string    `json:"date_precision"`
+	FirstSeen     time.Time `json:"first_seen"`
+}
+
+// HistoryOptions filters the past giveaways, zero values don't filter
+type HistoryOptions struct {
+	Year    int    // Giveaways that started in this year
+	Country string // Giveaways seen in this store country
+	Title   string // Case-insensitive title search
+}
+
+// History returns the past giveaways, newest first
+func (c *Client) History(ctx context.Context, opts HistoryOptions) ([]HistoryEntry, error) {
+	query := url.Values{}
+	if opts.Year > 0 {
+		query.Set("year", strconv.Itoa(opts.Year))
+	}
+	setParam(query, "country", opts.Country)
+	setParam(query, "title", opts.Title)
+
+	var resp struct {
+		Data []HistoryEntry `json:"data"`
+	}
+	if err := c.get(ctx, "/v1/history", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// setParam sets a query parameter unless the value is empty
+func setParam(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// get fetches path with retries and decodes the JSON response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	resp, err := c.send(ctx, path, query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// send requests path until it answers 200. Network errors, rate limited
+// (429) and failed (5xx) responses are retried c.Retries times, backing off
+// exponentially with jitter and honoring Retry-After.
+func (c *Client) send(ctx context.Context, path string, query url.Values, header http.Header) (*http.Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	endpoint := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	backoff := c.RetryDelay
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %v", err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if c.APIKey != "" {
+			req.Header.Set("X-API-Key", c.APIKey)
+		}
+
+		var retryAfter time.Duration
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			err = fmt.Errorf("error sending request: %v", err)
+			if ctx.Err() != nil {
+				return nil, err
+			}
+		} else {
+			if resp.StatusCode == http.StatusOK {
+				return resp, nil
+			}
+			err = responseError(resp)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return nil, err
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		if attempt >= c.Retries {
+			return nil, err
+		}
+		if retryAfter > maxRetryAfter {
+			return nil, fmt.Errorf("%v (retry after %v)", err, retryAfter)
+		}
+
+		// Equal jitter: half the backoff, plus up to the other half
+		wait := backoff
+		if backoff > 1 {
+			wait = backoff/2 + rand.N(backoff/2)
+		}
+		wait = max(wait, retryAfter)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// responseError reads the error of a failed response, which is JSON on the
+// API endpoints and plain text elsewhere
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	var payload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		apiErr.Code, apiErr.Message = payload.Code, payload.Message
+	} else {
+		// Plain text errors end with the request ID, which Error adds back
+		message := strings.TrimSpace(string(body))
+		apiErr.Message = strings.TrimSuffix(message, fmt.Sprintf(" (request ID %s)", apiErr.RequestID))
+	}
+	return apiErr
+}
+
+// parseRetryAfter reads a Retry-After header, in seconds or as an HTTP date,
+// 0 when it's missing or invalid
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
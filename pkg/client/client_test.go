@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFreeGames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/free-games" {
+			t.Errorf("path = %s, want /v1/free-games", r.URL.Path)
+		}
+		if got := r.URL.Query().Encode(); got != "country=GB&limit=5&offer_kinds=giveaway%2Cfree_week&upcoming=false" {
+			t.Errorf("query = %s", got)
+		}
+		if got := r.Header.Get("X-API-Key"); got != "secret" {
+			t.Errorf("X-API-Key = %q, want secret", got)
+		}
+		fmt.Fprint(w, `{"schema_version": 1, "count": 1, "total": 1, "source": "promotions",
+			"data": [{"title": "Control", "status": "free", "store": "epic", "critic_score": 85,
+			"end_date_iso": "2026-10-22T15:00:00Z"}]}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL + "/")
+	c.APIKey = "secret"
+	resp, err := c.FreeGames(context.Background(), FreeGamesOptions{
+		Country:     "GB",
+		CurrentOnly: true,
+		OfferKinds:  []string{"giveaway", "free_week"},
+		Limit:       5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Source != "promotions" || len(resp.Data) != 1 {
+		t.Fatalf("response = %+v", resp)
+	}
+	game := resp.Data[0]
+	if game.Title != "Control" || game.CriticScore != 85 || !game.EndDateISO.Equal(time.Date(2026, 10, 22, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("game = %+v", game)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-1")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code": "bad_parameter", "message": "Invalid country"}`)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).History(context.Background(), HistoryOptions{Country: "XX"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want an *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "bad_parameter" || apiErr.Message != "Invalid country" || apiErr.RequestID != "req-1" {
+		t.Errorf("error = %+v", apiErr)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"title": "Control", "country": "US"}]}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	c.RetryDelay = time.Millisecond
+	entries, err := c.History(context.Background(), HistoryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || calls.Load() != 3 {
+		t.Errorf("got %d entries after %d calls, want 1 after 3", len(entries), calls.Load())
+	}
+
+	calls.Store(0)
+	c.Retries = 1
+	if _, err := c.History(context.Background(), HistoryOptions{}); err == nil {
+		t.Error("History succeeded, want the 429 once the retries ran out")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	var mu sync.Mutex
+	var lastEventIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		first := len(lastEventIDs) == 1
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		if first {
+			fmt.Fprint(w, "retry: 1\n\nid: 7\nevent: game_added\ndata: {\"title\": \"Control\"}\n\n")
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []Event
+	err := New(server.URL).Subscribe(ctx, func(e Event) {
+		events = append(events, e)
+		go func() {
+			// Let the stream reconnect once before stopping
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+	})
+	if err == nil || ctx.Err() == nil {
+		t.Errorf("Subscribe returned %v before it was canceled", err)
+	}
+	if len(events) != 1 || events[0].ID != 7 || events[0].Type != EventGameAdded || events[0].Game.Title != "Control" {
+		t.Errorf("events = %+v", events)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lastEventIDs) < 2 || lastEventIDs[1] != "7" {
+		t.Errorf("Last-Event-ID of the requests = %q, want 7 on the reconnect", lastEventIDs)
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of the events of /v1/stream
+const (
+	EventGameAdded    = "game_added"    // A game became free or was announced
+	EventGameExpiring = "game_expiring" // A free game ends soon
+	EventGameRemoved  = "game_removed"  // A game is no longer listed
+)
+
+// Event is a change to the free games list
+type Event struct {
+	ID   int64
+	Type string
+	Game Game
+}
+
+// defaultReconnectDelay is waited before reconnecting until the instance
+// sends its own delay
+const defaultReconnectDelay = 10 * time.Second
+
+// Subscribe calls handle with every event of /v1/stream until ctx is done,
+// and returns its error then. Dropped connections are reopened with the
+// ID of the last event, so no event is missed in between. It gives up when
+// the instance rejects the stream, or is still unavailable after c.Retries
+// retries.
+func (c *Client) Subscribe(ctx context.Context, handle func(Event)) error {
+	var lastEventID int64
+	delay := defaultReconnectDelay
+	for {
+		header := http.Header{"Accept": {"text/event-stream"}}
+		if lastEventID > 0 {
+			header.Set("Last-Event-ID", strconv.FormatInt(lastEventID, 10))
+		}
+		resp, err := c.send(ctx, "/v1/stream", nil, header)
+		if err != nil {
+			return err
+		}
+		lastEventID, delay = readEvents(resp, lastEventID, delay, handle)
+		resp.Body.Close()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readEvents reads the events of a stream until it ends, returning the ID
+// of the last event and the reconnect delay the instance asked for
+func readEvents(resp *http.Response, lastEventID int64, delay time.Duration, handle func(Event)) (int64, time.Duration) {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	var event Event
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line dispatches the event
+			if event.Type != "" && data.Len() > 0 {
+				if err := json.Unmarshal([]byte(data.String()), &event.Game); err == nil {
+					handle(event)
+				}
+			}
+			if event.ID > 0 {
+				lastEventID = event.ID
+			}
+			event = Event{}
+			data.Reset()
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.ID, _ = strconv.ParseInt(value, 10, 64)
+		case "event":
+			event.Type = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return lastEventID, delay
+}
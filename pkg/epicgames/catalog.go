@@ -0,0 +1,371 @@
+// Package epicgames fetches the catalog of free offers of the Epic Games
+// Store, the raw material of the server's free games:
+//
+//	client := epicgames.NewClient()
+//	catalog, err := client.Promotions(ctx, "US", "en-US")
+//	for _, element := range catalog.Data.Catalog.SearchStore.Elements {
+//		fmt.Println(element.Title, element.StoreURL())
+//	}
+//
+// The server fetches with this client, adding retries and circuit breaking
+// through Client.HTTPClient. Telling which offers are free, their dates and
+// kinds is left to the server: programs wanting the classified games call a
+// running instance with package client.
+package epicgames
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Endpoints of Epic's store backend
+const (
+	// GraphQLURL serves the searchStore query
+	GraphQLURL = "https://graphql.epicgames.com/graphql"
+	// PromotionsURL is the endpoint behind the store's free games section
+	PromotionsURL = "https://store-site-backend-static.ak.epicgames.com/freeGamesPromotions"
+)
+
+// BrowserUserAgent is sent to Epic, which rejects some requests without a
+// browser's User-Agent
+const BrowserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// Store categories searched for free games, AddonCategories are added to
+// GameCategories when looking for add-ons
+const (
+	GameCategories  = "games/edition/base|bundles/games|editors"
+	AddonCategories = "addons|digitalextras"
+)
+
+// searchStoreQuery searches the catalog for free games on sale
+const searchStoreQuery = `
+query searchStoreQuery(
+  $category: String,
+  $count: Int,
+  $country: String!,
+  $locale: String,
+  $freeGame: Boolean,
+  $onSale: Boolean,
+  $withPrice: Boolean = true
+) {
+  Catalog {
+    searchStore(
+      category: $category
+      count: $count
+      country: $country
+      freeGame: $freeGame
+      onSale: $onSale
+      locale: $locale
+    ) {
+      elements {
+        title
+        description
+        seller {
+          name
+        }
+        keyImages {
+          type
+          url
+        }
+        productSlug
+        urlSlug
+        url
+        offerMappings {
+          pageSlug
+          pageType
+        }
+        catalogNs {
+          mappings(pageType: "productHome") {
+            pageSlug
+            pageType
+          }
+        }
+        linkedOffer {
+          effectiveDate
+          customAttributes{
+            key
+            value
+          }
+        }
+        categories {
+          path
+        }
+        tags {
+          id
+          name
+          groupName
+        }
+        offerType
+        namespace
+        id
+        price(country: $country) @include(if: $withPrice) {
+          totalPrice {
+            originalPrice
+            currencyCode
+            currencyInfo {
+              decimals
+            }
+            fmtPrice(locale: $locale) {
+              discountPrice
+              originalPrice
+            }
+          }
+        }
+        promotions {
+          promotionalOffers {
+            promotionalOffers {
+              startDate
+              endDate
+              discountSetting {
+                discountType
+                discountPercentage
+              }
+            }
+          }
+          upcomingPromotionalOffers {
+            promotionalOffers {
+              startDate
+              endDate
+              discountSetting {
+                discountType
+                discountPercentage
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+
+// GraphQLRequest is the body of a GraphQL request
+type GraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// CatalogResponse is the response of the searchStore query. The
+// freeGamesPromotions endpoint answers in the same shape.
+type CatalogResponse struct {
+	Data struct {
+		Catalog struct {
+			SearchStore struct {
+				Elements []Element `json:"elements"`
+			} `json:"searchStore"`
+		} `json:"Catalog"`
+	} `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
+
+// Element is an offer of the catalog
+type Element struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Seller      struct {
+		Name string `json:"name"`
+	} `json:"seller"`
+	KeyImages []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"keyImages"`
+	ProductSlug   string `json:"productSlug"`
+	URL           string `json:"url"`
+	UrlSlug       string `json:"urlSlug"`
+	OfferMappings []struct {
+		PageSlug string `json:"pageSlug"`
+		PageType string `json:"pageType"`
+	} `json:"offerMappings"`
+	CatalogNs struct {
+		Mappings []struct {
+			PageSlug string `json:"pageSlug"`
+			PageType string `json:"pageType"`
+		} `json:"mappings"`
+	} `json:"catalogNs"`
+	LinkedOffer struct {
+		EffectiveDate    string `json:"effectiveDate"`
+		CustomAttributes []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"customAttributes"`
+	} `json:"linkedOffer"`
+	// Set at the top level by the freeGamesPromotions endpoint
+	CustomAttributes []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"customAttributes"`
+	Categories []struct {
+		Path string `json:"path"`
+	} `json:"categories"`
+	Tags []struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		GroupName string `json:"groupName"`
+	} `json:"tags"`
+	OfferType string `json:"offerType"`
+	Namespace string `json:"namespace"`
+	ID        string `json:"id"`
+	Price     struct {
+		TotalPrice struct {
+			OriginalPrice int    `json:"originalPrice"`
+			CurrencyCode  string `json:"currencyCode"`
+			CurrencyInfo  struct {
+				Decimals int `json:"decimals"`
+			} `json:"currencyInfo"`
+			FmtPrice struct {
+				OriginalPrice string `json:"originalPrice"`
+				DiscountPrice string `json:"discountPrice"`
+			} `json:"fmtPrice"`
+		} `json:"totalPrice"`
+	} `json:"price"`
+	Promotions struct {
+		PromotionalOffers         []PromotionalOfferGroup `json:"promotionalOffers"`
+		UpcomingPromotionalOffers []PromotionalOfferGroup `json:"upcomingPromotionalOffers"`
+	} `json:"promotions"`
+}
+
+// PromotionalOfferGroup is a group of promotions of an offer
+type PromotionalOfferGroup struct {
+	PromotionalOffers []struct {
+		StartDate       string `json:"startDate"`
+		EndDate         string `json:"endDate"`
+		DiscountSetting struct {
+			DiscountType       string `json:"discountType"`
+			DiscountPercentage int    `json:"discountPercentage"`
+		} `json:"discountSetting"`
+	} `json:"promotionalOffers"`
+}
+
+// GraphQLError is an entry of the errors array of a GraphQL response, which
+// Epic returns with a 200 status
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e GraphQLError) String() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("%s (at %s)", e.Message, strings.Join(path, "."))
+}
+
+// JoinErrors joins the messages of GraphQL errors
+func JoinErrors(errs []GraphQLError) string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// IsAddon reports whether the offer is DLC or another add-on
+func (e *Element) IsAddon() bool {
+	for _, category := range e.Categories {
+		if strings.HasPrefix(category.Path, "addons") || strings.HasPrefix(category.Path, "digitalextras") {
+			return true
+		}
+	}
+	return false
+}
+
+// PageSlug returns the slug of the offer's store page, empty when Epic
+// didn't map one
+func (e *Element) PageSlug() string {
+	for _, mapping := range e.OfferMappings {
+		if mapping.PageSlug != "" {
+			return mapping.PageSlug
+		}
+	}
+	for _, mapping := range e.CatalogNs.Mappings {
+		if mapping.PageSlug != "" {
+			return mapping.PageSlug
+		}
+	}
+	return ""
+}
+
+// StoreURL returns the offer's store page
+func (e *Element) StoreURL() string {
+	return fmt.Sprintf("https://store.epicgames.com/en-US/p/%s", e.PageSlug())
+}
+
+// ImageURL returns the offer's thumbnail or box art, empty when it has
+// neither
+func (e *Element) ImageURL() string {
+	for _, img := range e.KeyImages {
+		if img.Type == "Thumbnail" || img.Type == "DieselGameBox" {
+			return img.URL
+		}
+	}
+	return ""
+}
+
+// DropAddons removes the add-ons from the elements. The freeGamesPromotions
+// endpoint has no category filter, unlike the searchStore query.
+func (r *CatalogResponse) DropAddons() {
+	elements := r.Data.Catalog.SearchStore.Elements
+	kept := elements[:0]
+	for _, element := range elements {
+		if !element.IsAddon() {
+			kept = append(kept, element)
+		}
+	}
+	r.Data.Catalog.SearchStore.Elements = kept
+}
+
+// NewSearchRequest builds the searchStore query of the free games of a
+// country, with add-ons when withAddons is set
+func NewSearchRequest(ctx context.Context, countryCode, locale string, withAddons bool) (*http.Request, error) {
+	category := GameCategories
+	if withAddons {
+		category += "|" + AddonCategories
+	}
+	requestBody, err := json.Marshal(GraphQLRequest{
+		Query: searchStoreQuery,
+		Variables: map[string]interface{}{
+			"category": category,
+			"count":    100,
+			"country":  countryCode,
+			"locale":   locale,
+			"freeGame": true,
+			"onSale":   true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", GraphQLURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", BrowserUserAgent)
+	return req, nil
+}
+
+// NewPromotionsRequest builds the request of the freeGamesPromotions
+// endpoint for a country
+func NewPromotionsRequest(ctx context.Context, countryCode, locale string) (*http.Request, error) {
+	query := url.Values{
+		"locale":         {locale},
+		"country":        {countryCode},
+		"allowCountries": {countryCode},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", PromotionsURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", BrowserUserAgent)
+	return req, nil
+}
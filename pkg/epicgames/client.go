@@ -0,0 +1,87 @@
+package epicgames
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Doer sends HTTP requests, *http.Client implements it
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client fetches Epic's catalog of free games. Requests aren't retried, wrap
+// HTTPClient for that.
+type Client struct {
+	HTTPClient Doer
+}
+
+// NewClient creates a client with a 30 second timeout per request
+func NewClient() *Client {
+	return &Client{HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Promotions fetches the freeGamesPromotions endpoint. Add-ons are included,
+// see CatalogResponse.DropAddons. An answer without games is an error, the
+// endpoint sometimes returns one while Epic updates the giveaways.
+func (c *Client) Promotions(ctx context.Context, countryCode, locale string) (*CatalogResponse, error) {
+	req, err := NewPromotionsRequest(ctx, countryCode, locale)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data.Catalog.SearchStore.Elements) == 0 {
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("error from promotions endpoint: %s", JoinErrors(resp.Errors))
+		}
+		return nil, fmt.Errorf("promotions endpoint returned no games")
+	}
+	return resp, nil
+}
+
+// Search runs the searchStore query for the free games. GraphQL errors are
+// only returned as an error when Epic sent no games along with them.
+func (c *Client) Search(ctx context.Context, countryCode, locale string, withAddons bool) (*CatalogResponse, error) {
+	req, err := NewSearchRequest(ctx, countryCode, locale, withAddons)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data.Catalog.SearchStore.Elements) == 0 && len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("error from GraphQL API: %s", JoinErrors(resp.Errors))
+	}
+	return resp, nil
+}
+
+// do sends a catalog request and decodes its response
+func (c *Client) do(req *http.Request) (*CatalogResponse, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	var catalog CatalogResponse
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	return &catalog, nil
+}
@@ -0,0 +1,118 @@
+package epicgames
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// doerFunc answers requests without a network
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// respond returns a doer answering every request with status and body
+func respond(status int, body string, requests *[]*http.Request) Doer {
+	return doerFunc(func(req *http.Request) (*http.Response, error) {
+		if requests != nil {
+			*requests = append(*requests, req)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})
+}
+
+const catalogJSON = `{"data": {"Catalog": {"searchStore": {"elements": [
+	{"title": "Control", "namespace": "ns", "id": "control",
+	 "offerMappings": [{"pageSlug": "control", "pageType": "productHome"}],
+	 "keyImages": [{"type": "OfferImageWide", "url": "wide.jpg"}, {"type": "Thumbnail", "url": "thumb.jpg"}],
+	 "categories": [{"path": "games/edition/base"}]},
+	{"title": "Control Expansion", "namespace": "ns", "id": "expansion",
+	 "categories": [{"path": "addons/durable"}]}
+]}}}}`
+
+func TestPromotions(t *testing.T) {
+	var requests []*http.Request
+	client := &Client{HTTPClient: respond(http.StatusOK, catalogJSON, &requests)}
+	catalog, err := client.Promotions(context.Background(), "DE", "de-DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := requests[0].URL.Query()
+	if requests[0].Method != "GET" || query.Get("country") != "DE" || query.Get("locale") != "de-DE" || query.Get("allowCountries") != "DE" {
+		t.Errorf("request = %s %s, want a GET of the DE promotions", requests[0].Method, requests[0].URL)
+	}
+	if requests[0].Header.Get("User-Agent") != BrowserUserAgent {
+		t.Errorf("User-Agent = %q, want the browser's", requests[0].Header.Get("User-Agent"))
+	}
+
+	elements := catalog.Data.Catalog.SearchStore.Elements
+	if len(elements) != 2 {
+		t.Fatalf("got %d elements, want 2 with the add-on", len(elements))
+	}
+	if got := elements[0].StoreURL(); got != "https://store.epicgames.com/en-US/p/control" {
+		t.Errorf("StoreURL() = %q", got)
+	}
+	if got := elements[0].ImageURL(); got != "thumb.jpg" {
+		t.Errorf("ImageURL() = %q, want the thumbnail", got)
+	}
+
+	catalog.DropAddons()
+	if elements := catalog.Data.Catalog.SearchStore.Elements; len(elements) != 1 || elements[0].Title != "Control" {
+		t.Errorf("DropAddons kept %+v, want Control only", elements)
+	}
+}
+
+func TestPromotionsWithoutGames(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", `{"data": {"Catalog": {"searchStore": {"elements": []}}}}`, "returned no games"},
+		{"errors", `{"errors": [{"message": "boom", "path": ["Catalog", 0]}]}`, "boom (at Catalog.0)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{HTTPClient: respond(http.StatusOK, tt.body, nil)}
+			_, err := client.Promotions(context.Background(), "US", "en-US")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var requests []*http.Request
+	body := `{"data": {"Catalog": {"searchStore": {"elements": [{"title": "Control"}]}}}, "errors": [{"message": "partial"}]}`
+	client := &Client{HTTPClient: respond(http.StatusOK, body, &requests)}
+	catalog, err := client.Search(context.Background(), "US", "en-US", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(catalog.Errors) != 1 || len(catalog.Data.Catalog.SearchStore.Elements) != 1 {
+		t.Errorf("catalog = %+v, want the game along with the error", catalog)
+	}
+
+	var sent GraphQLRequest
+	if err := json.NewDecoder(requests[0].Body).Decode(&sent); err != nil {
+		t.Fatal(err)
+	}
+	if requests[0].Method != "POST" || sent.Variables["country"] != "US" || sent.Variables["category"] != GameCategories+"|"+AddonCategories {
+		t.Errorf("request = %s with %v, want a POST searching games and add-ons in US", requests[0].Method, sent.Variables)
+	}
+}
+
+func TestBadStatus(t *testing.T) {
+	client := &Client{HTTPClient: respond(http.StatusServiceUnavailable, "down", nil)}
+	_, err := client.Search(context.Background(), "US", "en-US", false)
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "down") {
+		t.Errorf("error = %v, want the status and body", err)
+	}
+}
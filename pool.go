@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// fetchConcurrency bounds the stores and countries fetched at once, set by
+// -fetch-concurrency
+var fetchConcurrency = 4
+
+// fetchConcurrently runs the fetches on a pool of at most fetchConcurrency
+// workers. Results and errors come back in the order of the fetches, whatever
+// order they finish in, so merging them is deterministic. Fetches that
+// haven't started when ctx is done fail with its error.
+func fetchConcurrently[T any](ctx context.Context, fetches []func(context.Context) (T, error)) ([]T, []error) {
+	results := make([]T, len(fetches))
+	errs := make([]error, len(fetches))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(fetchConcurrency, 1), len(fetches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = fetches[i](ctx)
+			}
+		}()
+	}
+	for i := range fetches {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs
+}
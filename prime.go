@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"epic-games-api/pkg/epicgames"
+)
+
+// primeGamingGraphQLURL is the GraphQL API of the Prime Gaming website
+const primeGamingGraphQLURL = "https://gaming.amazon.com/graphql"
+
+// primeOffersQuery lists the full games offered with Prime, both the monthly
+// free games and the "games with Prime" offers. In-game loot is left out.
+const primeOffersQuery = `
+query primeOffers($group: OfferGroup, $pageSize: Int) {
+  primeOffers(group: $group, pageSize: $pageSize) {
+    id
+    title
+    description
+    startTime
+    endTime
+    assets {
+      type
+      purpose
+      location2x
+    }
+    game {
+      assets {
+        publisher
+      }
+    }
+  }
+}`
+
+// includePrimeGaming adds the Prime Gaming games to every fetch, set by
+// -prime-gaming
+var includePrimeGaming bool
+
+// primeOffersResponse is the part of the primeOffers response the service uses
+type primeOffersResponse struct {
+	Data struct {
+		PrimeOffers []struct {
+			ID          string `json:"id"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			StartTime   string `json:"startTime"`
+			EndTime     string `json:"endTime"`
+			Assets      []struct {
+				Type       string `json:"type"`
+				Purpose    string `json:"purpose"`
+				Location2x string `json:"location2x"`
+			} `json:"assets"`
+			Game struct {
+				Assets struct {
+					Publisher string `json:"publisher"`
+				} `json:"assets"`
+			} `json:"game"`
+		} `json:"primeOffers"`
+	} `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
+
+// fetchPrimeGamingOffers returns the games Prime members can claim. The offers
+// need a Prime subscription, so they are only free for members.
+func fetchPrimeGamingOffers(ctx context.Context, includeUpcoming bool, timezone string) ([]Game, error) {
+	requestBody, err := json.Marshal(GraphQLRequest{
+		Query: primeOffersQuery,
+		Variables: map[string]interface{}{
+			"group":    "FGWP_FULL",
+			"pageSize": 100,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", primeGamingGraphQLURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The website's client ID, the API rejects requests without one
+	req.Header.Set("Client-Id", "CarboxWebsite")
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var offersResp primeOffersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&offersResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	if len(offersResp.Errors) > 0 && len(offersResp.Data.PrimeOffers) == 0 {
+		return nil, fmt.Errorf("error from Prime Gaming API: %s", epicgames.JoinErrors(offersResp.Errors))
+	}
+
+	now := time.Now()
+	var games []Game
+	for _, offer := range offersResp.Data.PrimeOffers {
+		start, end := parseEpicDate(offer.StartTime), parseEpicDate(offer.EndTime)
+		if !end.IsZero() && end.Before(now) {
+			continue
+		}
+
+		game := Game{
+			ID:          gameID(storePrimeGaming, offer.ID),
+			Title:       offer.Title,
+			Description: offer.Description,
+			URL:         "https://gaming.amazon.com/home",
+			Status:      "free",
+			Publisher:   offer.Game.Assets.Publisher,
+			OfferType:   "BASE_GAME",
+			OfferKind:   offerKindGiveaway,
+			Store:       storePrimeGaming,
+			namespace:   storePrimeGaming,
+			offerID:     offer.ID,
+		}
+		if start.After(now) {
+			if !includeUpcoming {
+				continue
+			}
+			game.Status = "coming soon"
+		}
+		for _, asset := range offer.Assets {
+			if asset.Location2x != "" && (game.ImageURL == "" || asset.Purpose == "HERO") {
+				game.ImageURL = asset.Location2x
+			}
+		}
+		setPromotionWindow(&game, start, end, timezone)
+		games = append(games, game)
+	}
+	return games, nil
+}
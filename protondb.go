@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Games are matched to their Steam app, which ProtonDB and the Steam Deck
+// compatibility reports are keyed by
+const (
+	protonDBSummaryURL   = "https://www.protondb.com/api/v1/reports/summaries/%s.json"
+	steamDeckReportURL   = "https://store.steampowered.com/saleaction/ajaxgetdeckappcompatibilityreport"
+	compatibilityRefresh = 7 * 24 * time.Hour // Ratings move with new Proton versions
+)
+
+// steamDeckCategories are the Steam Deck compatibility categories by the
+// number Steam reports them as
+var steamDeckCategories = map[int]string{
+	1: "unsupported",
+	2: "playable",
+	3: "verified",
+}
+
+// compatibility is a cached compatibility lookup. An empty AppID means no
+// Steam app matched the title.
+type compatibility struct {
+	AppID     string    `json:"app_id,omitempty"`
+	Tier      string    `json:"tier,omitempty"`       // ProtonDB tier, e.g. "platinum"
+	SteamDeck string    `json:"steam_deck,omitempty"` // "verified", "playable" or "unsupported"
+	Checked   time.Time `json:"checked"`
+}
+
+// ProtonDBClient adds Linux compatibility hints to games, caching them by
+// title
+type ProtonDBClient struct {
+	cache *titleCache[compatibility]
+}
+
+// NewProtonDBClient creates a client, loading the cache from path if it
+// exists. An empty path keeps the cache in memory only.
+func NewProtonDBClient(path string) *ProtonDBClient {
+	return &ProtonDBClient{cache: newTitleCache[compatibility]("ProtonDB", path)}
+}
+
+// Name implements Enricher
+func (c *ProtonDBClient) Name() string {
+	return "ProtonDB"
+}
+
+// Enrich sets the ProtonDB tier and Steam Deck compatibility of the PC games
+// that are also sold on Steam. Failed lookups are logged and leave the game
+// without a hint.
+func (c *ProtonDBClient) Enrich(ctx context.Context, games []Game) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	changed := false
+	for i := range games {
+		key := titleKey(games[i].Title)
+		if key == "" || isMysteryGame(games[i]) || !containsFold(games[i].Platforms, "PC") {
+			continue
+		}
+
+		cached, ok := c.cache.entries[key]
+		if !ok || time.Since(cached.Checked) > compatibilityRefresh {
+			appID := ""
+			if gameStore(games[i]) == storeSteam {
+				appID = games[i].offerID
+			}
+			result, err := c.lookup(ctx, games[i].Title, appID)
+			if err != nil {
+				log.Printf("Warning: Error looking up compatibility of %s: %v", games[i].Title, err)
+				if !ok {
+					continue
+				}
+			} else {
+				cached = result
+				c.cache.entries[key] = result
+				changed = true
+			}
+		}
+
+		games[i].ProtonDBTier = cached.Tier
+		games[i].SteamDeck = cached.SteamDeck
+	}
+
+	if changed {
+		if err := c.cache.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// lookup finds the Steam app of the title, unless appID is known, and fetches
+// its ProtonDB summary and Steam Deck report
+func (c *ProtonDBClient) lookup(ctx context.Context, title, appID string) (*compatibility, error) {
+	result := &compatibility{Checked: time.Now()}
+
+	if appID == "" {
+		var err error
+		if appID, err = findSteamApp(ctx, title); err != nil {
+			return nil, err
+		}
+		if appID == "" {
+			return result, nil
+		}
+	}
+	result.AppID = appID
+
+	// ProtonDB answers 404 for apps without reports, which leaves the tier empty
+	var summary struct {
+		Tier string `json:"tier"`
+	}
+	if err := getJSON(ctx, fmt.Sprintf(protonDBSummaryURL, appID), &summary); err != nil && !strings.Contains(err.Error(), "bad status: 404") {
+		return nil, fmt.Errorf("error fetching ProtonDB summary: %v", err)
+	}
+	if summary.Tier != "pending" {
+		result.Tier = summary.Tier
+	}
+
+	var report struct {
+		Results struct {
+			ResolvedCategory int `json:"resolved_category"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, steamDeckReportURL+"?"+url.Values{"nAppID": {appID}}.Encode(), &report); err != nil {
+		return nil, fmt.Errorf("error fetching Steam Deck report: %v", err)
+	}
+	result.SteamDeck = steamDeckCategories[report.Results.ResolvedCategory]
+	return result, nil
+}
+
+// compatibilityText formats the Linux compatibility hint of a game for
+// notifications, e.g. "ProtonDB Platinum · Steam Deck Verified", empty when
+// unknown
+func compatibilityText(game Game) string {
+	var parts []string
+	if game.ProtonDBTier != "" {
+		parts = append(parts, "ProtonDB "+capitalize(game.ProtonDBTier))
+	}
+	if game.SteamDeck != "" {
+		parts = append(parts, "Steam Deck "+capitalize(game.SteamDeck))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
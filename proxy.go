@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the reverse proxies whose X-Forwarded-For and X-Real-IP
+// headers identify the client. Requests from anywhere else are identified by
+// their own address, so clients can't spoof the headers to dodge rate limits.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// trustAllProxies trusts every address, the behavior of TRUST_PROXY_HEADERS
+// without TRUSTED_PROXIES
+var trustAllProxies = &TrustedProxies{prefixes: []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/0"),
+	netip.MustParsePrefix("::/0"),
+}}
+
+// ParseTrustedProxies parses comma-separated CIDRs and addresses, like
+// 10.0.0.0/8,127.0.0.1. An empty list trusts no proxy.
+func ParseTrustedProxies(list string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+	for _, entry := range splitList(list) {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy %q, expected a CIDR like 10.0.0.0/8 or an address", entry)
+			}
+			addr = addr.Unmap()
+			entry = netip.PrefixFrom(addr, addr.BitLen()).String()
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q, expected a CIDR like 10.0.0.0/8 or an address", entry)
+		}
+		proxies.prefixes = append(proxies.prefixes, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// Enabled reports whether any proxy is trusted
+func (t *TrustedProxies) Enabled() bool {
+	return t != nil && len(t.prefixes) > 0
+}
+
+// Trusted reports whether addr belongs to a trusted proxy
+func (t *TrustedProxies) Trusted(addr netip.Addr) bool {
+	if t == nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClient returns the client of a request from a trusted proxy. The
+// X-Forwarded-For hops are read from the right, every proxy appending the
+// address it received the request from: the first untrusted hop is the
+// client, anything left of it may be forged.
+func (t *TrustedProxies) forwardedClient(r *http.Request, peer string) string {
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// A proxy wouldn't append this, stop at the last known hop
+				break
+			}
+			client = addr.Unmap().String()
+			if !t.Trusted(addr) {
+				break
+			}
+		}
+		return client
+	}
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return peer
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a per-client token bucket: each client may make Burst
+// requests at once, refilled at Rate requests per second
+type RateLimiter struct {
+	Rate  float64
+	Burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiterMaxClients bounds the tracked clients, full buckets are dropped
+// once it's reached
+const rateLimiterMaxClients = 10000
+
+// parseRateLimit parses limits such as "60/min", "5/hour" or "10/s". An empty
+// value, "0" or "off" returns nil, meaning no limit.
+func parseRateLimit(value string) (*RateLimiter, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" || value == "0" || value == "off" {
+		return nil, nil
+	}
+
+	countStr, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid rate limit %q, expected e.g. 60/min", value)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %q, expected a positive request count", value)
+	}
+
+	var period time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	case "d", "day":
+		period = 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("invalid rate limit %q, expected a unit of s, min, hour or day", value)
+	}
+
+	return &RateLimiter{
+		Rate:    float64(count) / period.Seconds(),
+		Burst:   float64(count),
+		buckets: make(map[string]*tokenBucket),
+	}, nil
+}
+
+// Allow takes a token for the client, returning how long to wait when the
+// client is out of tokens
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= rateLimiterMaxClients {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.Burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.Burst, b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+}
+
+// prune drops the buckets that have refilled completely, the caller must hold the lock
+func (l *RateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= l.Burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// rateLimitHandler applies api to the API endpoints and additionally notify to
+// the requests triggering notifications or fetching from Epic. Either limiter
+// may be nil.
+func rateLimitHandler(next http.Handler, api, notify *RateLimiter, proxies *TrustedProxies) http.Handler {
+	if api == nil && notify == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var limiters []*RateLimiter
+		if api != nil && isAPIPath(r.URL.Path) {
+			limiters = append(limiters, api)
+		}
+		if notify != nil && isNotifyRequest(r) {
+			limiters = append(limiters, notify)
+		}
+
+		client := clientIP(r, proxies)
+		for _, limiter := range limiters {
+			if ok, wait := limiter.Allow(client); !ok {
+				retryAfter := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(errorPayload(w, errorCodeRateLimited, fmt.Sprintf("Rate limit exceeded, retry in %d seconds", retryAfter)))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAPIPath reports whether the path is an API endpoint, versioned or legacy,
+// or one of the images and feeds built from the games
+func isAPIPath(path string) bool {
+	switch path {
+	case "/notify", "/badge.svg", "/calendar.ics":
+		return true
+	}
+	return strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/og/")
+}
+
+// isNotifyRequest reports whether the request posts to the channels, with
+// /notify or ?notify=true, or bypasses the cache with ?refresh=true
+func isNotifyRequest(r *http.Request) bool {
+	if r.URL.Path == "/notify" || r.URL.Path == "/v1/notify" {
+		return true
+	}
+	query := r.URL.Query()
+	for _, name := range []string{"notify", "refresh"} {
+		if value, _ := strconv.ParseBool(query.Get(name)); value {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP identifies the client, from X-Forwarded-For or X-Real-IP when the
+// request comes from a trusted reverse proxy
+func clientIP(r *http.Request, proxies *TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !proxies.Trusted(peer) {
+		return host
+	}
+	return proxies.forwardedClient(r, peer.Unmap().String())
+}
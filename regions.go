@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// regionCheckRefresh is how long the free games of a verified country are
+// reused, so API requests don't fetch every country each time
+const regionCheckRefresh = 15 * time.Minute
+
+// verifyCountries are the store countries every Epic giveaway is checked in,
+// set by -verify-countries
+var verifyCountries []string
+
+// regionFreeEntry holds the free games of a country, locked while they're
+// fetched so concurrent requests fetch a country once
+type regionFreeEntry struct {
+	mu      sync.Mutex
+	ids     map[string]bool // By game ID
+	fetched time.Time
+}
+
+var (
+	regionFreeMu      sync.Mutex
+	regionFreeEntries = map[string]*regionFreeEntry{} // By country and add-ons
+)
+
+// verifyRegionalAvailability checks the Epic giveaways against the store of
+// every country in verifyCountries, and sets the countries they're free in
+// and those they aren't. Countries that fail to load are left out of both.
+func verifyRegionalAvailability(ctx context.Context, games []Game, countryCode, locale string, withAddons bool) {
+	// The countries are fetched at once, and applied in their configured order
+	fetches := make([]func(context.Context) (map[string]bool, error), len(verifyCountries))
+	for i, country := range verifyCountries {
+		fetches[i] = func(ctx context.Context) (map[string]bool, error) {
+			if strings.EqualFold(country, countryCode) {
+				// The games were fetched from this country's store
+				return nil, nil
+			}
+			return lookupRegionFreeIDs(ctx, country, locale, withAddons)
+		}
+	}
+	results, errs := fetchConcurrently(ctx, fetches)
+
+	for i, country := range verifyCountries {
+		free := results[i]
+		if errs[i] != nil {
+			log.Printf("Warning: Error verifying availability in %s: %v", country, errs[i])
+			continue
+		}
+
+		for i := range games {
+			if gameStore(games[i]) != storeEpic || games[i].ID == "" || isMysteryGame(games[i]) {
+				continue
+			}
+			if free == nil || free[games[i].ID] {
+				games[i].AvailableIn = append(games[i].AvailableIn, country)
+			} else {
+				games[i].UnavailableIn = append(games[i].UnavailableIn, country)
+			}
+		}
+	}
+}
+
+// lookupRegionFreeIDs returns the IDs of the games free or upcoming in a
+// country's store, fetched at most every regionCheckRefresh
+func lookupRegionFreeIDs(ctx context.Context, country, locale string, withAddons bool) (map[string]bool, error) {
+	key := fmt.Sprintf("%s|%t", country, withAddons)
+	regionFreeMu.Lock()
+	entry, ok := regionFreeEntries[key]
+	if !ok {
+		entry = &regionFreeEntry{}
+		regionFreeEntries[key] = entry
+	}
+	regionFreeMu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if time.Since(entry.fetched) < regionCheckRefresh {
+		return entry.ids, nil
+	}
+
+	resp, _, err := fetchCatalog(ctx, country, locale, withAddons)
+	if err != nil {
+		return nil, err
+	}
+
+	free := map[string]bool{}
+	for _, element := range resp.Data.Catalog.SearchStore.Elements {
+		for _, groups := range [][]PromotionalOfferGroup{element.Promotions.PromotionalOffers, element.Promotions.UpcomingPromotionalOffers} {
+			for _, group := range groups {
+				for _, promo := range group.PromotionalOffers {
+					if promo.DiscountSetting.DiscountPercentage == 100 {
+						free[gameID(element.Namespace, element.ID)] = true
+					}
+				}
+			}
+		}
+	}
+	entry.ids = free
+	entry.fetched = time.Now()
+	return free, nil
+}
+
+// parseCountries parses a comma-separated list of two-letter country codes
+func parseCountries(value string) ([]string, error) {
+	var countries []string
+	for _, country := range strings.Split(value, ",") {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if country == "" {
+			continue
+		}
+		if err := validateCountry(country); err != nil {
+			return nil, err
+		}
+		countries = append(countries, country)
+	}
+	return countries, nil
+}
+
+// validateCountry rejects anything but two-letter country codes
+func validateCountry(country string) error {
+	if len(country) != 2 {
+		return fmt.Errorf("invalid country code %q", country)
+	}
+	for _, r := range strings.ToUpper(country) {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("invalid country code %q", country)
+		}
+	}
+	return nil
+}
+
+// withRegionWarnings returns copies of the games that are known not to be
+// free in region with a warning for notifications
+func withRegionWarnings(games []Game, region string) []Game {
+	if region == "" {
+		return games
+	}
+	warned := make([]Game, len(games))
+	for i, game := range games {
+		if containsFold(game.UnavailableIn, region) {
+			game.regionWarning = fmt.Sprintf("Not available in %s", strings.ToUpper(region))
+		}
+		warned[i] = game
+	}
+	return warned
+}
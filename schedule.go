@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// Modes of the scheduled free games check
+const (
+	scheduleModeCron  = "cron"  // On CRON_SCHEDULE
+	scheduleModeSmart = "smart" // Shortly after the next promotion starts or ends
+)
+
+// smartRetryWait is how long the smart scheduler waits after a failed check
+const smartRetryWait = 15 * time.Minute
+
+// SmartScheduler runs the free games check shortly after the next known
+// promotion boundary, the start or end date of a giveaway, instead of on a
+// fixed schedule. New giveaways are caught within minutes of Epic's rotation.
+type SmartScheduler struct {
+	check  func() ([]Game, error)
+	delay  time.Duration // Wait after a boundary, the store takes a moment to switch
+	jitter time.Duration // Random extra wait, so instances don't all fetch at once
+	poll   time.Duration // Longest wait, for giveaways without known dates
+}
+
+// NewSmartScheduler creates a scheduler running check around the promotion
+// boundaries of the games it returns
+func NewSmartScheduler(check func() ([]Game, error), delay, jitter, poll time.Duration) *SmartScheduler {
+	return &SmartScheduler{check: check, delay: delay, jitter: jitter, poll: poll}
+}
+
+// Run checks right away, then after every boundary, forever
+func (s *SmartScheduler) Run() {
+	for {
+		games, err := s.check()
+		wait := smartRetryWait
+		if err == nil {
+			wait = s.nextWait(games, time.Now())
+		}
+		wait = min(wait, s.poll)
+		if s.jitter > 0 {
+			wait += rand.N(s.jitter)
+		}
+		log.Printf("Next scheduled free games check in %v", wait.Round(time.Second))
+		time.Sleep(wait)
+	}
+}
+
+// nextWait returns how long to wait for the next boundary plus the delay, or
+// the poll interval when no boundary is known
+func (s *SmartScheduler) nextWait(games []Game, now time.Time) time.Duration {
+	boundary, ok := nextPromotionBoundary(games, now)
+	if !ok {
+		return s.poll
+	}
+	return boundary.Sub(now) + s.delay
+}
+
+// nextPromotionBoundary returns the earliest exact start or end date of the
+// games after now
+func nextPromotionBoundary(games []Game, now time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, game := range games {
+		if game.DatePrecision != "exact" {
+			continue
+		}
+		for _, boundary := range []time.Time{game.startTime, game.endTime} {
+			if boundary.After(now) && (next.IsZero() || boundary.Before(next)) {
+				next = boundary
+			}
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// CheckRetryPolicy says how a failed scheduled check is retried
+type CheckRetryPolicy struct {
+	Retries int           // Retries after the first attempt, 0 doesn't retry
+	Delay   time.Duration // Before the first retry, doubled for every next one
+}
+
+// retryCheck runs check until it succeeds or the retries are used up, and
+// alerts ops once every attempt failed
+func retryCheck(check func() ([]Game, error), policy CheckRetryPolicy, ops *OpsAlerter) ([]Game, error) {
+	delay := policy.Delay
+	for attempt := 1; ; attempt++ {
+		games, err := check()
+		if err == nil {
+			return games, nil
+		}
+		if attempt > policy.Retries {
+			ops.Alert(fmt.Sprintf("Scheduled free games check failed %d times: %v", attempt, err))
+			return nil, err
+		}
+		log.Printf("Warning: Scheduled check failed, retrying in %v", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// sleepJitter waits a random duration below jitter
+func sleepJitter(jitter time.Duration) {
+	if jitter <= 0 {
+		return
+	}
+	wait := rand.N(jitter)
+	log.Printf("Waiting %v before the scheduled check", wait.Round(time.Second))
+	time.Sleep(wait)
+}
+
+// catchUpCheck runs check right away when the cron schedule had a run since
+// the last successful one, which a restart around the weekly rotation would
+// otherwise skip
+func catchUpCheck(schedule string, check func() ([]Game, error)) {
+	sched, err := cronParser.Parse(schedule)
+	if err != nil {
+		return
+	}
+	last, ok := lastSuccessfulRun()
+	if !ok {
+		log.Println("No successful scheduled check recorded yet, not catching up")
+		return
+	}
+	if missed := sched.Next(last); missed.Before(time.Now()) {
+		log.Printf("Missed the scheduled check of %s, catching up", missed.Format(time.RFC3339))
+		check()
+	}
+}
+
+// validateScheduleMode rejects unknown scheduling modes
+func validateScheduleMode(mode string) error {
+	if mode != scheduleModeCron && mode != scheduleModeSmart {
+		return fmt.Errorf("unknown schedule mode %q, expected cron or smart", mode)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"epic-games-api/storage"
+)
+
+// stateDB persists the seen games, deliveries, history and push
+// subscriptions instead of their JSON files, set in main when -database-url
+// is set
+var stateDB storage.Store
+
+// SeenTracker remembers which games have already been announced on a channel
+// so repeated cron runs don't post the same giveaway over and over. The state
+// is persisted in the database, or as JSON when a path or the shared state
+// file is set, so it survives restarts.
+type SeenTracker struct {
+	mu      sync.Mutex
+	channel string // Key of the channel in the database, empty to not use it
+	path    string
+	file    *StateFile // Shared state file, where the channel is fileKey
+	fileKey string
+	seen    map[string]time.Time
+}
+
+// NewSeenTracker creates a tracker for a channel, loading previously seen
+// games from the database if one is open, or else from path if it exists, or
+// else from the shared state file. An empty channel and path keep the state
+// in memory only.
+func NewSeenTracker(channel, path string) *SeenTracker {
+	t := &SeenTracker{
+		path: path,
+		seen: make(map[string]time.Time),
+	}
+
+	if stateDB != nil && channel != "" {
+		t.channel = channel
+		seen, err := stateDB.SeenOffers(channel)
+		if err != nil {
+			log.Printf("Warning: Error loading seen games of %s: %v", channel, err)
+			return t
+		}
+		t.seen = seen
+		return t
+	}
+	if path == "" {
+		if stateFile != nil && channel != "" {
+			t.file, t.fileKey = stateFile, channel
+			t.seen = stateFile.Seen(channel)
+		}
+		return t
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Error reading seen games file %s: %v", path, err)
+		}
+		return t
+	}
+	if err := json.Unmarshal(data, &t.seen); err != nil {
+		log.Printf("Warning: Error parsing seen games file %s: %v", path, err)
+	}
+	return t
+}
+
+// gameKey identifies a single giveaway of a game: the offer plus its promotion
+// window, so the same game given away again later is announced again. Upcoming
+// and mystery games get their own key, so a game is announced again when it
+// goes live or is revealed.
+func gameKey(game Game) string {
+	offer := game.Title
+	if game.namespace != "" || game.offerID != "" {
+		offer = game.namespace + ":" + game.offerID
+	}
+	switch game.Status {
+	case "coming soon":
+		offer += "|upcoming"
+	case "mystery":
+		// Announced again once revealed
+		offer += "|mystery"
+	}
+
+	// Estimated windows move with every fetch and can't identify a giveaway
+	if game.DatePrecision != "exact" {
+		return offer
+	}
+	return fmt.Sprintf("%s|%s|%s", offer, game.startTime.UTC().Format(time.RFC3339), game.endTime.UTC().Format(time.RFC3339))
+}
+
+// Unseen returns the games that haven't been marked as seen yet
+func (t *SeenTracker) Unseen(games []Game) []Game {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var unseen []Game
+	for _, game := range games {
+		if _, ok := t.seen[gameKey(game)]; !ok {
+			unseen = append(unseen, game)
+		}
+	}
+	return unseen
+}
+
+// UnseenBy returns the games that haven't been marked as seen for one
+// recipient of the channel, nor for the whole channel
+func (t *SeenTracker) UnseenBy(recipient string, games []Game) []Game {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var unseen []Game
+	for _, game := range games {
+		_, channelSeen := t.seen[gameKey(game)]
+		_, recipientSeen := t.seen[recipientKey(recipient, game)]
+		if !channelSeen && !recipientSeen {
+			unseen = append(unseen, game)
+		}
+	}
+	return unseen
+}
+
+// MarkSeen records the games as announced and persists the state
+func (t *SeenTracker) MarkSeen(games ...Game) error {
+	keys := make([]string, 0, len(games))
+	for _, game := range games {
+		keys = append(keys, gameKey(game))
+	}
+	return t.markKeys(keys)
+}
+
+// MarkSeenBy records the games as announced to one recipient of the channel
+// and persists the state
+func (t *SeenTracker) MarkSeenBy(recipient string, games ...Game) error {
+	keys := make([]string, 0, len(games))
+	for _, game := range games {
+		keys = append(keys, recipientKey(recipient, game))
+	}
+	return t.markKeys(keys)
+}
+
+// MarkSeenByAll records the games every recipient got as announced on the
+// whole channel
+func (t *SeenTracker) MarkSeenByAll(recipients []string, games []Game) error {
+	var everyone []Game
+	for _, game := range t.Unseen(games) {
+		received := true
+		for _, recipient := range recipients {
+			if len(t.UnseenBy(recipient, []Game{game})) > 0 {
+				received = false
+				break
+			}
+		}
+		if received {
+			everyone = append(everyone, game)
+		}
+	}
+	if len(everyone) == 0 {
+		return nil
+	}
+	return t.MarkSeen(everyone...)
+}
+
+// markKeys records the game keys as announced and persists the state
+func (t *SeenTracker) markKeys(keys []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range keys {
+		t.seen[key] = now
+	}
+
+	if t.channel != "" {
+		return stateDB.MarkSeen(t.channel, keys, now)
+	}
+	return t.saveFile()
+}
+
+// recipientKey identifies a giveaway announced to one recipient of a channel
+func recipientKey(recipient string, game Game) string {
+	return recipient + ">" + gameKey(game)
+}
+
+// Seen returns when each game was announced, by game key
+func (t *SeenTracker) Seen() map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.seen)
+}
+
+// Import merges games announced elsewhere, keeping the earlier time of the
+// games announced on both, and persists the state
+func (t *SeenTracker) Import(seen map[string]time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, seenAt := range seen {
+		if current, ok := t.seen[key]; ok && current.Before(seenAt) {
+			continue
+		}
+		t.seen[key] = seenAt
+		if t.channel != "" {
+			if err := stateDB.MarkSeen(t.channel, []string{key}, seenAt); err != nil {
+				return err
+			}
+		}
+	}
+	if t.channel != "" {
+		return nil
+	}
+	return t.saveFile()
+}
+
+// saveFile writes the state to the JSON file or the shared state file, if
+// set. The caller must hold the lock.
+func (t *SeenTracker) saveFile() error {
+	if t.file != nil {
+		return t.file.SetSeen(t.fileKey, t.seen)
+	}
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.seen, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling seen games: %v", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing seen games file: %v", err)
+	}
+	return nil
+}
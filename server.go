@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"slices"
+)
+
+// ServerOptions are the settings and dependencies the HTTP handlers and the
+// one-shot commands share, built once in main. New settings are added here
+// instead of to the parameters of every handler.
+type ServerOptions struct {
+	Country  string // Store country of requests that don't pick one
+	Locale   string
+	Timezone string // Dates are formatted in it
+
+	Notifiers []Notifier    // Notified by /v1/notify and /v1/free-games?notify=true
+	Targets   *AdminTargets // Added from /admin, notified with Notifiers
+	History   *HistoryStore
+
+	// Games returns the snapshot of the free games of a request, fetching it
+	// when refresh is set. It's cachedFreeGames when nil, tests swap in a
+	// fake.
+	Games func(ctx context.Context, request freeGamesRequest, refresh bool) (*freeGamesResult, bool, error)
+}
+
+// notifiers returns the configured channels and the enabled targets of
+// /admin without a schedule of their own
+func (o ServerOptions) notifiers() []Notifier {
+	return append(slices.Clip(o.Notifiers), o.Targets.Notifiers()...)
+}
+
+// request describes a fetch of the free games in the configured store
+func (o ServerOptions) request(includeUpcoming, withAddons bool) freeGamesRequest {
+	return freeGamesRequest{o.Country, o.Locale, o.Timezone, includeUpcoming, withAddons}
+}
+
+// cachedGames returns the snapshot of the free games of a request
+func (o ServerOptions) cachedGames(ctx context.Context, request freeGamesRequest, refresh bool) (*freeGamesResult, bool, error) {
+	if o.Games != nil {
+		return o.Games(ctx, request, refresh)
+	}
+	return cachedFreeGames(ctx, request, refresh)
+}
+
+// freeGames returns the free games from the cache, which keeps serving the
+// last fetched ones while Epic is unavailable
+func (o ServerOptions) freeGames(ctx context.Context, includeUpcoming bool) ([]Game, error) {
+	result, _, err := o.cachedGames(ctx, o.request(includeUpcoming, includeAddons), false)
+	if err != nil {
+		return nil, err
+	}
+	return result.games, nil
+}
+
+// latestGames bypasses the cache, for notifications that must not miss a
+// giveaway that just started. The cache is updated with the games.
+func (o ServerOptions) latestGames(ctx context.Context, includeUpcoming bool) ([]Game, error) {
+	result, _, err := o.cachedGames(ctx, o.request(includeUpcoming, includeAddons), true)
+	if err != nil {
+		return nil, err
+	}
+	if result.stale() {
+		return nil, result.refreshErr
+	}
+	return result.games, nil
+}
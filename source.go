@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"epic-games-api/pkg/epicgames"
+)
+
+// Data sources of the free games, selected with -data-source
+const (
+	sourceAuto       = "auto"       // freeGamesPromotions, falling back to GraphQL
+	sourcePromotions = "promotions" // Epic's store-content freeGamesPromotions endpoint
+	sourceGraphQL    = "graphql"    // The GraphQL searchStore query
+)
+
+// dataSource selects where free games are fetched from, set by -data-source
+var dataSource = sourceAuto
+
+// Retries of the requests fetching the free games from Epic, set by
+// -epic-retries and -epic-retry-delay
+var (
+	epicRetries    = 2
+	epicRetryDelay = time.Second
+)
+
+// fetchTimeout bounds fetching the free games from Epic and the other stores,
+// set by -fetch-timeout
+var fetchTimeout = time.Minute
+
+// epicMaxRetryAfter is the longest Retry-After waited for, the fetch fails
+// when Epic asks to wait longer
+const epicMaxRetryAfter = time.Minute
+
+// validateSource rejects unknown data sources
+func validateSource(source string) error {
+	switch source {
+	case sourceAuto, sourcePromotions, sourceGraphQL:
+		return nil
+	}
+	return fmt.Errorf("unknown source %q, expected auto, promotions or graphql", source)
+}
+
+// fetchCatalog fetches the free games from the configured data source. In
+// auto mode the lighter freeGamesPromotions endpoint is tried first, falling
+// back to GraphQL when it fails. The source that answered is returned.
+func fetchCatalog(ctx context.Context, countryCode, locale string, withAddons bool) (*GraphQLResponse, string, error) {
+	switch dataSource {
+	case sourcePromotions:
+		resp, err := fetchPromotionsCatalog(ctx, countryCode, locale, withAddons)
+		return resp, sourcePromotions, err
+	case sourceGraphQL:
+		resp, err := fetchGraphQLCatalog(ctx, countryCode, locale, withAddons)
+		return resp, sourceGraphQL, err
+	}
+
+	resp, err := fetchPromotionsCatalog(ctx, countryCode, locale, withAddons)
+	if err == nil {
+		return resp, sourcePromotions, nil
+	}
+	log.Printf("Warning: Error fetching free games promotions, falling back to GraphQL: %v", err)
+	resp, err = fetchGraphQLCatalog(ctx, countryCode, locale, withAddons)
+	return resp, sourceGraphQL, err
+}
+
+// fetchPromotionsCatalog fetches the free games listed by the
+// freeGamesPromotions endpoint. Its response has the shape of the GraphQL
+// searchStore query, without the category filter, so add-ons are dropped
+// here unless withAddons is set.
+func fetchPromotionsCatalog(ctx context.Context, countryCode, locale string, withAddons bool) (*GraphQLResponse, error) {
+	doer := &epicDoer{breaker: promotionsBreaker}
+	catalog, err := (&epicgames.Client{HTTPClient: doer}).Promotions(ctx, countryCode, locale)
+	if err != nil {
+		return nil, err
+	}
+	if !withAddons {
+		catalog.DropAddons()
+	}
+	return newGraphQLResponse(catalog, doer.body)
+}
+
+// fetchGraphQLCatalog searches the store catalog for free games with Epic's
+// GraphQL API
+func fetchGraphQLCatalog(ctx context.Context, countryCode, locale string, withAddons bool) (*GraphQLResponse, error) {
+	doer := &epicDoer{breaker: graphQLBreaker}
+	catalog, err := (&epicgames.Client{HTTPClient: doer}).Search(ctx, countryCode, locale, withAddons)
+	if err != nil {
+		return nil, err
+	}
+	return newGraphQLResponse(catalog, doer.body)
+}
+
+// epicDoer sends the requests of an epicgames.Client with the retries and
+// the circuit breaker of doEpicRequest, and keeps the body of the response
+// for the raw payload archive
+type epicDoer struct {
+	breaker *CircuitBreaker
+	body    []byte
+}
+
+// Do sends the request, building a copy of it for every attempt
+func (d *epicDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := doEpicRequest(req.Context(), d.breaker, func() (*http.Request, error) {
+		attempt := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		return attempt, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if d.body, err = io.ReadAll(resp.Body); err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(d.body))
+	return resp, nil
+}
+
+// doEpicRequest sends the request built by newRequest, which is called again
+// for every attempt. Network errors, rate limited (429) and failed (5xx)
+// responses are retried epicRetries times, backing off exponentially with
+// jitter and honoring Retry-After. The returned response has a 200 status.
+// Requests fail right away while the endpoint's breaker is open.
+func doEpicRequest(ctx context.Context, breaker *CircuitBreaker, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+	resp, unavailable, err := sendEpicRequest(ctx, newRequest)
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The caller gave up, that says nothing about Epic
+		breaker.Abort()
+	case unavailable:
+		breaker.Record(err)
+	default:
+		// Epic answered, even if it rejected the request
+		breaker.Record(nil)
+	}
+	return resp, err
+}
+
+// sendEpicRequest sends the request with retries, and reports whether it
+// failed because Epic is down or rate limiting
+func sendEpicRequest(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, bool, error) {
+	client := newHTTPClient(30 * time.Second)
+	backoff := epicRetryDelay
+
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, false, fmt.Errorf("error creating request: %v", err)
+		}
+
+		var retryAfter time.Duration
+		resp, err := client.Do(req)
+		if err != nil {
+			err = fmt.Errorf("error sending request: %v", err)
+			if ctx.Err() != nil {
+				return nil, true, err
+			}
+		} else {
+			if resp.StatusCode == http.StatusOK {
+				return resp, false, nil
+			}
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			err = fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return nil, false, err
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		if attempt >= epicRetries {
+			return nil, true, err
+		}
+
+		// Equal jitter: half the backoff, plus up to the other half
+		wait := backoff
+		if backoff > 1 {
+			wait = backoff/2 + rand.N(backoff/2)
+		}
+		if retryAfter > epicMaxRetryAfter {
+			return nil, true, fmt.Errorf("%v (retry after %v)", err, retryAfter)
+		}
+		wait = max(wait, retryAfter)
+		log.Printf("Warning: Epic request failed, retrying in %v: %v", wait.Round(time.Millisecond), err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, true, err
+		}
+		backoff *= 2
+	}
+}
+
+// parseRetryAfter reads a Retry-After header, in seconds or as an HTTP date,
+// 0 when it's missing or invalid
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// withStageTimeout bounds a stage of a fetch or notification with its
+// configured timeout, which doesn't apply when it's 0
+func withStageTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
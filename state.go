@@ -0,0 +1,274 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	webpush "github.com/SherClockHolmes/webpush-go"
+)
+
+// stateSnapshotVersion is the version of the export format, bumped when an
+// older version can't read it anymore
+const stateSnapshotVersion = 1
+
+// Formats of exported state
+const (
+	stateFormatJSON = "json"
+	stateFormatTar  = "tar"
+)
+
+// StateSnapshot is the persisted state of an instance: the history archive,
+// the games announced on every channel and the browser push subscriptions.
+// Importing it on another host carries the dedup state over, so nothing is
+// announced twice.
+type StateSnapshot struct {
+	Version           int                             `json:"version"`
+	ExportedAt        time.Time                       `json:"exported_at"`
+	History           map[string]*HistoryEntry        `json:"history"`
+	Seen              map[string]map[string]time.Time `json:"seen"` // By channel, then game key
+	PushSubscriptions []webpush.Subscription          `json:"push_subscriptions"`
+}
+
+// stateStores are the stores a snapshot is taken from and imported into
+type stateStores struct {
+	history *HistoryStore
+	push    *PushSubscriptionStore
+	seen    map[string]*SeenTracker // By channel
+}
+
+// snapshot takes a snapshot of the stores
+func (s *stateStores) snapshot() *StateSnapshot {
+	snapshot := &StateSnapshot{
+		Version:           stateSnapshotVersion,
+		ExportedAt:        time.Now().UTC(),
+		History:           s.history.Entries(),
+		Seen:              map[string]map[string]time.Time{},
+		PushSubscriptions: s.push.All(),
+	}
+	for channel, tracker := range s.seen {
+		if seen := tracker.Seen(); len(seen) > 0 {
+			snapshot.Seen[channel] = seen
+		}
+	}
+	sort.Slice(snapshot.PushSubscriptions, func(i, j int) bool {
+		return snapshot.PushSubscriptions[i].Endpoint < snapshot.PushSubscriptions[j].Endpoint
+	})
+	return snapshot
+}
+
+// restore merges a snapshot into the stores. Existing state is kept, the
+// channels this instance doesn't know are skipped with a warning.
+func (s *stateStores) restore(snapshot *StateSnapshot) error {
+	if snapshot.Version > stateSnapshotVersion {
+		return fmt.Errorf("snapshot version %d is newer than the supported version %d", snapshot.Version, stateSnapshotVersion)
+	}
+
+	added, err := s.history.Import(snapshot.History)
+	if err != nil {
+		return fmt.Errorf("error importing history: %v", err)
+	}
+	log.Printf("Imported %d of %d history entries", added, len(snapshot.History))
+
+	for channel, seen := range snapshot.Seen {
+		tracker, ok := s.seen[channel]
+		if !ok {
+			log.Printf("Warning: Skipping the seen games of unknown channel %s", channel)
+			continue
+		}
+		if err := tracker.Import(seen); err != nil {
+			return fmt.Errorf("error importing seen games of %s: %v", channel, err)
+		}
+		log.Printf("Imported %d seen games of %s", len(seen), channel)
+	}
+
+	for _, sub := range snapshot.PushSubscriptions {
+		if err := s.push.Add(sub); err != nil {
+			return fmt.Errorf("error importing push subscription: %v", err)
+		}
+	}
+	log.Printf("Imported %d push subscriptions", len(snapshot.PushSubscriptions))
+	return nil
+}
+
+// writeStateSnapshot encodes a snapshot as a JSON document, or as a tar
+// archive with a JSON file per part
+func writeStateSnapshot(w io.Writer, snapshot *StateSnapshot, format string) error {
+	if format != stateFormatTar {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshot)
+	}
+
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"manifest.json", map[string]interface{}{"version": snapshot.Version, "exported_at": snapshot.ExportedAt}},
+		{"history.json", snapshot.History},
+		{"push_subscriptions.json", snapshot.PushSubscriptions},
+	}
+	channels := make([]string, 0, len(snapshot.Seen))
+	for channel := range snapshot.Seen {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	for _, channel := range channels {
+		files = append(files, struct {
+			name string
+			v    interface{}
+		}{"seen/" + channel + ".json", snapshot.Seen[channel]})
+	}
+
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling %s: %v", file.name, err)
+		}
+		header := &tar.Header{Name: file.name, Mode: 0600, Size: int64(len(data)), ModTime: snapshot.ExportedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("error writing %s: %v", file.name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("error writing %s: %v", file.name, err)
+		}
+	}
+	return tw.Close()
+}
+
+// readStateSnapshot decodes a snapshot written by writeStateSnapshot in
+// either format
+func readStateSnapshot(r io.Reader) (*StateSnapshot, error) {
+	br := bufio.NewReader(r)
+	start, _ := br.Peek(512)
+	if trimmed := bytes.TrimSpace(start); len(trimmed) > 0 && trimmed[0] == '{' {
+		var snapshot StateSnapshot
+		if err := json.NewDecoder(br).Decode(&snapshot); err != nil {
+			return nil, fmt.Errorf("error decoding snapshot: %v", err)
+		}
+		return &snapshot, nil
+	}
+
+	snapshot := &StateSnapshot{Seen: map[string]map[string]time.Time{}}
+	tr := tar.NewReader(br)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading snapshot archive: %v", err)
+		}
+
+		var v interface{}
+		switch name := path.Clean(header.Name); {
+		case name == "manifest.json":
+			v = snapshot
+		case name == "history.json":
+			v = &snapshot.History
+		case name == "push_subscriptions.json":
+			v = &snapshot.PushSubscriptions
+		case strings.HasPrefix(name, "seen/") && strings.HasSuffix(name, ".json"):
+			seen := map[string]time.Time{}
+			snapshot.Seen[strings.TrimSuffix(strings.TrimPrefix(name, "seen/"), ".json")] = seen
+			v = &seen
+		default:
+			continue
+		}
+		if err := json.NewDecoder(tr).Decode(v); err != nil {
+			return nil, fmt.Errorf("error decoding %s: %v", header.Name, err)
+		}
+	}
+	return snapshot, nil
+}
+
+// runStateCommand runs the export and import commands:
+//
+//	export [file]   writes a snapshot to file, a tar archive when it ends
+//	                in .tar, or JSON to standard output without a file
+//	import file     merges a snapshot into the configured state
+func runStateCommand(args []string, stores *stateStores) error {
+	command, file := args[0], ""
+	if len(args) > 1 {
+		file = args[1]
+	}
+
+	switch command {
+	case "export":
+		format := stateFormatJSON
+		if strings.HasSuffix(file, ".tar") {
+			format = stateFormatTar
+		}
+		if file == "" || file == "-" {
+			return writeStateSnapshot(os.Stdout, stores.snapshot(), format)
+		}
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %v", file, err)
+		}
+		if err := writeStateSnapshot(f, stores.snapshot(), format); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("error writing %s: %v", file, err)
+		}
+		log.Printf("State exported to %s", file)
+		return nil
+
+	case "import":
+		if file == "" {
+			return fmt.Errorf("usage: import <file>")
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %v", file, err)
+		}
+		defer f.Close()
+		snapshot, err := readStateSnapshot(f)
+		if err != nil {
+			return err
+		}
+		return stores.restore(snapshot)
+	}
+	return fmt.Errorf("unknown command %q, expected export or import", command)
+}
+
+// stateExportHandler serves GET /v1/admin/export?format=json|tar
+func stateExportHandler(w http.ResponseWriter, r *http.Request, stores *stateStores) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = stateFormatJSON
+	}
+	if format != stateFormatJSON && format != stateFormatTar {
+		httpError(w, fmt.Sprintf("Unknown format %q, expected json or tar", format), http.StatusBadRequest)
+		return
+	}
+
+	snapshot := stores.snapshot()
+	var buf bytes.Buffer
+	if err := writeStateSnapshot(&buf, snapshot, format); err != nil {
+		httpError(w, fmt.Sprintf("Error exporting state: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/json"
+	if format == stateFormatTar {
+		contentType = "application/x-tar"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="epic-games-state-%s.%s"`, snapshot.ExportedAt.Format("2006-01-02"), format))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"sync"
+	"time"
+)
+
+// stateFile keeps the games announced on every channel and the last
+// scheduled run in a single JSON file, set by -state-file when there's no
+// database
+var stateFile *StateFile
+
+// StateFile is a JSON document persisting the dedup state of minimal
+// deployments, that don't run a database or set a state file per channel
+type StateFile struct {
+	mu    sync.Mutex
+	path  string
+	state stateFileData
+}
+
+// stateFileData is the content of the state file
+type stateFileData struct {
+	Seen        map[string]map[string]time.Time `json:"seen"` // By channel, then game key
+	LastRun     *RunInfo                        `json:"last_run,omitempty"`
+	LastSuccess *time.Time                      `json:"last_success,omitempty"` // End of the last check without errors
+}
+
+// RunInfo describes a scheduled check
+type RunInfo struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Games      int       `json:"games"`
+	GameIDs    []string  `json:"game_ids,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// OpenStateFile loads the state file at path, starting empty when it doesn't
+// exist yet
+func OpenStateFile(path string) (*StateFile, error) {
+	f := &StateFile{
+		path:  path,
+		state: stateFileData{Seen: map[string]map[string]time.Time{}},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &f.state); err != nil {
+		return nil, fmt.Errorf("error parsing state file %s: %v", path, err)
+	}
+	if f.state.Seen == nil {
+		f.state.Seen = map[string]map[string]time.Time{}
+	}
+	return f, nil
+}
+
+// Seen returns the games announced on a channel, by game key
+func (f *StateFile) Seen(channel string) map[string]time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	seen := maps.Clone(f.state.Seen[channel])
+	if seen == nil {
+		seen = map[string]time.Time{}
+	}
+	return seen
+}
+
+// SetSeen replaces the games announced on a channel and saves the file
+func (f *StateFile) SetSeen(channel string, seen map[string]time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state.Seen[channel] = maps.Clone(seen)
+	return f.save()
+}
+
+// LastRun returns the last scheduled check, nil before the first one
+func (f *StateFile) LastRun() *RunInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state.LastRun == nil {
+		return nil
+	}
+	run := *f.state.LastRun
+	return &run
+}
+
+// LastSuccess returns when the last scheduled check without errors finished,
+// the zero time before the first one
+func (f *StateFile) LastSuccess() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state.LastSuccess == nil {
+		return time.Time{}
+	}
+	return *f.state.LastSuccess
+}
+
+// SetLastRun records a scheduled check and saves the file
+func (f *StateFile) SetLastRun(run RunInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state.LastRun = &run
+	if run.Error == "" {
+		f.state.LastSuccess = &run.FinishedAt
+	}
+	return f.save()
+}
+
+// save writes the state to a temporary file renamed over the state file, so
+// a crash never leaves it half written. The caller must hold the lock.
+func (f *StateFile) save() error {
+	data, err := json.MarshalIndent(f.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling state: %v", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing state file: %v", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("error writing state file: %v", err)
+	}
+	return nil
+}
+
+// Keys of the scheduled runs in the state database
+const (
+	metaLastRun     = "last_run"
+	metaLastSuccess = "last_success"
+)
+
+// recordRun saves a scheduled check to the state database or file, if
+// there's one
+func recordRun(startedAt time.Time, games []Game, err error) {
+	if stateDB == nil && stateFile == nil {
+		return
+	}
+	run := RunInfo{StartedAt: startedAt, FinishedAt: time.Now(), Games: len(games)}
+	for _, game := range games {
+		if game.ID != "" {
+			run.GameIDs = append(run.GameIDs, game.ID)
+		}
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	if stateFile != nil {
+		if err := stateFile.SetLastRun(run); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		return
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		log.Printf("Warning: Error marshaling run: %v", err)
+		return
+	}
+	if err := stateDB.SetMeta(metaLastRun, string(data)); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if run.Error == "" {
+		if err := stateDB.SetMeta(metaLastSuccess, run.FinishedAt.UTC().Format(time.RFC3339)); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// lastSuccessfulRun returns when the last scheduled check without errors
+// finished, false when it's unknown
+func lastSuccessfulRun() (time.Time, bool) {
+	if stateFile != nil {
+		last := stateFile.LastSuccess()
+		return last, !last.IsZero()
+	}
+	if stateDB == nil {
+		return time.Time{}, false
+	}
+	value, err := stateDB.Meta(metaLastSuccess)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return time.Time{}, false
+	}
+	last, err := time.Parse(time.RFC3339, value)
+	return last, err == nil
+}
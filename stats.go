@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsTopPublishers is the number of publishers listed in the statistics
+const statsTopPublishers = 5
+
+// GiveawayStats summarizes the archived giveaways of a store country
+type GiveawayStats struct {
+	Country       string `json:"country"`
+	Year          int    `json:"year"`
+	TotalGames    int    `json:"total_games"`     // All archived giveaways
+	GamesThisYear int    `json:"games_this_year"` // Giveaways that started in Year
+	// Sum of the regular prices of the games given away in Year, by currency.
+	// Games archived without a price aren't counted.
+	ValueSaved      map[string]float64 `json:"value_saved"`
+	AverageDuration string             `json:"average_duration,omitempty"` // e.g. "7 days", exact windows only
+	AverageHours    float64            `json:"average_duration_hours,omitempty"`
+	TopPublishers   []PublisherCount   `json:"top_publishers"`
+}
+
+// PublisherCount is the number of giveaways of a publisher
+type PublisherCount struct {
+	Publisher string `json:"publisher"`
+	Games     int    `json:"games"`
+}
+
+// StatsResponse is returned by the stats endpoint
+type StatsResponse struct {
+	Success       bool           `json:"success"`
+	SchemaVersion int            `json:"schema_version"`
+	Code          string         `json:"code,omitempty"` // Machine-readable error code, set on errors
+	Message       string         `json:"message,omitempty"`
+	RequestID     string         `json:"request_id,omitempty"` // Quoted in bug reports, set on errors
+	Data          *GiveawayStats `json:"data,omitempty"`
+}
+
+// Stats computes the statistics of the giveaways archived for country, with
+// the value saved counted for year
+func (h *HistoryStore) Stats(country string, year int) GiveawayStats {
+	stats := GiveawayStats{
+		Country:       strings.ToUpper(country),
+		Year:          year,
+		ValueSaved:    map[string]float64{},
+		TopPublishers: []PublisherCount{},
+	}
+
+	var totalDuration time.Duration
+	exact := 0
+	publishers := map[string]int{}
+	for _, entry := range h.Query(0, country, "") {
+		stats.TotalGames++
+		if entry.Publisher != "" {
+			publishers[entry.Publisher]++
+		}
+		if entry.DatePrecision == "exact" && entry.EndDate.After(entry.StartDate) {
+			totalDuration += entry.EndDate.Sub(entry.StartDate)
+			exact++
+		}
+		if entry.StartDate.Year() == year {
+			stats.GamesThisYear++
+			if entry.Currency != "" && entry.PriceValue > 0 {
+				stats.ValueSaved[entry.Currency] += entry.PriceValue
+			}
+		}
+	}
+
+	for currency, value := range stats.ValueSaved {
+		stats.ValueSaved[currency] = math.Round(value*100) / 100
+	}
+	if exact > 0 {
+		average := totalDuration / time.Duration(exact)
+		stats.AverageDuration = humanizeDuration(average)
+		stats.AverageHours = math.Round(average.Hours()*10) / 10
+	}
+
+	for publisher, games := range publishers {
+		stats.TopPublishers = append(stats.TopPublishers, PublisherCount{Publisher: publisher, Games: games})
+	}
+	sort.Slice(stats.TopPublishers, func(i, j int) bool {
+		a, b := stats.TopPublishers[i], stats.TopPublishers[j]
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		return a.Publisher < b.Publisher
+	})
+	if len(stats.TopPublishers) > statsTopPublishers {
+		stats.TopPublishers = stats.TopPublishers[:statsTopPublishers]
+	}
+	return stats
+}
+
+// statsHandler serves GET /v1/stats?country=US&year=2025, defaulting to the
+// configured country and the current year
+func statsHandler(w http.ResponseWriter, r *http.Request, opts ServerOptions) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	country := r.URL.Query().Get("country")
+	if country == "" {
+		country = opts.Country
+	}
+	year := time.Now().Year()
+	if value := r.URL.Query().Get("year"); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil {
+			w.WriteHeader(ErrBadParameter.Status)
+			json.NewEncoder(w).Encode(StatsResponse{SchemaVersion: apiSchemaVersion, Code: ErrBadParameter.Code, RequestID: requestID(w), Message: fmt.Sprintf("Invalid year %q", value)})
+			return
+		}
+	}
+
+	stats := opts.History.Stats(country, year)
+	jsonData, _ := json.MarshalIndent(StatsResponse{
+		Success:       true,
+		SchemaVersion: apiSchemaVersion,
+		Data:          &stats,
+	}, "", "  ")
+	writeConditional(w, r, jsonData, jsonData)
+}
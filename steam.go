@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Steam store APIs: the featured categories are behind the specials shown on
+// the front page, the store search behind the search box
+const (
+	steamFeaturedCategoriesURL = "https://store.steampowered.com/api/featuredcategories"
+	steamStoreSearchURL        = "https://store.steampowered.com/api/storesearch/"
+)
+
+// includeSteam adds Steam's free-to-keep promotions to every fetch, set by
+// -steam-giveaways
+var includeSteam bool
+
+// steamFeaturedCategoriesResponse is the part of the featured categories the
+// service uses
+type steamFeaturedCategoriesResponse struct {
+	Specials struct {
+		Items []struct {
+			ID                 int    `json:"id"`
+			Type               int    `json:"type"` // 0 for apps
+			Name               string `json:"name"`
+			DiscountPercent    int    `json:"discount_percent"`
+			OriginalPrice      int    `json:"original_price"` // In cents
+			FinalPrice         int    `json:"final_price"`
+			Currency           string `json:"currency"`
+			HeaderImage        string `json:"header_image"`
+			DiscountExpiration int64  `json:"discount_expiration"` // Unix time
+		} `json:"items"`
+	} `json:"specials"`
+}
+
+// fetchSteamGiveaways returns the limited-time free-to-keep promotions on
+// Steam, the specials discounted by 100%. Steam only publishes when they end.
+func fetchSteamGiveaways(ctx context.Context, countryCode, timezone string) ([]Game, error) {
+	query := url.Values{
+		"cc": {countryCode},
+		"l":  {"english"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", steamFeaturedCategoriesURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var featured steamFeaturedCategoriesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&featured); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	var games []Game
+	for _, item := range featured.Specials.Items {
+		if item.Type != 0 || item.DiscountPercent != 100 || item.FinalPrice != 0 {
+			continue
+		}
+
+		appID := strconv.Itoa(item.ID)
+		game := Game{
+			ID:            gameID(storeSteam, appID),
+			Title:         item.Name,
+			ImageURL:      item.HeaderImage,
+			URL:           "https://store.steampowered.com/app/" + appID + "/",
+			Status:        "free",
+			OriginalPrice: fmt.Sprintf("%.2f %s", float64(item.OriginalPrice)/100, item.Currency),
+			DiscountPrice: "0",
+			OfferType:     "BASE_GAME",
+			OfferKind:     offerKindGiveaway,
+			Store:         storeSteam,
+			namespace:     storeSteam,
+			offerID:       appID,
+			originalPrice: float64(item.OriginalPrice) / 100,
+			currency:      item.Currency,
+		}
+		var end time.Time
+		if item.DiscountExpiration > 0 {
+			end = time.Unix(item.DiscountExpiration, 0)
+		}
+		setPromotionWindow(&game, time.Time{}, end, timezone)
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// findSteamApp searches the Steam store for the app closest to a title sold
+// elsewhere, empty when none is close enough
+func findSteamApp(ctx context.Context, title string) (string, error) {
+	var search struct {
+		Items []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	query := url.Values{"term": {title}, "cc": {"US"}, "l": {"english"}}
+	if err := getJSON(ctx, steamStoreSearchURL+"?"+query.Encode(), &search); err != nil {
+		return "", fmt.Errorf("error searching Steam: %v", err)
+	}
+
+	appID := ""
+	best := minTitleSimilarity
+	for _, item := range search.Items {
+		if similarity := titleSimilarity(title, item.Name); similarity >= best {
+			best = similarity
+			appID = strconv.Itoa(item.ID)
+		}
+	}
+	return appID, nil
+}
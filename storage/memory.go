@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"maps"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxMemoryDeliveries bounds the delivery log kept in memory
+const maxMemoryDeliveries = 1000
+
+// MemoryStore keeps the state in memory, it's lost on restart
+type MemoryStore struct {
+	mu             sync.Mutex
+	seen           map[string]map[string]time.Time // By channel, then game key
+	deliveries     []Delivery                      // Oldest first
+	lastDeliveryID int64
+	history        map[string][]byte
+	push           map[string][]byte
+	rawOffers      map[string]RawOffer
+	meta           map[string]string
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		seen:      map[string]map[string]time.Time{},
+		history:   map[string][]byte{},
+		push:      map[string][]byte{},
+		rawOffers: map[string]RawOffer{},
+		meta:      map[string]string{},
+	}
+}
+
+// SeenOffers implements Store
+func (m *MemoryStore) SeenOffers(channel string) (map[string]time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := maps.Clone(m.seen[channel])
+	if seen == nil {
+		seen = map[string]time.Time{}
+	}
+	return seen, nil
+}
+
+// MarkSeen implements Store
+func (m *MemoryStore) MarkSeen(channel string, keys []string, seenAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[channel] == nil {
+		m.seen[channel] = map[string]time.Time{}
+	}
+	for _, key := range keys {
+		m.seen[channel][key] = seenAt
+	}
+	return nil
+}
+
+// RecordDelivery implements Store
+func (m *MemoryStore) RecordDelivery(delivery Delivery) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastDeliveryID++
+	delivery.ID = m.lastDeliveryID
+	m.deliveries = append(m.deliveries, delivery)
+	if len(m.deliveries) > maxMemoryDeliveries {
+		m.deliveries = m.deliveries[len(m.deliveries)-maxMemoryDeliveries:]
+	}
+	return delivery.ID, nil
+}
+
+// Deliveries implements Store
+func (m *MemoryStore) Deliveries(filter DeliveryFilter) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deliveries []Delivery
+	for i := len(m.deliveries) - 1; i >= 0; i-- {
+		if filter.matches(m.deliveries[i]) {
+			deliveries = append(deliveries, m.deliveries[i])
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].DeliveredAt.After(deliveries[j].DeliveredAt)
+	})
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, nil
+}
+
+// Delivery implements Store
+func (m *MemoryStore) Delivery(id int64) (*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, delivery := range m.deliveries {
+		if delivery.ID == id {
+			return &delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+// DeleteDeliveries implements Store
+func (m *MemoryStore) DeleteDeliveries(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.deliveries[:0]
+	for _, delivery := range m.deliveries {
+		if !delivery.DeliveredAt.Before(before) {
+			kept = append(kept, delivery)
+		}
+	}
+	deleted := len(m.deliveries) - len(kept)
+	m.deliveries = kept
+	return deleted, nil
+}
+
+// PutRawOffer implements Store
+func (m *MemoryStore) PutRawOffer(offer RawOffer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rawOffers[offer.OfferID] = offer
+	return nil
+}
+
+// RawOffer implements Store
+func (m *MemoryStore) RawOffer(offerID string) (*RawOffer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	offer, ok := m.rawOffers[offerID]
+	if !ok {
+		return nil, nil
+	}
+	return &offer, nil
+}
+
+// DeleteRawOffers implements Store
+func (m *MemoryStore) DeleteRawOffers(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := 0
+	for offerID, offer := range m.rawOffers {
+		if offer.FetchedAt.Before(before) {
+			delete(m.rawOffers, offerID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Meta implements Store
+func (m *MemoryStore) Meta(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.meta[key], nil
+}
+
+// SetMeta implements Store
+func (m *MemoryStore) SetMeta(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.meta[key] = value
+	return nil
+}
+
+// Compact implements Store, there's nothing to reclaim in memory
+func (m *MemoryStore) Compact() error {
+	return nil
+}
+
+// History implements Store
+func (m *MemoryStore) History() (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.history), nil
+}
+
+// ReplaceHistory implements Store
+func (m *MemoryStore) ReplaceHistory(entries map[string][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = maps.Clone(entries)
+	return nil
+}
+
+// PushSubscriptions implements Store
+func (m *MemoryStore) PushSubscriptions() (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.push), nil
+}
+
+// PutPushSubscription implements Store
+func (m *MemoryStore) PutPushSubscription(endpoint string, subscription []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.push[endpoint] = subscription
+	return nil
+}
+
+// DeletePushSubscription implements Store
+func (m *MemoryStore) DeletePushSubscription(endpoint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.push, endpoint)
+	return nil
+}
+
+// Close implements Store
+func (m *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,4 @@
+package storage
+
+// The PostgreSQL driver of postgres:// URLs
+import _ "github.com/jackc/pgx/v5/stdlib"
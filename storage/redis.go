@@ -0,0 +1,555 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Keys of the Redis store, under the prefix set by the key_prefix URL
+// parameter
+const (
+	redisSeenKey       = "seen:" // Hash per channel, game key to seen time
+	redisDeliveriesKey = "deliveries"
+	redisDeliveryIDKey = "deliveries:id"
+	redisHistoryKey    = "history"
+	redisPushKey       = "push_subscriptions"
+	redisRawOffersKey  = "raw_offers"
+	redisMetaKey       = "meta"
+	redisMaxDeliveries = 10000
+	redisTimeout       = 10 * time.Second
+)
+
+// errRedisNil is the reply to a missing key
+var errRedisNil = errors.New("redis: nil")
+
+// RedisStore keeps the state in Redis. It speaks the Redis protocol itself
+// over a single connection, so no client library is needed.
+type RedisStore struct {
+	addr     string
+	tls      bool
+	username string
+	password string
+	db       int
+	prefix   string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// openRedis connects to the Redis server of a redis:// or rediss:// URL
+func openRedis(u *url.URL) (*RedisStore, error) {
+	r := &RedisStore{
+		addr:   u.Host,
+		tls:    u.Scheme == "rediss",
+		prefix: u.Query().Get("key_prefix"),
+	}
+	if r.prefix == "" {
+		r.prefix = "epic-games:"
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		db, err := strconv.Atoi(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", path)
+		}
+		r.db = db
+	}
+
+	if _, err := r.do("PING"); err != nil {
+		return nil, fmt.Errorf("error connecting to Redis: %v", err)
+	}
+	return r, nil
+}
+
+// SeenOffers implements Store
+func (r *RedisStore) SeenOffers(channel string) (map[string]time.Time, error) {
+	fields, err := r.hashAll(redisSeenKey + channel)
+	if err != nil {
+		return nil, fmt.Errorf("error reading seen offers: %v", err)
+	}
+	seen := map[string]time.Time{}
+	for key, value := range fields {
+		seenAt, err := time.Parse(time.RFC3339Nano, string(value))
+		if err != nil {
+			continue
+		}
+		seen[key] = seenAt
+	}
+	return seen, nil
+}
+
+// MarkSeen implements Store
+func (r *RedisStore) MarkSeen(channel string, keys []string, seenAt time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"HSET", r.prefix + redisSeenKey + channel}
+	for _, key := range keys {
+		args = append(args, key, seenAt.UTC().Format(time.RFC3339Nano))
+	}
+	if _, err := r.do(args...); err != nil {
+		return fmt.Errorf("error marking offers seen: %v", err)
+	}
+	return nil
+}
+
+// redisDelivery is a delivery as stored in the Redis list
+type redisDelivery struct {
+	ID          int64          `json:"id"`
+	Channel     string         `json:"channel"`
+	Games       []DeliveryGame `json:"games,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	StatusCode  int            `json:"status_code,omitempty"`
+	Attempt     int            `json:"attempt,omitempty"`
+	RetryOf     int64          `json:"retry_of,omitempty"`
+	DeliveredAt time.Time      `json:"delivered_at"`
+}
+
+// RecordDelivery implements Store
+func (r *RedisStore) RecordDelivery(delivery Delivery) (int64, error) {
+	reply, err := r.do("INCR", r.prefix+redisDeliveryIDKey)
+	if err != nil {
+		return 0, fmt.Errorf("error recording delivery: %v", err)
+	}
+	delivery.ID, _ = reply.(int64)
+
+	document, err := json.Marshal(redisDelivery(delivery))
+	if err != nil {
+		return 0, fmt.Errorf("error recording delivery: %v", err)
+	}
+	key := r.prefix + redisDeliveriesKey
+	if _, err := r.do("LPUSH", key, string(document)); err != nil {
+		return 0, fmt.Errorf("error recording delivery: %v", err)
+	}
+	if _, err := r.do("LTRIM", key, "0", strconv.Itoa(redisMaxDeliveries-1)); err != nil {
+		return 0, fmt.Errorf("error recording delivery: %v", err)
+	}
+	return delivery.ID, nil
+}
+
+// Deliveries implements Store
+func (r *RedisStore) Deliveries(filter DeliveryFilter) ([]Delivery, error) {
+	all, err := r.deliveries()
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []Delivery
+	for _, delivery := range all {
+		if filter.Limit > 0 && len(deliveries) == filter.Limit {
+			break
+		}
+		if filter.matches(delivery) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// Delivery implements Store
+func (r *RedisStore) Delivery(id int64) (*Delivery, error) {
+	all, err := r.deliveries()
+	if err != nil {
+		return nil, err
+	}
+	for _, delivery := range all {
+		if delivery.ID == id {
+			return &delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+// DeleteDeliveries implements Store. The list is newest first, so the old
+// deliveries are trimmed from its tail, which deliveries pushed meanwhile
+// don't move.
+func (r *RedisStore) DeleteDeliveries(before time.Time) (int, error) {
+	all, err := r.deliveries()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for i, delivery := range all {
+		if delivery.DeliveredAt.Before(before) {
+			deleted = len(all) - i
+			break
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	if _, err := r.do("LTRIM", r.prefix+redisDeliveriesKey, "0", strconv.Itoa(-deleted-1)); err != nil {
+		return 0, fmt.Errorf("error deleting deliveries: %v", err)
+	}
+	return deleted, nil
+}
+
+// redisRawOffer is an archived payload as stored in the Redis hash
+type redisRawOffer struct {
+	Payload   []byte    `json:"payload"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// PutRawOffer implements Store
+func (r *RedisStore) PutRawOffer(offer RawOffer) error {
+	document, err := json.Marshal(redisRawOffer{Payload: offer.Payload, FetchedAt: offer.FetchedAt})
+	if err != nil {
+		return fmt.Errorf("error archiving raw offer: %v", err)
+	}
+	if _, err := r.do("HSET", r.prefix+redisRawOffersKey, offer.OfferID, string(document)); err != nil {
+		return fmt.Errorf("error archiving raw offer: %v", err)
+	}
+	return nil
+}
+
+// RawOffer implements Store
+func (r *RedisStore) RawOffer(offerID string) (*RawOffer, error) {
+	reply, err := r.do("HGET", r.prefix+redisRawOffersKey, offerID)
+	if errors.Is(err, errRedisNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading raw offer: %v", err)
+	}
+	document, _ := reply.([]byte)
+	var stored redisRawOffer
+	if err := json.Unmarshal(document, &stored); err != nil {
+		return nil, fmt.Errorf("error reading raw offer: %v", err)
+	}
+	return &RawOffer{OfferID: offerID, Payload: stored.Payload, FetchedAt: stored.FetchedAt}, nil
+}
+
+// DeleteRawOffers implements Store
+func (r *RedisStore) DeleteRawOffers(before time.Time) (int, error) {
+	offers, err := r.hashAll(redisRawOffersKey)
+	if err != nil {
+		return 0, err
+	}
+	args := []string{"HDEL", r.prefix + redisRawOffersKey}
+	for offerID, document := range offers {
+		var stored redisRawOffer
+		if err := json.Unmarshal(document, &stored); err != nil || stored.FetchedAt.Before(before) {
+			args = append(args, offerID)
+		}
+	}
+	if len(args) == 2 {
+		return 0, nil
+	}
+	if _, err := r.do(args...); err != nil {
+		return 0, fmt.Errorf("error deleting raw offers: %v", err)
+	}
+	return len(args) - 2, nil
+}
+
+// Meta implements Store
+func (r *RedisStore) Meta(key string) (string, error) {
+	reply, err := r.do("HGET", r.prefix+redisMetaKey, key)
+	if errors.Is(err, errRedisNil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", key, err)
+	}
+	value, _ := reply.([]byte)
+	return string(value), nil
+}
+
+// SetMeta implements Store
+func (r *RedisStore) SetMeta(key, value string) error {
+	if _, err := r.do("HSET", r.prefix+redisMetaKey, key, value); err != nil {
+		return fmt.Errorf("error writing %s: %v", key, err)
+	}
+	return nil
+}
+
+// Compact implements Store, Redis reclaims memory itself
+func (r *RedisStore) Compact() error {
+	return nil
+}
+
+// deliveries reads the whole delivery list, newest first
+func (r *RedisStore) deliveries() ([]Delivery, error) {
+	reply, err := r.do("LRANGE", r.prefix+redisDeliveriesKey, "0", "-1")
+	if err != nil {
+		return nil, fmt.Errorf("error reading deliveries: %v", err)
+	}
+	items, _ := reply.([]interface{})
+
+	deliveries := make([]Delivery, 0, len(items))
+	for _, item := range items {
+		document, _ := item.([]byte)
+		var delivery redisDelivery
+		if err := json.Unmarshal(document, &delivery); err != nil {
+			continue
+		}
+		deliveries = append(deliveries, Delivery(delivery))
+	}
+	return deliveries, nil
+}
+
+// History implements Store
+func (r *RedisStore) History() (map[string][]byte, error) {
+	return r.hashAll(redisHistoryKey)
+}
+
+// ReplaceHistory implements Store
+func (r *RedisStore) ReplaceHistory(entries map[string][]byte) error {
+	key := r.prefix + redisHistoryKey
+	commands := [][]string{{"DEL", key}}
+	if len(entries) > 0 {
+		hset := []string{"HSET", key}
+		for entryKey, entry := range entries {
+			hset = append(hset, entryKey, string(entry))
+		}
+		commands = append(commands, hset)
+	}
+	if err := r.transaction(commands...); err != nil {
+		return fmt.Errorf("error writing history: %v", err)
+	}
+	return nil
+}
+
+// PushSubscriptions implements Store
+func (r *RedisStore) PushSubscriptions() (map[string][]byte, error) {
+	return r.hashAll(redisPushKey)
+}
+
+// PutPushSubscription implements Store
+func (r *RedisStore) PutPushSubscription(endpoint string, subscription []byte) error {
+	if _, err := r.do("HSET", r.prefix+redisPushKey, endpoint, string(subscription)); err != nil {
+		return fmt.Errorf("error writing push subscription: %v", err)
+	}
+	return nil
+}
+
+// DeletePushSubscription implements Store
+func (r *RedisStore) DeletePushSubscription(endpoint string) error {
+	if _, err := r.do("HDEL", r.prefix+redisPushKey, endpoint); err != nil {
+		return fmt.Errorf("error deleting push subscription: %v", err)
+	}
+	return nil
+}
+
+// Close implements Store
+func (r *RedisStore) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// hashAll reads a hash under the prefix
+func (r *RedisStore) hashAll(key string) (map[string][]byte, error) {
+	reply, err := r.do("HGETALL", r.prefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", key, err)
+	}
+	items, _ := reply.([]interface{})
+	fields := map[string][]byte{}
+	for i := 0; i+1 < len(items); i += 2 {
+		name, _ := items[i].([]byte)
+		value, _ := items[i+1].([]byte)
+		fields[string(name)] = value
+	}
+	return fields, nil
+}
+
+// transaction runs commands atomically with MULTI and EXEC
+func (r *RedisStore) transaction(commands ...[]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	commands = append(append([][]string{{"MULTI"}}, commands...), []string{"EXEC"})
+	for _, args := range commands {
+		if _, err := r.command(args); err != nil {
+			if args[0] != "MULTI" && args[0] != "EXEC" {
+				r.command([]string{"DISCARD"})
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// redisIdempotent are the commands that can run twice with the same result.
+// The server may have run a command before the connection broke, so the
+// others (INCR, LPUSH, LTRIM from the end) are never retried.
+var redisIdempotent = map[string]bool{
+	"PING":    true,
+	"HGET":    true,
+	"HGETALL": true,
+	"HSET":    true,
+	"HDEL":    true,
+	"LRANGE":  true,
+}
+
+// do runs a command, retrying an idempotent one once on a new connection when
+// the server closed an idle one
+func (r *RedisStore) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reconnect := r.conn != nil && redisIdempotent[args[0]]
+	reply, err := r.command(args)
+	if err != nil && reconnect && (errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, syscall.ECONNRESET)) {
+		reply, err = r.command(args)
+	}
+	return reply, err
+}
+
+// command sends a command and reads its reply, connecting first if needed.
+// The connection is dropped on network errors. r.mu must be held.
+func (r *RedisStore) command(args []string) (interface{}, error) {
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.conn.SetDeadline(time.Now().Add(redisTimeout))
+	reply, err := r.roundTrip(args)
+	if err != nil {
+		var redisErr redisError
+		if !errors.As(err, &redisErr) {
+			r.conn.Close()
+			r.conn = nil
+		}
+	}
+	return reply, err
+}
+
+// connect dials the server, then authenticates and selects the database
+func (r *RedisStore) connect() error {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", r.addr)
+	}
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	r.rd = bufio.NewReader(conn)
+
+	var setup [][]string
+	if r.password != "" {
+		if r.username != "" {
+			setup = append(setup, []string{"AUTH", r.username, r.password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.password})
+		}
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		r.conn.SetDeadline(time.Now().Add(redisTimeout))
+		if _, err := r.roundTrip(args); err != nil {
+			r.conn.Close()
+			r.conn = nil
+			return fmt.Errorf("error in %s: %v", args[0], err)
+		}
+	}
+	return nil
+}
+
+// roundTrip writes a command as an array of bulk strings and reads the reply
+func (r *RedisStore) roundTrip(args []string) (interface{}, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := r.conn.Write([]byte(sb.String())); err != nil {
+		return nil, err
+	}
+	return readRedisReply(r.rd)
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// readRedisReply reads a RESP2 reply: simple strings and bulk strings as
+// []byte, integers as int64 and arrays as []interface{}
+func readRedisReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply from Redis")
+	}
+
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", line[1:])
+		}
+		if size < 0 {
+			return nil, errRedisNil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length %q", line[1:])
+		}
+		if count < 0 {
+			return nil, errRedisNil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readRedisReply(rd)
+			if err != nil && !errors.Is(err, errRedisNil) {
+				var redisErr redisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply from Redis: %q", line)
+}
@@ -0,0 +1,461 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlDialect describes a SQL database: the database/sql driver it's opened
+// with, its schema migrations and how it writes placeholders
+type sqlDialect struct {
+	driver     string
+	migrations []string // Applied in order at startup, only ever append
+	numbered   bool     // Placeholders are $1, $2, ... instead of ?
+}
+
+var (
+	dialectSQLite = sqlDialect{
+		driver:     "sqlite",
+		migrations: sqliteMigrations,
+	}
+	dialectPostgres = sqlDialect{
+		driver:     "pgx",
+		migrations: postgresMigrations,
+		numbered:   true,
+	}
+)
+
+var sqliteMigrations = []string{
+	// 1: initial schema
+	`CREATE TABLE seen_offers (
+		channel TEXT NOT NULL,
+		game_key TEXT NOT NULL,
+		seen_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (channel, game_key)
+	);
+	CREATE TABLE deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL,
+		games INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		delivered_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX deliveries_channel ON deliveries (channel, delivered_at);
+	CREATE TABLE history (
+		entry_key TEXT PRIMARY KEY,
+		entry TEXT NOT NULL
+	);
+	CREATE TABLE push_subscriptions (
+		endpoint TEXT PRIMARY KEY,
+		subscription TEXT NOT NULL
+	);`, // 2: games, response code and retries of deliveries
+	`ALTER TABLE deliveries ADD COLUMN game_list TEXT NOT NULL DEFAULT '[]';
+	ALTER TABLE deliveries ADD COLUMN status_code INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE deliveries ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE deliveries ADD COLUMN retry_of INTEGER NOT NULL DEFAULT 0;`,
+	// 3: raw catalog payloads
+	`CREATE TABLE raw_offers (
+		offer_id TEXT PRIMARY KEY,
+		payload BLOB NOT NULL,
+		fetched_at TIMESTAMP NOT NULL
+	);`,
+	// 4: metadata like the last scheduled run
+	`CREATE TABLE meta (
+		meta_key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+}
+
+var postgresMigrations = []string{
+	// 1: initial schema
+	`CREATE TABLE seen_offers (
+		channel TEXT NOT NULL,
+		game_key TEXT NOT NULL,
+		seen_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (channel, game_key)
+	);
+	CREATE TABLE deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		channel TEXT NOT NULL,
+		games INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		delivered_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX deliveries_channel ON deliveries (channel, delivered_at);
+	CREATE TABLE history (
+		entry_key TEXT PRIMARY KEY,
+		entry TEXT NOT NULL
+	);
+	CREATE TABLE push_subscriptions (
+		endpoint TEXT PRIMARY KEY,
+		subscription TEXT NOT NULL
+	);`, // 2: games, response code and retries of deliveries
+	`ALTER TABLE deliveries ADD COLUMN game_list TEXT NOT NULL DEFAULT '[]';
+	ALTER TABLE deliveries ADD COLUMN status_code INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE deliveries ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE deliveries ADD COLUMN retry_of INTEGER NOT NULL DEFAULT 0;`,
+	// 3: raw catalog payloads
+	`CREATE TABLE raw_offers (
+		offer_id TEXT PRIMARY KEY,
+		payload BYTEA NOT NULL,
+		fetched_at TIMESTAMPTZ NOT NULL
+	);`,
+	// 4: metadata like the last scheduled run
+	`CREATE TABLE meta (
+		meta_key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+}
+
+// SQLStore keeps the state in a SQL database
+type SQLStore struct {
+	sql     *sql.DB
+	dialect sqlDialect
+}
+
+// openSQL opens a SQL database, creating the SQLite file if needed, and
+// applies the pending migrations
+func openSQL(dialect sqlDialect, dsn string) (*SQLStore, error) {
+	conn, err := sql.Open(dialect.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %v", err)
+	}
+	if dialect.driver == dialectSQLite.driver {
+		// SQLite allows a single writer, serializing access avoids busy errors
+		conn.SetMaxOpenConns(1)
+	}
+
+	db := &SQLStore{sql: conn, dialect: dialect}
+	if err := db.migrate(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// rebind rewrites the ? placeholders of a query for the dialect
+func (db *SQLStore) rebind(query string) string {
+	if !db.dialect.numbered {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Close implements Store
+func (db *SQLStore) Close() error {
+	return db.sql.Close()
+}
+
+// migrate applies the migrations that haven't been applied yet
+func (db *SQLStore) migrate() error {
+	if _, err := db.sql.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("error creating migrations table: %v", err)
+	}
+
+	var current int
+	if err := db.sql.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("error reading schema version: %v", err)
+	}
+
+	migrations := db.dialect.migrations
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.sql.Begin()
+		if err != nil {
+			return fmt.Errorf("error starting migration %d: %v", version, err)
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %v", version, err)
+		}
+		if _, err := tx.Exec(db.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`), version, time.Now().UTC()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error recording migration %d: %v", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error committing migration %d: %v", version, err)
+		}
+	}
+	return nil
+}
+
+// SeenOffers implements Store
+func (db *SQLStore) SeenOffers(channel string) (map[string]time.Time, error) {
+	rows, err := db.sql.Query(db.rebind(`SELECT game_key, seen_at FROM seen_offers WHERE channel = ?`), channel)
+	if err != nil {
+		return nil, fmt.Errorf("error reading seen offers: %v", err)
+	}
+	defer rows.Close()
+
+	seen := map[string]time.Time{}
+	for rows.Next() {
+		var key string
+		var seenAt time.Time
+		if err := rows.Scan(&key, &seenAt); err != nil {
+			return nil, fmt.Errorf("error reading seen offers: %v", err)
+		}
+		seen[key] = seenAt
+	}
+	return seen, rows.Err()
+}
+
+// MarkSeen implements Store
+func (db *SQLStore) MarkSeen(channel string, keys []string, seenAt time.Time) error {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("error marking offers seen: %v", err)
+	}
+	for _, key := range keys {
+		if _, err := tx.Exec(db.rebind(`INSERT INTO seen_offers (channel, game_key, seen_at) VALUES (?, ?, ?)
+			ON CONFLICT (channel, game_key) DO UPDATE SET seen_at = excluded.seen_at`), channel, key, seenAt.UTC()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error marking offers seen: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error marking offers seen: %v", err)
+	}
+	return nil
+}
+
+// deliveryColumns are the columns scanned by scanDelivery
+const deliveryColumns = `id, channel, game_list, error, status_code, attempt, retry_of, delivered_at`
+
+// RecordDelivery implements Store
+func (db *SQLStore) RecordDelivery(delivery Delivery) (int64, error) {
+	games, err := json.Marshal(delivery.Games)
+	if err != nil {
+		return 0, fmt.Errorf("error recording delivery: %v", err)
+	}
+	var id int64
+	if err := db.sql.QueryRow(db.rebind(`INSERT INTO deliveries (channel, games, game_list, error, status_code, attempt, retry_of, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		delivery.Channel, len(delivery.Games), string(games), delivery.Error, delivery.StatusCode, delivery.Attempt, delivery.RetryOf, delivery.DeliveredAt.UTC()).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error recording delivery: %v", err)
+	}
+	return id, nil
+}
+
+// Deliveries implements Store
+func (db *SQLStore) Deliveries(filter DeliveryFilter) ([]Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM deliveries WHERE delivered_at >= ?`
+	args := []interface{}{filter.Since.UTC()}
+	if filter.Channel != "" {
+		query += ` AND channel = ?`
+		args = append(args, filter.Channel)
+	}
+	if filter.FailedOnly {
+		query += ` AND error <> ''`
+	}
+	query += ` ORDER BY delivered_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.sql.Query(db.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Delivery implements Store
+func (db *SQLStore) Delivery(id int64) (*Delivery, error) {
+	delivery, err := scanDelivery(db.sql.QueryRow(db.rebind(`SELECT `+deliveryColumns+` FROM deliveries WHERE id = ?`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return delivery, err
+}
+
+// DeleteDeliveries implements Store
+func (db *SQLStore) DeleteDeliveries(before time.Time) (int, error) {
+	result, err := db.sql.Exec(db.rebind(`DELETE FROM deliveries WHERE delivered_at < ?`), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting deliveries: %v", err)
+	}
+	deleted, _ := result.RowsAffected()
+	return int(deleted), nil
+}
+
+// PutRawOffer implements Store
+func (db *SQLStore) PutRawOffer(offer RawOffer) error {
+	if _, err := db.sql.Exec(db.rebind(`INSERT INTO raw_offers (offer_id, payload, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT (offer_id) DO UPDATE SET payload = excluded.payload, fetched_at = excluded.fetched_at`),
+		offer.OfferID, offer.Payload, offer.FetchedAt.UTC()); err != nil {
+		return fmt.Errorf("error archiving raw offer: %v", err)
+	}
+	return nil
+}
+
+// RawOffer implements Store
+func (db *SQLStore) RawOffer(offerID string) (*RawOffer, error) {
+	offer := RawOffer{OfferID: offerID}
+	err := db.sql.QueryRow(db.rebind(`SELECT payload, fetched_at FROM raw_offers WHERE offer_id = ?`), offerID).Scan(&offer.Payload, &offer.FetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading raw offer: %v", err)
+	}
+	return &offer, nil
+}
+
+// DeleteRawOffers implements Store
+func (db *SQLStore) DeleteRawOffers(before time.Time) (int, error) {
+	result, err := db.sql.Exec(db.rebind(`DELETE FROM raw_offers WHERE fetched_at < ?`), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting raw offers: %v", err)
+	}
+	deleted, _ := result.RowsAffected()
+	return int(deleted), nil
+}
+
+// Meta implements Store
+func (db *SQLStore) Meta(key string) (string, error) {
+	var value string
+	err := db.sql.QueryRow(db.rebind(`SELECT value FROM meta WHERE meta_key = ?`), key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", key, err)
+	}
+	return value, nil
+}
+
+// SetMeta implements Store
+func (db *SQLStore) SetMeta(key, value string) error {
+	if _, err := db.sql.Exec(db.rebind(`INSERT INTO meta (meta_key, value) VALUES (?, ?)
+		ON CONFLICT (meta_key) DO UPDATE SET value = excluded.value`), key, value); err != nil {
+		return fmt.Errorf("error writing %s: %v", key, err)
+	}
+	return nil
+}
+
+// Compact implements Store. Deleted rows only free pages for reuse, VACUUM
+// rebuilds the database so the file shrinks.
+func (db *SQLStore) Compact() error {
+	if _, err := db.sql.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("error compacting database: %v", err)
+	}
+	return nil
+}
+
+// scanDelivery reads a row of deliveryColumns
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*Delivery, error) {
+	var delivery Delivery
+	var games string
+	if err := row.Scan(&delivery.ID, &delivery.Channel, &games, &delivery.Error, &delivery.StatusCode, &delivery.Attempt, &delivery.RetryOf, &delivery.DeliveredAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reading deliveries: %v", err)
+	}
+	if err := json.Unmarshal([]byte(games), &delivery.Games); err != nil {
+		return nil, fmt.Errorf("error reading games of delivery %d: %v", delivery.ID, err)
+	}
+	return &delivery, nil
+}
+
+// History implements Store
+func (db *SQLStore) History() (map[string][]byte, error) {
+	return db.documents(`SELECT entry_key, entry FROM history`)
+}
+
+// ReplaceHistory implements Store
+func (db *SQLStore) ReplaceHistory(entries map[string][]byte) error {
+	return db.putDocuments(`DELETE FROM history`, `INSERT INTO history (entry_key, entry) VALUES (?, ?)`, entries)
+}
+
+// PushSubscriptions implements Store
+func (db *SQLStore) PushSubscriptions() (map[string][]byte, error) {
+	return db.documents(`SELECT endpoint, subscription FROM push_subscriptions`)
+}
+
+// PutPushSubscription implements Store
+func (db *SQLStore) PutPushSubscription(endpoint string, subscription []byte) error {
+	return db.putDocuments("", `INSERT INTO push_subscriptions (endpoint, subscription) VALUES (?, ?)
+		ON CONFLICT (endpoint) DO UPDATE SET subscription = excluded.subscription`, map[string][]byte{endpoint: subscription})
+}
+
+// DeletePushSubscription implements Store
+func (db *SQLStore) DeletePushSubscription(endpoint string) error {
+	if _, err := db.sql.Exec(db.rebind(`DELETE FROM push_subscriptions WHERE endpoint = ?`), endpoint); err != nil {
+		return fmt.Errorf("error deleting push subscription: %v", err)
+	}
+	return nil
+}
+
+// documents reads key and JSON document pairs
+func (db *SQLStore) documents(query string) (map[string][]byte, error) {
+	rows, err := db.sql.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error reading documents: %v", err)
+	}
+	defer rows.Close()
+
+	documents := map[string][]byte{}
+	for rows.Next() {
+		var key, document string
+		if err := rows.Scan(&key, &document); err != nil {
+			return nil, fmt.Errorf("error reading documents: %v", err)
+		}
+		documents[key] = []byte(document)
+	}
+	return documents, rows.Err()
+}
+
+// putDocuments writes key and JSON document pairs in one transaction, after
+// running clear unless it's empty
+func (db *SQLStore) putDocuments(clear, query string, documents map[string][]byte) error {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("error writing documents: %v", err)
+	}
+	if clear != "" {
+		if _, err := tx.Exec(clear); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error writing documents: %v", err)
+		}
+	}
+	query = db.rebind(query)
+	for key, document := range documents {
+		if _, err := tx.Exec(query, key, string(document)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error writing documents: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error writing documents: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package storage
+
+// The SQLite driver of sqlite:// URLs, pure Go so the binary still
+// builds without cgo
+import _ "modernc.org/sqlite"
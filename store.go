@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Stores the service tracks giveaways on
+const (
+	storeEpic        = "epic"
+	storeGOG         = "gog"
+	storePrimeGaming = "prime_gaming"
+	storeSteam       = "steam"
+	storeItchIO      = "itch_io"
+	storeUbisoft     = "ubisoft"
+	storePSPlus      = "ps_plus"
+	storeGamePass    = "xbox_game_pass"
+)
+
+// storeIDs lists the stores in display order
+var storeIDs = []string{storeEpic, storeGOG, storePrimeGaming, storeSteam, storeItchIO, storeUbisoft, storePSPlus, storeGamePass}
+
+// storeNames are the display names of the stores
+var storeNames = map[string]string{
+	storeEpic:        "Epic Games Store",
+	storeGOG:         "GOG",
+	storePrimeGaming: "Prime Gaming",
+	storeSteam:       "Steam",
+	storeItchIO:      "itch.io",
+	storeUbisoft:     "Ubisoft Connect",
+	storePSPlus:      "PlayStation Plus",
+	storeGamePass:    "Xbox Game Pass",
+}
+
+// storeShortNames are used where space is short, e.g. "Claim on Epic"
+var storeShortNames = map[string]string{
+	storeEpic:        "Epic",
+	storeGOG:         "GOG",
+	storePrimeGaming: "Prime",
+	storeSteam:       "Steam",
+	storeItchIO:      "itch.io",
+	storeUbisoft:     "Ubisoft",
+	storePSPlus:      "PS Plus",
+	storeGamePass:    "Game Pass",
+}
+
+// storeOnPhrases say where a game is free, e.g. "free on the Epic Games Store"
+var storeOnPhrases = map[string]string{
+	storeEpic:        "on the Epic Games Store",
+	storeGOG:         "on GOG",
+	storePrimeGaming: "with Prime Gaming",
+	storeSteam:       "on Steam",
+	storeItchIO:      "on itch.io",
+	storeUbisoft:     "on Ubisoft Connect",
+	storePSPlus:      "with PlayStation Plus",
+	storeGamePass:    "with Xbox Game Pass",
+}
+
+// gameStore is the store of the game, games without one are from Epic
+func gameStore(game Game) string {
+	if game.Store == "" {
+		return storeEpic
+	}
+	return game.Store
+}
+
+// storeName is the display name of the game's store
+func storeName(game Game) string {
+	return storeNames[gameStore(game)]
+}
+
+// storeShortName is the short display name of the game's store
+func storeShortName(game Game) string {
+	return storeShortNames[gameStore(game)]
+}
+
+// onStore says where the game is free, e.g. "on the Epic Games Store"
+func onStore(game Game) string {
+	return storeOnPhrases[gameStore(game)]
+}
+
+// validateStore rejects unknown stores
+func validateStore(store string) error {
+	if _, ok := storeNames[store]; !ok {
+		return fmt.Errorf("unknown store %q, expected one of %s", store, strings.Join(storeIDs, ", "))
+	}
+	return nil
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// storesName names the stores of the games in the order they appear, e.g.
+// "Epic Games Store and GOG". An empty list is named after Epic.
+func storesName(games []Game) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, game := range games {
+		if name := storeName(game); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return storeNames[storeEpic]
+	case 1:
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// fetchOtherStores returns the giveaways of the stores besides Epic that are
+// enabled. A store that fails is logged and skipped, the other games are
+// still worth returning.
+func fetchOtherStores(ctx context.Context, countryCode, locale string, includeUpcoming bool, timezone string) []Game {
+	stores := []struct {
+		enabled bool
+		store   string
+		fetch   func(context.Context) ([]Game, error)
+	}{
+		{includeGOG, storeGOG, func(ctx context.Context) ([]Game, error) {
+			return fetchGOGGiveaways(ctx, countryCode, locale)
+		}},
+		{includePrimeGaming, storePrimeGaming, func(ctx context.Context) ([]Game, error) {
+			return fetchPrimeGamingOffers(ctx, includeUpcoming, timezone)
+		}},
+		{includeSteam, storeSteam, func(ctx context.Context) ([]Game, error) {
+			return fetchSteamGiveaways(ctx, countryCode, timezone)
+		}},
+		{includeItchIO, storeItchIO, func(ctx context.Context) ([]Game, error) {
+			return fetchItchIOGiveaways(ctx, itchIOMinRating)
+		}},
+		{includeUbisoft, storeUbisoft, func(ctx context.Context) ([]Game, error) {
+			return fetchUbisoftGiveaways(ctx, locale, includeUpcoming, timezone)
+		}},
+		{includePSPlus, storePSPlus, func(ctx context.Context) ([]Game, error) {
+			return fetchPSPlusMonthlyGames(ctx, locale)
+		}},
+		{includeGamePass, storeGamePass, func(ctx context.Context) ([]Game, error) {
+			return fetchGamePassAdditions(ctx, countryCode, locale)
+		}},
+	}
+
+	// The stores are fetched at once, their games merged in the order above
+	var names []string
+	var fetches []func(context.Context) ([]Game, error)
+	for _, store := range stores {
+		if store.enabled {
+			names = append(names, storeNames[store.store])
+			fetches = append(fetches, store.fetch)
+		}
+	}
+	results, errs := fetchConcurrently(ctx, fetches)
+
+	var games []Game
+	for i, storeGames := range results {
+		if errs[i] != nil {
+			log.Printf("Warning: Error fetching %s giveaways: %v", names[i], errs[i])
+			continue
+		}
+		games = append(games, storeGames...)
+	}
+	return games
+}
+
+// setPromotionWindow sets the exact promotion dates of a game found outside
+// Epic, formatted like the Epic dates
+func setPromotionWindow(game *Game, start, end time.Time, timezone string) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.FixedZone("UTC+8", 8*60*60)
+	}
+
+	game.StartDate, game.EndDate = "Unknown", "Unknown"
+	game.DatePrecision = "unknown"
+	game.startTime, game.endTime = start, end
+	if !start.IsZero() {
+		game.StartDate = start.In(location).Format("2006-01-02 15:04:05 MST")
+		game.StartDateISO = start.Format(time.RFC3339)
+	}
+	if !end.IsZero() {
+		game.EndDate = end.In(location).Format("2006-01-02 15:04:05 MST")
+		game.EndDateISO = end.Format(time.RFC3339)
+	}
+	if start.IsZero() || end.IsZero() {
+		return
+	}
+
+	game.DatePrecision = "exact"
+	now := time.Now()
+	if start.After(now) {
+		game.StartsIn = humanizeDuration(start.Sub(now))
+	}
+	if end.After(now) {
+		game.EndsIn = humanizeDuration(end.Sub(now))
+	}
+}
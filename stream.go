@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Types of the events sent on the stream
+const (
+	eventGameAdded    = "game_added"    // A game became free or was announced
+	eventGameExpiring = "game_expiring" // A free game ends within ExpiringWithin
+	eventGameRemoved  = "game_removed"  // A game is no longer listed
+)
+
+// streamReplaySize is how many past events are kept for reconnecting clients
+const streamReplaySize = 100
+
+// StreamEvent is a change to the free games list
+type StreamEvent struct {
+	ID   int64
+	Type string
+	Game Game
+}
+
+// GameStream diffs each refresh of the free games against the previous one
+// and broadcasts the changes to the connected Server-Sent Events clients
+type GameStream struct {
+	ExpiringWithin time.Duration
+
+	mu       sync.Mutex
+	clients  map[chan StreamEvent]struct{}
+	games    map[string]Game // Last refresh, by streamKey
+	expiring map[string]bool // Games game_expiring was sent for
+	primed   bool
+	nextID   int64
+	recent   []StreamEvent
+}
+
+// NewGameStream creates a stream without clients that sends game_expiring
+// for free games ending within expiringWithin
+func NewGameStream(expiringWithin time.Duration) *GameStream {
+	return &GameStream{
+		ExpiringWithin: expiringWithin,
+		clients:        make(map[chan StreamEvent]struct{}),
+		games:          make(map[string]Game),
+		expiring:       make(map[string]bool),
+	}
+}
+
+// streamKey identifies a game across status changes
+func streamKey(game Game) string {
+	if game.ID != "" {
+		return game.ID
+	}
+	return game.Title
+}
+
+// Run refreshes the games every interval, fetching only while clients are
+// connected (and once at startup so the first refresh has a baseline)
+func (s *GameStream) Run(fetch func() ([]Game, error), interval time.Duration) {
+	for first := true; ; first = false {
+		if first || s.hasClients() {
+			if games, err := fetch(); err != nil {
+				log.Printf("Warning: Error refreshing games for the stream: %v", err)
+			} else {
+				s.Update(games)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (s *GameStream) hasClients() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients) > 0
+}
+
+// Update compares games with the previous refresh and broadcasts the changes.
+// The first update only records the baseline.
+func (s *GameStream) Update(games []Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	current := make(map[string]Game, len(games))
+	var events []StreamEvent
+	for _, game := range games {
+		key := streamKey(game)
+		current[key] = game
+
+		previous, known := s.games[key]
+		if s.primed && (!known || previous.Status != game.Status) {
+			events = append(events, StreamEvent{Type: eventGameAdded, Game: game})
+		}
+		if game.Status == "free" && game.DatePrecision == "exact" && !s.expiring[key] &&
+			game.endTime.Sub(now) <= s.ExpiringWithin {
+			s.expiring[key] = true
+			if s.primed {
+				events = append(events, StreamEvent{Type: eventGameExpiring, Game: game})
+			}
+		}
+	}
+	for key, game := range s.games {
+		if _, ok := current[key]; !ok {
+			delete(s.expiring, key)
+			if s.primed {
+				events = append(events, StreamEvent{Type: eventGameRemoved, Game: game})
+			}
+		}
+	}
+	s.games = current
+	s.primed = true
+
+	for _, event := range events {
+		s.nextID++
+		event.ID = s.nextID
+		s.recent = append(s.recent, event)
+		if len(s.recent) > streamReplaySize {
+			s.recent = s.recent[len(s.recent)-streamReplaySize:]
+		}
+		for client := range s.clients {
+			select {
+			case client <- event:
+			default:
+				// Drop clients that don't keep up, they can reconnect with Last-Event-ID
+				delete(s.clients, client)
+				close(client)
+			}
+		}
+	}
+}
+
+// current returns the games of the last refresh
+func (s *GameStream) current() []Game {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	games := make([]Game, 0, len(s.games))
+	for _, game := range s.games {
+		games = append(games, game)
+	}
+	return games
+}
+
+// subscribe registers a client, returning the events it missed since
+// lastEventID and a function to unregister it
+func (s *GameStream) subscribe(lastEventID int64) (chan StreamEvent, []StreamEvent, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client := make(chan StreamEvent, 16)
+	s.clients[client] = struct{}{}
+
+	var missed []StreamEvent
+	if lastEventID > 0 {
+		for _, event := range s.recent {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	return client, missed, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.clients[client]; ok {
+			delete(s.clients, client)
+			close(client)
+		}
+	}
+}
+
+// streamHandler serves GET /v1/stream, a Server-Sent Events stream of
+// game_added, game_expiring and game_removed events whose data is the game
+func streamHandler(w http.ResponseWriter, r *http.Request, stream *GameStream) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpError(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	events, missed, unsubscribe := stream.subscribe(lastEventID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	fmt.Fprint(w, "retry: 10000\n\n")
+	for _, event := range missed {
+		writeStreamEvent(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			writeStreamEvent(w, event)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// writeStreamEvent writes an event in the text/event-stream format
+func writeStreamEvent(w http.ResponseWriter, event StreamEvent) {
+	data, err := json.Marshal(event.Game)
+	if err != nil {
+		log.Printf("Warning: Error encoding stream event: %v", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
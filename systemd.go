@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation
+const listenFDsStart = 3
+
+// refresherHeartbeat is when the background refresher last finished a pass,
+// in Unix nanoseconds, checked by the systemd watchdog
+var refresherHeartbeat atomic.Int64
+
+// sdNotify sends a state like READY=1 to the systemd notification socket.
+// Without NOTIFY_SOCKET, when the service doesn't run with Type=notify, it
+// does nothing.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		// Abstract socket
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("error connecting to systemd: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("error notifying systemd: %v", err)
+	}
+	return nil
+}
+
+// systemdListener returns the first socket passed by systemd socket
+// activation, nil when the service wasn't socket activated
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+	// Child processes must not take the sockets for theirs
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if fds > 1 {
+		log.Printf("Warning: systemd passed %d sockets, only the first one is used", fds)
+	}
+
+	syscall.CloseOnExec(listenFDsStart)
+	file := os.NewFile(listenFDsStart, "systemd-socket")
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("error using the systemd socket: %v", err)
+	}
+	file.Close()
+	return listener, nil
+}
+
+// systemdWatchdogInterval returns how often the watchdog must be pinged, half
+// of WatchdogSec, and false when the watchdog is off
+func systemdWatchdogInterval() (time.Duration, bool) {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond / 2, true
+}
+
+// runSystemdWatchdog pings the systemd watchdog as long as healthy reports
+// true, so systemd restarts the service once it stops
+func runSystemdWatchdog(interval time.Duration, healthy func() bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !healthy() {
+			log.Println("Warning: Background refresher is stuck, not pinging the systemd watchdog")
+			continue
+		}
+		if err := sdNotify("WATCHDOG=1"); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// refresherHealthy reports whether the background refresher finished a pass
+// lately, always true when it's off
+func refresherHealthy() bool {
+	if freeGamesTTL <= 0 {
+		return true
+	}
+	last := time.Unix(0, refresherHeartbeat.Load())
+	return time.Since(last) < 2*freeGamesTTL+time.Minute
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"epic-games-api/pkg/epicgames"
+)
+
+// epicTagsQuery lists the store tags, the freeGamesPromotions endpoint only
+// returns their IDs
+const epicTagsQuery = `
+query tags($locale: String) {
+  Catalog {
+    tags(namespace: "epic", locale: $locale, start: 0, count: 999) {
+      elements {
+        id
+        name
+        groupName
+      }
+    }
+  }
+}`
+
+// epicTag is a store tag, grouped e.g. in "genre" or "feature"
+type epicTag struct {
+	Name      string `json:"name"`
+	GroupName string `json:"groupName"`
+}
+
+// epicTagsRefresh is how long the tag names are reused before being fetched
+// again
+const epicTagsRefresh = 24 * time.Hour
+
+var (
+	epicTagsMu      sync.Mutex
+	epicTagsCache   = map[string]map[string]epicTag{} // By locale, then tag ID
+	epicTagsFetched = map[string]time.Time{}
+)
+
+// lookupEpicTags returns the store tags of a locale by ID, fetched once a day.
+// A failed fetch returns the tags known so far.
+func lookupEpicTags(ctx context.Context, locale string) map[string]epicTag {
+	epicTagsMu.Lock()
+	defer epicTagsMu.Unlock()
+
+	if time.Since(epicTagsFetched[locale]) < epicTagsRefresh {
+		return epicTagsCache[locale]
+	}
+	epicTagsFetched[locale] = time.Now()
+
+	tags, err := fetchEpicTags(ctx, locale)
+	if err != nil {
+		log.Printf("Warning: Error fetching store tags: %v", err)
+		return epicTagsCache[locale]
+	}
+	epicTagsCache[locale] = tags
+	return tags
+}
+
+// fetchEpicTags fetches the names and groups of the store tags
+func fetchEpicTags(ctx context.Context, locale string) (map[string]epicTag, error) {
+	requestBody, err := json.Marshal(GraphQLRequest{
+		Query:     epicTagsQuery,
+		Variables: map[string]interface{}{"locale": locale},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://graphql.epicgames.com/graphql", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var tagsResp struct {
+		Data struct {
+			Catalog struct {
+				Tags struct {
+					Elements []struct {
+						ID string `json:"id"`
+						epicTag
+					} `json:"elements"`
+				} `json:"tags"`
+			} `json:"Catalog"`
+		} `json:"data"`
+		Errors []GraphQLError `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	if len(tagsResp.Errors) > 0 && len(tagsResp.Data.Catalog.Tags.Elements) == 0 {
+		return nil, fmt.Errorf("error from GraphQL API: %s", epicgames.JoinErrors(tagsResp.Errors))
+	}
+
+	tags := map[string]epicTag{}
+	for _, element := range tagsResp.Data.Catalog.Tags.Elements {
+		tags[element.ID] = element.epicTag
+	}
+	return tags, nil
+}
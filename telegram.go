@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// TelegramNotifier sends the games to one or more Telegram chats via a bot
+type TelegramNotifier struct {
+	BotToken string
+	ChatIDs  []string
+	tracker  *SeenTracker
+	client   *http.Client
+}
+
+// NewTelegramNotifier creates a Telegram notifier for the given bot and chats.
+// Games already sent are remembered by tracker, per chat.
+func NewTelegramNotifier(botToken string, chatIDs []string, tracker *SeenTracker) *TelegramNotifier {
+	return &TelegramNotifier{
+		BotToken: botToken,
+		ChatIDs:  chatIDs,
+		tracker:  tracker,
+		client:   newHTTPClient(10 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// pendingGames returns the games not sent to every chat yet
+func (t *TelegramNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return t.tracker.Unseen(games), t.tracker
+}
+
+// Notify sends a single message listing the games not announced yet to every
+// chat. A chat that failed only gets the games it missed next time.
+func (t *TelegramNotifier) Notify(ctx context.Context, games []Game) error {
+	var errs []error
+	for _, chatID := range t.ChatIDs {
+		unseen := t.tracker.UnseenBy(chatID, games)
+		if len(unseen) == 0 {
+			continue
+		}
+		text, err := messageTemplates.Render(t.Name(), formatHTML, unseen)
+		if err != nil {
+			return err
+		}
+		if err := t.sendMessage(ctx, chatID, text); err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %v", chatID, err))
+			continue
+		}
+		if err := t.tracker.MarkSeenBy(chatID, unseen...); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Games every chat got are seen for the whole channel
+	if err := t.tracker.MarkSeenByAll(t.ChatIDs, games); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return errors.Join(errs...)
+}
+
+func (t *TelegramNotifier) sendMessage(ctx context.Context, chatID, text string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":    chatID,
+		"text":       text,
+		"parse_mode": "HTML",
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling Telegram message: %v", err)
+	}
+
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.BotToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("error creating Telegram request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The URL contains the bot token, don't leak it into logs
+		return fmt.Errorf("error sending Telegram request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Telegram returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Formats of the message templates
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatDiscord  = "discord" // A Discord embed object, executed with a game
+)
+
+// defaultMessageTemplates are the built-in templates, one per format plus
+// the channels that need their own flavor, e.g. zulip.markdown.tmpl or the
+// short texts of mastodon.text.tmpl
+//
+//go:embed templates/*.tmpl
+var defaultMessageTemplates embed.FS
+
+// templateFuncs are available in every template: the message templates,
+// the webhook payload template and the Discord template
+var templateFuncs = template.FuncMap{
+	// json renders a value as JSON, e.g. {{ json .Games }} or {{ json .Title }}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"trim":      strings.TrimSpace,
+	"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"replace":   func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"join":      func(sep string, list []string) string { return strings.Join(list, sep) },
+	// default returns value unless it's empty, e.g. {{ .Publisher | default "Unknown" }}
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+	// trunc shortens s to n characters, ending it with "…" when cut
+	"trunc": func(n int, s string) string {
+		if utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:max(n-1, 0)]) + "…"
+	},
+	// md escapes the Markdown syntax in s
+	"md": escapeMarkdown,
+
+	// Texts of a game shared by every channel
+	"upcoming": isUpcoming,
+	"bundle":   bundleContentsText,
+	"score":    criticScoreText,
+	"playtime": playtimeText,
+	"region":   func(game Game) string { return game.regionWarning },
+	"store":    onStore,
+	// day is the date part of "2006-01-02 15:04:05 MST", empty when unknown
+	"day": func(date string) string {
+		if fields := strings.Fields(date); len(fields) > 0 && date != "Unknown" {
+			return fields[0]
+		}
+		return ""
+	},
+}
+
+// markdownEscaper escapes the characters Markdown gives a meaning to
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "~", `\~`, "|", `\|`, "#", `\#`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// messageData is passed to the message templates
+type messageData struct {
+	Games    []Game
+	Free     []Game // Games free now
+	Upcoming []Game
+	Count    int
+	Stores   string // Names of the stores of the games
+}
+
+func newMessageData(games []Game) messageData {
+	data := messageData{Games: games, Count: len(games), Stores: storesName(games)}
+	for _, game := range games {
+		if isUpcoming(game) {
+			data.Upcoming = append(data.Upcoming, game)
+		} else {
+			data.Free = append(data.Free, game)
+		}
+	}
+	return data
+}
+
+// messageTemplate is a parsed text or HTML template
+type messageTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// MessageTemplates render the notifications of the channels. A channel asks
+// for a format and gets its own flavor, "{channel}.{format}.tmpl", or the
+// shared "{format}.tmpl". Files in the -message-templates directory replace
+// the built-in templates of the same name. Structured payloads, i.e.
+// Mattermost attachments and LINE Flex carousels, are built by their
+// channels; Discord embeds only come from discord.tmpl when it's set.
+type MessageTemplates struct {
+	templates map[string]messageTemplate // By name without .tmpl, e.g. "zulip.markdown"
+}
+
+// messageTemplates are the templates in use, set from -message-templates
+var messageTemplates = mustLoadMessageTemplates("")
+
+// LoadMessageTemplates parses the built-in templates and the overrides in
+// dir, which may be empty. Every template is executed with sample games so
+// mistakes fail at startup rather than when the first notification is sent.
+func LoadMessageTemplates(dir string) (*MessageTemplates, error) {
+	m := &MessageTemplates{templates: map[string]messageTemplate{}}
+	sources := map[string]string{}
+	defaults, _ := fs.Glob(defaultMessageTemplates, "templates/*.tmpl")
+	for _, file := range defaults {
+		data, err := defaultMessageTemplates.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[strings.TrimSuffix(path.Base(file), ".tmpl")] = string(data)
+	}
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading message templates: %v", err)
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".tmpl")
+			if !ok || entry.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(dir + "/" + entry.Name())
+			if err != nil {
+				return nil, fmt.Errorf("error reading message template: %v", err)
+			}
+			sources[name] = string(data)
+		}
+	}
+
+	for name, source := range sources {
+		format := name[strings.LastIndex(name, ".")+1:]
+		var tmpl messageTemplate
+		var err error
+		switch format {
+		case formatHTML:
+			tmpl, err = htmltemplate.New(name).Funcs(templateFuncs).Parse(source)
+		case formatText, formatMarkdown, formatDiscord:
+			tmpl, err = template.New(name).Funcs(templateFuncs).Parse(source)
+		default:
+			return nil, fmt.Errorf("unknown format of message template %s.tmpl, expected text, markdown, html or discord", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing message template %s.tmpl: %v", name, err)
+		}
+		m.templates[name] = tmpl
+	}
+
+	samples := []Game{
+		{Title: "Sample", Status: "free", StartDate: "Unknown", EndDate: "Unknown", DatePrecision: "unknown"},
+		{Title: "Sample", Status: "coming soon", StartDate: "Unknown", EndDate: "Unknown", DatePrecision: "unknown"},
+	}
+	for name, tmpl := range m.templates {
+		if strings.HasSuffix(name, formatDiscord) {
+			continue
+		}
+		if err := tmpl.Execute(io.Discard, newMessageData(samples)); err != nil {
+			return nil, fmt.Errorf("error in message template %s.tmpl: %v", name, err)
+		}
+	}
+	if _, _, err := m.discordEmbed(samples[0]); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func mustLoadMessageTemplates(dir string) *MessageTemplates {
+	m, err := LoadMessageTemplates(dir)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// lookup returns the template of a channel in a format
+func (m *MessageTemplates) lookup(channel, format string) (messageTemplate, bool) {
+	if tmpl, ok := m.templates[channel+"."+format]; ok {
+		return tmpl, true
+	}
+	tmpl, ok := m.templates[format]
+	return tmpl, ok
+}
+
+// Render renders the message of a channel announcing games
+func (m *MessageTemplates) Render(channel, format string, games []Game) (string, error) {
+	tmpl, ok := m.lookup(channel, format)
+	if !ok {
+		return "", fmt.Errorf("no %s message template", format)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newMessageData(games)); err != nil {
+		return "", fmt.Errorf("error rendering %s message: %v", format, err)
+	}
+	return buf.String(), nil
+}
+
+// RenderGame renders the text message of a channel announcing a single
+// game, for the channels posting a message per game
+func (m *MessageTemplates) RenderGame(channel string, game Game) (string, error) {
+	text, err := m.Render(channel, formatText, []Game{game})
+	return strings.TrimSpace(text), err
+}
+
+// discordEmbed renders the embed of a game with discord.tmpl, which has no
+// built-in version: without one, ok is false and the embed is built from
+// the Discord template configuration
+func (m *MessageTemplates) discordEmbed(game Game) (embed DiscordEmbed, ok bool, err error) {
+	tmpl, ok := m.lookup("discord", formatDiscord)
+	if !ok {
+		return DiscordEmbed{}, false, nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, game); err != nil {
+		return DiscordEmbed{}, true, fmt.Errorf("error rendering Discord embed: %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &embed); err != nil {
+		return DiscordEmbed{}, true, fmt.Errorf("discord.tmpl didn't render an embed object: %v", err)
+	}
+	return embed, true, nil
+}
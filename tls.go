@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certCheckInterval is how often the certificate files are checked for a
+// renewed certificate
+const certCheckInterval = time.Minute
+
+// TLSOptions configures serving HTTPS, from a certificate and key file or
+// with certificates obtained from Let's Encrypt
+type TLSOptions struct {
+	CertFile      string
+	KeyFile       string
+	AutocertHosts []string // Hostnames certificates are obtained for
+	AutocertCache string   // Directory keeping the account key and certificates
+	AutocertEmail string   // Contact for expiry notices, optional
+	RedirectPort  int      // Plain HTTP port redirecting to HTTPS, 0 disables
+}
+
+// Enabled reports whether the server serves HTTPS
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || len(o.AutocertHosts) > 0
+}
+
+// serverTLS returns the TLS config of the server, and the handler of the
+// redirect port. With autocert, the redirect port also answers the HTTP-01
+// challenges of Let's Encrypt.
+func (o TLSOptions) serverTLS(httpsPort int) (*tls.Config, http.Handler, error) {
+	if len(o.AutocertHosts) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(o.AutocertHosts...),
+			Email:      o.AutocertEmail,
+		}
+		if o.AutocertCache != "" {
+			manager.Cache = autocert.DirCache(o.AutocertCache)
+		}
+		return manager.TLSConfig(), manager.HTTPHandler(nil), nil
+	}
+
+	certs, err := newCertReloader(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	return config, httpsRedirectHandler(httpsPort), nil
+}
+
+// httpsRedirectHandler redirects plain HTTP requests to the same URL over
+// HTTPS on port
+func httpsRedirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// startRedirectServer serves handler on the plain HTTP port in the background
+func startRedirectServer(port int, handler http.Handler) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("error listening for HTTP redirects: %v", err)
+	}
+	go func() {
+		server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+		if err := server.Serve(listener); err != nil {
+			log.Printf("Warning: HTTP redirect server stopped: %v", err)
+		}
+	}()
+	fmt.Printf("Redirecting HTTP on port %d to HTTPS...\n", port)
+	return nil
+}
+
+// certReloader serves a certificate loaded from files, and loads it again
+// once the certificate file changed, so renewals by certbot or similar tools
+// don't need a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // Of the certificate file when it was loaded
+	checked time.Time
+}
+
+// newCertReloader loads the certificate and key, failing when they're invalid
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the certificate and key. The caller must hold the lock, or be
+// the constructor.
+func (c *certReloader) load() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return fmt.Errorf("error reading TLS certificate: %v", err)
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate: %v", err)
+	}
+	c.cert = &cert
+	c.modTime = info.ModTime()
+	c.checked = time.Now()
+	return nil
+}
+
+// GetCertificate returns the current certificate, loading the files again
+// when the certificate changed. A broken renewal keeps the old certificate.
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checked) < certCheckInterval {
+		return c.cert, nil
+	}
+	c.checked = time.Now()
+	if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+		if err := c.load(); err != nil {
+			log.Printf("Warning: Keeping the current TLS certificate: %v", err)
+		} else {
+			log.Println("Loaded the renewed TLS certificate")
+		}
+	}
+	return c.cert, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// steamAppDetailsURL describes Steam apps, filtered to their videos
+const steamAppDetailsURL = "https://store.steampowered.com/api/appdetails"
+
+// Trailers are rarely replaced, games without one are checked again after a
+// week
+const (
+	trailerRefresh     = 30 * 24 * time.Hour
+	trailerMissRefresh = 7 * 24 * time.Hour
+)
+
+// trailer is a cached trailer lookup. An empty URL means no trailer was found.
+type trailer struct {
+	AppID   string    `json:"app_id,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// TrailerClient adds trailer links to games, caching them by title. Epic's
+// catalog APIs don't expose videos, so the trailer comes from the media of
+// the game's Steam page.
+type TrailerClient struct {
+	cache *titleCache[trailer]
+}
+
+// NewTrailerClient creates a client, loading the cache from path if it
+// exists. An empty path keeps the cache in memory only.
+func NewTrailerClient(path string) *TrailerClient {
+	return &TrailerClient{cache: newTitleCache[trailer]("trailer", path)}
+}
+
+// Name implements Enricher
+func (c *TrailerClient) Name() string {
+	return "trailers"
+}
+
+// Enrich sets the trailer URL of the games with a trailer. Failed lookups are
+// logged and leave the game without a trailer.
+func (c *TrailerClient) Enrich(ctx context.Context, games []Game) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	changed := false
+	for i := range games {
+		key := titleKey(games[i].Title)
+		if key == "" || isMysteryGame(games[i]) {
+			continue
+		}
+
+		cached, ok := c.cache.entries[key]
+		refresh := trailerRefresh
+		if ok && cached.URL == "" {
+			refresh = trailerMissRefresh
+		}
+		if !ok || time.Since(cached.Checked) > refresh {
+			appID := ""
+			if gameStore(games[i]) == storeSteam {
+				appID = games[i].offerID
+			}
+			result, err := c.lookup(ctx, games[i].Title, appID)
+			if err != nil {
+				log.Printf("Warning: Error looking up trailer of %s: %v", games[i].Title, err)
+				if !ok {
+					continue
+				}
+			} else {
+				cached = result
+				c.cache.entries[key] = result
+				changed = true
+			}
+		}
+
+		games[i].TrailerURL = cached.URL
+	}
+
+	if changed {
+		if err := c.cache.save(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// lookup finds the Steam app of the title, unless appID is known, and returns
+// its first video in the highest quality
+func (c *TrailerClient) lookup(ctx context.Context, title, appID string) (*trailer, error) {
+	result := &trailer{Checked: time.Now()}
+
+	if appID == "" {
+		var err error
+		if appID, err = findSteamApp(ctx, title); err != nil {
+			return nil, err
+		}
+		if appID == "" {
+			return result, nil
+		}
+	}
+	result.AppID = appID
+
+	// Data is an empty array instead of an object when the app has no videos
+	var details map[string]struct {
+		Data json.RawMessage `json:"data"`
+	}
+	query := url.Values{"appids": {appID}, "filters": {"movies"}}
+	if err := getJSON(ctx, steamAppDetailsURL+"?"+query.Encode(), &details); err != nil {
+		return nil, fmt.Errorf("error fetching Steam app details: %v", err)
+	}
+	data := details[appID].Data
+	if len(data) == 0 || data[0] != '{' {
+		return result, nil
+	}
+
+	type video struct {
+		Max string `json:"max"`
+	}
+	var media struct {
+		Movies []struct {
+			MP4  video `json:"mp4"`
+			WebM video `json:"webm"`
+		} `json:"movies"`
+	}
+	if err := json.Unmarshal(data, &media); err != nil {
+		return nil, fmt.Errorf("error decoding Steam app details: %v", err)
+	}
+
+	movies := media.Movies
+	for _, movie := range movies {
+		for _, src := range []string{movie.MP4.Max, movie.WebM.Max} {
+			if src != "" {
+				result.URL = src
+				return result, nil
+			}
+		}
+	}
+	if len(movies) > 0 {
+		// Newer videos are only streamed, the store page plays them
+		result.URL = "https://store.steampowered.com/app/" + appID + "/"
+	}
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioNotifier texts newly free games to one or more phone numbers
+type TwilioNotifier struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	To         []string
+	MaxLength  int // Maximum characters per SMS
+	MaxPerRun  int // Maximum SMS sent per run across all recipients, 0 means unlimited
+	tracker    *SeenTracker
+	client     *http.Client
+}
+
+// NewTwilioNotifier creates a Twilio SMS notifier. Games already texted are
+// remembered by tracker; games that don't fit in the caps are sent next run.
+func NewTwilioNotifier(accountSID, authToken, from string, to []string, maxLength, maxPerRun int, tracker *SeenTracker) *TwilioNotifier {
+	if maxLength <= 0 {
+		maxLength = 160
+	}
+	return &TwilioNotifier{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		To:         to,
+		MaxLength:  maxLength,
+		MaxPerRun:  maxPerRun,
+		tracker:    tracker,
+		client:     newHTTPClient(10 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (t *TwilioNotifier) Name() string {
+	return "twilio"
+}
+
+// pendingGames returns the games not texted to every recipient yet
+func (t *TwilioNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return t.tracker.Unseen(games), t.tracker
+}
+
+// Notify texts the new games, splitting them across messages of at most
+// MaxLength characters. Games are remembered per recipient, so a failed text
+// is only sent again to the recipients that didn't get it.
+func (t *TwilioNotifier) Notify(ctx context.Context, games []Game) error {
+	if len(t.To) == 0 {
+		return nil
+	}
+
+	// The cap is shared evenly by the recipients
+	maxMessages := 0
+	if t.MaxPerRun > 0 {
+		maxMessages = t.MaxPerRun / len(t.To)
+		if maxMessages == 0 {
+			return fmt.Errorf("SMS cap of %d per run is lower than the %d recipients", t.MaxPerRun, len(t.To))
+		}
+	}
+
+	var errs []error
+	for _, to := range t.To {
+		unseen := t.tracker.UnseenBy(to, games)
+		if len(unseen) == 0 {
+			continue
+		}
+
+		messages, included, err := t.buildMessages(unseen)
+		if err != nil {
+			return err
+		}
+		if maxMessages > 0 && len(messages) > maxMessages {
+			log.Printf("Twilio: %d messages needed for %s, sending %d this run", len(messages), to, maxMessages)
+			messages = messages[:maxMessages]
+		}
+		for i, message := range messages {
+			if err := t.sendSMS(ctx, to, message); err != nil {
+				errs = append(errs, fmt.Errorf("error texting %s: %v", to, err))
+				break
+			}
+			if err := t.tracker.MarkSeenBy(to, included[i]...); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+
+	// Games every recipient got are seen for the whole channel
+	if err := t.tracker.MarkSeenByAll(t.To, games); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return errors.Join(errs...)
+}
+
+// buildMessages packs one line per game into messages no longer than
+// MaxLength, returning the games contained in each message
+func (t *TwilioNotifier) buildMessages(games []Game) ([]string, [][]Game, error) {
+	const header = "Free on Epic:"
+
+	var messages []string
+	var included [][]Game
+	current := header
+	var currentGames []Game
+
+	for _, game := range games {
+		text, err := messageTemplates.RenderGame(t.Name(), game)
+		if err != nil {
+			return nil, nil, err
+		}
+		line := "\n" + text
+		if len([]rune(header+line)) > t.MaxLength {
+			line = truncateRunes(line, t.MaxLength-len([]rune(header)))
+		}
+
+		if len([]rune(current+line)) > t.MaxLength {
+			messages = append(messages, current)
+			included = append(included, currentGames)
+			current = header
+			currentGames = nil
+		}
+		current += line
+		currentGames = append(currentGames, game)
+	}
+
+	if len(currentGames) > 0 {
+		messages = append(messages, current)
+		included = append(included, currentGames)
+	}
+	return messages, included, nil
+}
+
+func (t *TwilioNotifier) sendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("From", t.From)
+	form.Set("To", to)
+	form.Set("Body", body)
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", url.PathEscape(t.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating Twilio request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending Twilio request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Twilio returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	twitterTweetURL       = "https://api.x.com/2/tweets"
+	twitterMediaUploadURL = "https://api.x.com/2/media/upload"
+
+	// Links always count as 23 characters regardless of their length
+	twitterMaxLength  = 280
+	twitterLinkLength = 23
+)
+
+// TwitterCredentials holds the OAuth 1.0a user context keys of an X app
+type TwitterCredentials struct {
+	ConsumerKey       string
+	ConsumerSecret    string
+	AccessToken       string
+	AccessTokenSecret string
+}
+
+// TwitterNotifier tweets every newly detected free game
+type TwitterNotifier struct {
+	Credentials TwitterCredentials
+	AttachImage bool
+	MaxPerRun   int // Maximum number of tweets per run, 0 means unlimited
+	tracker     *SeenTracker
+	client      *http.Client
+}
+
+// NewTwitterNotifier creates an X/Twitter notifier. Games already tweeted are
+// remembered by tracker; games over the per-run cap are tweeted on later runs.
+func NewTwitterNotifier(credentials TwitterCredentials, attachImage bool, maxPerRun int, tracker *SeenTracker) *TwitterNotifier {
+	return &TwitterNotifier{
+		Credentials: credentials,
+		AttachImage: attachImage,
+		MaxPerRun:   maxPerRun,
+		tracker:     tracker,
+		client:      newHTTPClient(30 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (t *TwitterNotifier) Name() string {
+	return "twitter"
+}
+
+// pendingGames returns the games not announced yet
+func (t *TwitterNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return t.tracker.Unseen(games), t.tracker
+}
+
+// Notify tweets each game that hasn't been tweeted before, up to MaxPerRun
+func (t *TwitterNotifier) Notify(ctx context.Context, games []Game) error {
+	unseen := t.tracker.Unseen(games)
+	if t.MaxPerRun > 0 && len(unseen) > t.MaxPerRun {
+		log.Printf("Twitter: %d new games, tweeting %d this run", len(unseen), t.MaxPerRun)
+		unseen = unseen[:t.MaxPerRun]
+	}
+
+	for _, game := range unseen {
+		text, err := formatTweet(game)
+		if err != nil {
+			return err
+		}
+
+		var mediaIDs []string
+		if t.AttachImage && game.ImageURL != "" {
+			mediaID, err := t.uploadMedia(ctx, game.ImageURL)
+			if err != nil {
+				log.Printf("Warning: Error uploading Twitter media for %s: %v", game.Title, err)
+			} else {
+				mediaIDs = append(mediaIDs, mediaID)
+			}
+		}
+
+		if err := t.postTweet(ctx, text, mediaIDs); err != nil {
+			return fmt.Errorf("error tweeting %s: %v", game.Title, err)
+		}
+
+		if err := t.tracker.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+// formatTweet renders the tweet text, shortening the title if the tweet
+// would exceed the length limit. Links count as twitterLinkLength.
+func formatTweet(game Game) (string, error) {
+	text, err := messageTemplates.RenderGame("twitter", game)
+	if err != nil {
+		return "", err
+	}
+	length := utf8.RuneCountInString(text)
+	if game.URL != "" {
+		length -= strings.Count(text, game.URL) * (utf8.RuneCountInString(game.URL) - twitterLinkLength)
+	}
+	excess := length - twitterMaxLength
+	title := utf8.RuneCountInString(game.Title)
+	if excess <= 0 || excess >= title {
+		return text, nil
+	}
+	shortened := game
+	shortened.Title = truncateRunes(game.Title, title-excess)
+	return messageTemplates.RenderGame("twitter", shortened)
+}
+
+func (t *TwitterNotifier) postTweet(ctx context.Context, text string, mediaIDs []string) error {
+	payload := map[string]interface{}{"text": text}
+	if len(mediaIDs) > 0 {
+		payload["media"] = map[string]interface{}{"media_ids": mediaIDs}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshaling tweet: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", twitterTweetURL, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("error creating tweet request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", t.Credentials.authorizationHeader(req.Method, req.URL, nil))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending tweet request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("X API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// uploadMedia downloads an image and uploads it to X, returning the media ID
+func (t *TwitterNotifier) uploadMedia(ctx context.Context, imageURL string) (string, error) {
+	imgResp, err := httpGet(ctx, t.client, imageURL)
+	if err != nil {
+		return "", fmt.Errorf("error downloading image: %v", err)
+	}
+	defer imgResp.Body.Close()
+
+	if imgResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading image: status %d", imgResp.StatusCode)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("media_category", "tweet_image")
+	part, err := writer.CreateFormFile("media", path.Base(imgResp.Request.URL.Path))
+	if err != nil {
+		return "", fmt.Errorf("error creating multipart body: %v", err)
+	}
+	if _, err := io.Copy(part, imgResp.Body); err != nil {
+		return "", fmt.Errorf("error reading image: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("error creating multipart body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", twitterMediaUploadURL, &body)
+	if err != nil {
+		return "", fmt.Errorf("error creating media request: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	// Multipart bodies are not part of the OAuth signature
+	req.Header.Set("Authorization", t.Credentials.authorizationHeader(req.Method, req.URL, nil))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending media request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("X API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var media struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return "", fmt.Errorf("error decoding media response: %v", err)
+	}
+	return media.Data.ID, nil
+}
+
+// authorizationHeader builds an OAuth 1.0a HMAC-SHA1 Authorization header.
+// params holds any form-encoded body parameters that must be signed.
+func (c TwitterCredentials) authorizationHeader(method string, u *url.URL, params url.Values) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     c.ConsumerKey,
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_token":            c.AccessToken,
+		"oauth_version":          "1.0",
+	}
+
+	// Collect every parameter that takes part in the signature
+	var pairs []string
+	for k, v := range oauthParams {
+		pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+	}
+	for k, values := range u.Query() {
+		for _, v := range values {
+			pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+		}
+	}
+	for k, values := range params {
+		for _, v := range values {
+			pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+
+	baseURL := fmt.Sprintf("%s://%s%s", strings.ToLower(u.Scheme), strings.ToLower(u.Host), u.EscapedPath())
+	baseString := strings.ToUpper(method) + "&" + oauthEscape(baseURL) + "&" + oauthEscape(strings.Join(pairs, "&"))
+	signingKey := oauthEscape(c.ConsumerSecret) + "&" + oauthEscape(c.AccessTokenSecret)
+
+	mac := hmac.New(sha1.New, []byte(signingKey))
+	mac.Write([]byte(baseString))
+	oauthParams["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	keys := make([]string, 0, len(oauthParams))
+	for k := range oauthParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var header []string
+	for _, k := range keys {
+		header = append(header, fmt.Sprintf(`%s="%s"`, oauthEscape(k), oauthEscape(oauthParams[k])))
+	}
+	return "OAuth " + strings.Join(header, ", ")
+}
+
+// oauthEscape percent-encodes a string as required by RFC 5849
+func oauthEscape(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '.' || b == '_' || b == '~' {
+			sb.WriteByte(b)
+		} else {
+			sb.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return sb.String()
+}
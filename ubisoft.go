@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ubisoftNewsURL is the Ubisoft services news feed behind free.ubisoft.com,
+// which lists the free game events
+const ubisoftNewsURL = "https://public-ubiservices.ubi.com/v1/spaces/news"
+
+// IDs free.ubisoft.com sends, the feed answers only to known applications
+const (
+	ubisoftFreeSpaceID = "6d0af36b-8226-44b6-a03b-4660073a6349"
+	ubisoftFreeAppID   = "314d4fef-e568-454a-ae06-43e3bece12a6"
+)
+
+// includeUbisoft adds Ubisoft's free game events to every fetch, set by
+// -ubisoft-giveaways
+var includeUbisoft bool
+
+// ubisoftNewsResponse is the part of the news feed the service uses
+type ubisoftNewsResponse struct {
+	News []struct {
+		NewsID          string `json:"newsId"`
+		Title           string `json:"title"`
+		Body            string `json:"body"`
+		MediaURL        string `json:"mediaURL"`
+		PublicationDate string `json:"publicationDate"`
+		ExpirationDate  string `json:"expirationDate"`
+		Links           []struct {
+			Param string `json:"param"`
+		} `json:"links"`
+	} `json:"news"`
+}
+
+// fetchUbisoftGiveaways returns the free game events of Ubisoft Connect.
+// Besides giveaways Ubisoft runs free weekends, told apart by their wording.
+func fetchUbisoftGiveaways(ctx context.Context, locale string, includeUpcoming bool, timezone string) ([]Game, error) {
+	query := url.Values{"spaceId": {ubisoftFreeSpaceID}}
+	req, err := http.NewRequestWithContext(ctx, "GET", ubisoftNewsURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Ubi-AppId", ubisoftFreeAppID)
+	req.Header.Set("Ubi-LocaleCode", locale)
+
+	client := newHTTPClient(30 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bad status: %d, response: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var news ubisoftNewsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&news); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	now := time.Now()
+	var games []Game
+	for _, item := range news.News {
+		start, end := parseEpicDate(item.PublicationDate), parseEpicDate(item.ExpirationDate)
+		if !end.IsZero() && end.Before(now) {
+			continue
+		}
+
+		game := Game{
+			ID:          gameID(storeUbisoft, item.NewsID),
+			Title:       item.Title,
+			Description: item.Body,
+			ImageURL:    item.MediaURL,
+			URL:         "https://free.ubisoft.com/",
+			Status:      "free",
+			OfferType:   "BASE_GAME",
+			OfferKind:   classifyOffer(true, "", item.Title, item.Body),
+			Store:       storeUbisoft,
+			namespace:   storeUbisoft,
+			offerID:     item.NewsID,
+		}
+		if len(item.Links) > 0 && item.Links[0].Param != "" {
+			game.URL = item.Links[0].Param
+		}
+		if start.After(now) {
+			if !includeUpcoming {
+				continue
+			}
+			game.Status = "coming soon"
+		}
+		setPromotionWindow(&game, start, end, timezone)
+		games = append(games, game)
+	}
+	return games, nil
+}
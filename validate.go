@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	// Timezones validate the same on images without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser parses schedules like the scheduler, with a seconds field
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// localePattern matches locales like en, en-US or zh-Hant
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// hostnamePattern matches DNS names like games.example.com
+var hostnamePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,63}$`)
+
+// Config is the part of the configuration checked on startup, so mistakes
+// fail fast instead of surfacing when a scheduled job runs
+type Config struct {
+	CronSchedule        string // Checked when set
+	ScheduleMode        string
+	SmartSchedulePoll   time.Duration // Checked in smart mode
+	MaintenanceSchedule string        // Checked unless empty or off
+	Timezone            string
+	Country             string
+	Locale              string
+	TLSCert             string
+	TLSKey              string
+	AutocertHosts       []string
+	URLs                map[string]string // By flag name, the http(s) URLs set
+}
+
+// Validate returns every problem of the configuration, joined
+func (c Config) Validate() error {
+	var errs []error
+	if c.CronSchedule != "" {
+		if _, err := cronParser.Parse(c.CronSchedule); err != nil {
+			errs = append(errs, fmt.Errorf("cron-schedule: invalid schedule %q: %v", c.CronSchedule, err))
+		}
+	}
+	if err := validateScheduleMode(c.ScheduleMode); err != nil {
+		errs = append(errs, fmt.Errorf("schedule-mode: %v", err))
+	} else if c.ScheduleMode == scheduleModeSmart && c.SmartSchedulePoll <= 0 {
+		errs = append(errs, fmt.Errorf("smart-schedule-poll: must be positive in smart mode"))
+	}
+	if c.MaintenanceSchedule != "" && c.MaintenanceSchedule != "off" {
+		if _, err := cronParser.Parse(c.MaintenanceSchedule); err != nil {
+			errs = append(errs, fmt.Errorf("maintenance-schedule: invalid schedule %q: %v", c.MaintenanceSchedule, err))
+		}
+	}
+	if err := validateTimezone(c.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("timezone: %v", err))
+	}
+	if err := validateCountry(c.Country); err != nil {
+		errs = append(errs, fmt.Errorf("country: %v", err))
+	}
+	if !localePattern.MatchString(c.Locale) {
+		errs = append(errs, fmt.Errorf("locale: invalid locale %q, expected e.g. en-US", c.Locale))
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, fmt.Errorf("tls-cert, tls-key: both are required to serve HTTPS"))
+	}
+	if c.TLSCert != "" && len(c.AutocertHosts) > 0 {
+		errs = append(errs, fmt.Errorf("autocert-host: can't be combined with tls-cert"))
+	}
+	for _, host := range c.AutocertHosts {
+		if !hostnamePattern.MatchString(host) {
+			errs = append(errs, fmt.Errorf("autocert-host: invalid hostname %q, expected e.g. games.example.com", host))
+		}
+	}
+
+	names := make([]string, 0, len(c.URLs))
+	for name := range c.URLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := validateHTTPURL(c.URLs[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateTimezone accepts the IANA names and the UTC+8 or GMT-5 offsets
+// dates are formatted with
+func validateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err == nil {
+		return nil
+	}
+	for _, prefix := range []string{"UTC", "GMT"} {
+		if offset, ok := strings.CutPrefix(timezone, prefix); ok {
+			hours := 0
+			if _, err := fmt.Sscanf(offset, "%d", &hours); err == nil && hours >= -12 && hours <= 14 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unknown timezone %q, expected e.g. Europe/Berlin or UTC+8", timezone)
+}
+
+// validateHTTPURL rejects anything but absolute http and https URLs, empty
+// values are unset and fine
+func validateHTTPURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", value, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q, expected an http:// or https:// URL", value)
+	}
+	return nil
+}
+
+// testGame is the placeholder sent by validate -test-notify
+func testGame() Game {
+	now := time.Now()
+	return Game{
+		ID:          "test:notification",
+		Store:       storeEpic,
+		Title:       "Test notification",
+		Description: "epic-games-api can reach this channel. This isn't a real giveaway.",
+		URL:         "https://store.epicgames.com/free-games",
+		Status:      "free",
+		OfferKind:   offerKindGiveaway,
+		StartDate:   now.Format("2006-01-02 15:04:05 MST"),
+		EndDate:     now.Add(24 * time.Hour).Format("2006-01-02 15:04:05 MST"),
+		startTime:   now,
+		endTime:     now.Add(24 * time.Hour),
+	}
+}
+
+// validateFlags defines the flags of the validate command
+func validateFlags(flags *flag.FlagSet) (testNotify *bool) {
+	return flags.Bool("test-notify", false, "Send a test message to every notification channel")
+}
+
+// runValidateCommand reports the valid configuration to w, and with
+// -test-notify sends a test game to every notifier
+func runValidateCommand(args []string, w io.Writer, notifiers []Notifier) error {
+	flags := flag.NewFlagSet(commandValidate, flag.ContinueOnError)
+	testNotify := validateFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Configuration is valid, %d notification channels configured\n", len(notifiers))
+	if !*testNotify {
+		return nil
+	}
+
+	var errs []error
+	for _, n := range notifiers {
+		if err := notifyChannel(context.Background(), n, []Game{testGame()}); err != nil {
+			fmt.Fprintf(w, "%s: failed: %v\n", n.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %v", n.Name(), err))
+			continue
+		}
+		fmt.Fprintf(w, "%s: test message sent\n", n.Name())
+	}
+	return errors.Join(errs...)
+}
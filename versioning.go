@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// apiSchemaVersion is reported in every response of the /v1 API. It only
+// changes together with the path prefix, e.g. /v2 with schema_version 2.
+const apiSchemaVersion = 1
+
+// handleAPI registers an endpoint under its versioned path, and under its
+// pre-versioning path as a deprecated alias. Paths ending in "/" are prefixes.
+func handleAPI(path, legacyPath string, handler http.HandlerFunc) {
+	handleAPIOn(http.DefaultServeMux, path, legacyPath, handler)
+}
+
+// handleAPIOn registers an endpoint like handleAPI, on another mux
+func handleAPIOn(mux *http.ServeMux, path, legacyPath string, handler http.HandlerFunc) {
+	mux.HandleFunc(path, handler)
+	mux.HandleFunc(legacyPath, func(w http.ResponseWriter, r *http.Request) {
+		successor := path + strings.TrimPrefix(r.URL.Path, legacyPath)
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		handler(w, r)
+	})
+}
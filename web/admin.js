@@ -0,0 +1,282 @@
+(function () {
+	'use strict';
+
+	var $ = function (id) { return document.getElementById(id); };
+	var targets = [];
+	var editing = null;
+
+	// The key lives in this tab only, it's asked again in a new one
+	function apiKey() {
+		return sessionStorage.getItem('apiKey') || '';
+	}
+
+	function showMessage(text, failed) {
+		$('message').textContent = text || '';
+		$('message').className = failed ? 'failed' : '';
+	}
+
+	function api(method, path, body) {
+		var options = { method: method, headers: { 'X-API-Key': apiKey() } };
+		if (body !== undefined) {
+			options.headers['Content-Type'] = 'application/json';
+			options.body = JSON.stringify(body);
+		}
+		return fetch(path, options).then(function (response) {
+			return response.json().catch(function () {
+				return { success: false, message: response.statusText };
+			}).then(function (data) {
+				if (response.status === 401 || response.status === 403) {
+					signOut();
+				}
+				if (!response.ok || data.success === false) {
+					throw new Error(data.message || data.error || response.statusText);
+				}
+				return data;
+			});
+		});
+	}
+
+	function cell(row, text) {
+		var td = document.createElement('td');
+		td.textContent = text;
+		row.appendChild(td);
+		return td;
+	}
+
+	function button(parent, text, onClick) {
+		var element = document.createElement('button');
+		element.type = 'button';
+		element.textContent = text;
+		element.addEventListener('click', onClick);
+		parent.appendChild(element);
+		return element;
+	}
+
+	function describeFilter(filter) {
+		var parts = [];
+		if (filter.status) {
+			parts.push(filter.status);
+		}
+		if (filter.exclude_dlc) {
+			parts.push('no add-ons');
+		}
+		if (filter.only_dlc) {
+			parts.push('add-ons only');
+		}
+		if (filter.min_original_price) {
+			parts.push('from ' + filter.min_original_price);
+		}
+		['stores', 'offer_kinds', 'genres', 'platforms'].forEach(function (field) {
+			if (filter[field] && filter[field].length) {
+				parts.push(filter[field].join(', '));
+			}
+		});
+		if (filter.region) {
+			parts.push('region ' + filter.region);
+		}
+		return parts.join('; ') || 'All games';
+	}
+
+	function renderTargets() {
+		var body = $('targets').querySelector('tbody');
+		body.textContent = '';
+		targets.forEach(function (target) {
+			var row = document.createElement('tr');
+			cell(row, target.name);
+			cell(row, target.url.split('://')[0]);
+			cell(row, describeFilter(target.filter || {}));
+			var schedule = target.schedule || 'With the server checks';
+			if (target.next_run) {
+				schedule += ', next ' + new Date(target.next_run).toLocaleString();
+			}
+			cell(row, schedule);
+			cell(row, target.enabled ? 'Yes' : 'No');
+			var actions = cell(row, '');
+			button(actions, 'Edit', function () { edit(target); });
+			button(actions, target.enabled ? 'Disable' : 'Enable', function () {
+				var changed = Object.assign({}, target, { enabled: !target.enabled });
+				api('PUT', '/v1/admin/targets/' + target.id, changed).then(loadTargets, function (err) {
+					showMessage(err.message, true);
+				});
+			});
+			button(actions, 'Send test', function () {
+				showMessage('Sending a test notification to ' + target.name + '...');
+				api('POST', '/v1/admin/targets/' + target.id + '/test').then(function (data) {
+					showMessage(data.message);
+					loadDeliveries();
+				}, function (err) {
+					showMessage(err.message, true);
+				});
+			});
+			button(actions, 'Delete', function () {
+				if (!confirm('Delete ' + target.name + '?')) {
+					return;
+				}
+				api('DELETE', '/v1/admin/targets/' + target.id).then(loadTargets, function (err) {
+					showMessage(err.message, true);
+				});
+			});
+			body.appendChild(row);
+		});
+		if (!targets.length) {
+			var row = document.createElement('tr');
+			cell(row, 'No targets yet.').colSpan = 6;
+			body.appendChild(row);
+		}
+	}
+
+	function loadTargets() {
+		return api('GET', '/v1/admin/targets').then(function (data) {
+			targets = data.data;
+			$('persistence').textContent = data.persistent ? '' :
+				'Without a state database (DATABASE_URL), targets are lost when the server restarts.';
+			renderTargets();
+		});
+	}
+
+	function renderDeliveries(deliveries) {
+		var body = $('deliveries').querySelector('tbody');
+		body.textContent = '';
+		deliveries.forEach(function (delivery) {
+			var row = document.createElement('tr');
+			cell(row, new Date(delivery.delivered_at).toLocaleString());
+			cell(row, delivery.channel);
+			var result = cell(row, delivery.error ? delivery.result + ': ' + delivery.error : delivery.result);
+			if (delivery.error) {
+				result.className = 'failed';
+			}
+			cell(row, (delivery.games || []).map(function (game) { return game.title; }).join(', '));
+			cell(row, String(delivery.attempt));
+			var actions = cell(row, '');
+			if (delivery.error) {
+				button(actions, 'Retry', function () {
+					api('POST', '/v1/deliveries/' + delivery.id + '/retry').then(function (data) {
+						showMessage(data.message || 'Delivery retried');
+						loadDeliveries();
+					}, function (err) {
+						showMessage(err.message, true);
+					});
+				});
+			}
+			body.appendChild(row);
+		});
+		if (!deliveries.length) {
+			var row = document.createElement('tr');
+			cell(row, 'No deliveries yet.').colSpan = 6;
+			body.appendChild(row);
+		}
+	}
+
+	function loadDeliveries() {
+		var path = '/v1/deliveries?limit=50' + ($('failed-only').checked ? '&failed=true' : '');
+		return api('GET', path).then(function (data) {
+			renderDeliveries(data.data || []);
+		}, function (err) {
+			showMessage(err.message, true);
+		});
+	}
+
+	function splitList(value) {
+		return value.split(',').map(function (item) { return item.trim(); }).filter(Boolean);
+	}
+
+	function edit(target) {
+		editing = target;
+		var form = $('editor').elements;
+		var filter = (target && target.filter) || {};
+		$('editor-title').textContent = target ? 'Edit ' + target.name : 'Add a target';
+		form.name.value = target ? target.name : '';
+		form.url.value = target ? target.url : '';
+		form.schedule.value = target ? target.schedule || '' : '';
+		form.enabled.checked = target ? target.enabled : true;
+		form.status.value = filter.status || '';
+		form.dlc.value = filter.exclude_dlc ? 'exclude' : filter.only_dlc ? 'only' : '';
+		form.min_original_price.value = filter.min_original_price || '';
+		['stores', 'offer_kinds', 'genres', 'platforms'].forEach(function (field) {
+			form[field].value = (filter[field] || []).join(', ');
+		});
+		form.region.value = filter.region || '';
+		$('editor-section').hidden = false;
+		form.name.focus();
+	}
+
+	function save(event) {
+		event.preventDefault();
+		var form = $('editor').elements;
+		var target = {
+			name: form.name.value,
+			url: form.url.value,
+			schedule: form.schedule.value,
+			enabled: form.enabled.checked,
+			filter: {
+				status: form.status.value,
+				exclude_dlc: form.dlc.value === 'exclude',
+				only_dlc: form.dlc.value === 'only',
+				min_original_price: parseFloat(form.min_original_price.value) || 0,
+				stores: splitList(form.stores.value),
+				offer_kinds: splitList(form.offer_kinds.value),
+				genres: splitList(form.genres.value),
+				platforms: splitList(form.platforms.value),
+				region: form.region.value.trim().toUpperCase()
+			}
+		};
+		var request = editing ?
+			api('PUT', '/v1/admin/targets/' + editing.id, target) :
+			api('POST', '/v1/admin/targets', target);
+		request.then(function (data) {
+			showMessage(data.data[0].name + ' saved');
+			$('editor-section').hidden = true;
+			editing = null;
+			loadTargets();
+		}, function (err) {
+			showMessage(err.message, true);
+		});
+	}
+
+	function signIn() {
+		loadTargets().then(function () {
+			showMessage('');
+			$('api-key').hidden = true;
+			$('login').querySelector('[type=submit]').hidden = true;
+			$('logout').hidden = false;
+			$('targets-section').hidden = false;
+			$('deliveries-section').hidden = false;
+			loadDeliveries();
+		}, function (err) {
+			showMessage(err.message, true);
+		});
+	}
+
+	function signOut() {
+		sessionStorage.removeItem('apiKey');
+		$('api-key').hidden = false;
+		$('login').querySelector('[type=submit]').hidden = false;
+		$('logout').hidden = true;
+		$('targets-section').hidden = true;
+		$('editor-section').hidden = true;
+		$('deliveries-section').hidden = true;
+	}
+
+	$('login').addEventListener('submit', function (event) {
+		event.preventDefault();
+		sessionStorage.setItem('apiKey', $('api-key').value);
+		$('api-key').value = '';
+		signIn();
+	});
+	$('logout').addEventListener('click', function () {
+		signOut();
+		showMessage('');
+	});
+	$('add').addEventListener('click', function () { edit(null); });
+	$('cancel').addEventListener('click', function () {
+		$('editor-section').hidden = true;
+		editing = null;
+	});
+	$('editor').addEventListener('submit', save);
+	$('refresh').addEventListener('click', loadDeliveries);
+	$('failed-only').addEventListener('change', loadDeliveries);
+
+	if (apiKey()) {
+		signIn();
+	}
+}());
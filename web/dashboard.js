@@ -0,0 +1,272 @@
+(function () {
+	'use strict';
+
+	var countries = {
+		AR: 'Argentina', AU: 'Australia', BR: 'Brazil', CA: 'Canada', DE: 'Germany',
+		ES: 'Spain', FR: 'France', GB: 'United Kingdom', IN: 'India', IT: 'Italy',
+		JP: 'Japan', KR: 'South Korea', MX: 'Mexico', NL: 'Netherlands', PH: 'Philippines',
+		PL: 'Poland', RU: 'Russia', SE: 'Sweden', TR: 'Turkey', US: 'United States'
+	};
+	var timezones = [
+		'UTC', 'America/Los_Angeles', 'America/Denver', 'America/Chicago', 'America/New_York',
+		'America/Sao_Paulo', 'Europe/London', 'Europe/Berlin', 'Europe/Moscow', 'Asia/Kolkata',
+		'Asia/Manila', 'Asia/Tokyo', 'Australia/Sydney'
+	];
+	var storeNames = {
+		epic: 'Epic Games Store', gog: 'GOG', steam: 'Steam', prime: 'Prime Gaming',
+		itchio: 'itch.io', ubisoft: 'Ubisoft Connect', ps_plus: 'PlayStation Plus', xbox: 'Xbox'
+	};
+
+	var games = [];
+	var $ = function (id) { return document.getElementById(id); };
+
+	// Settings come from the query string, so a bookmarked view keeps them,
+	// then from the last visit
+	function loadSettings() {
+		var query = new URLSearchParams(location.search);
+		var saved = {};
+		try {
+			saved = JSON.parse(localStorage.getItem('dashboard') || '{}');
+		} catch (e) {}
+		var browserZone = Intl.DateTimeFormat().resolvedOptions().timeZone || '';
+		return {
+			country: query.get('country') || saved.country || '',
+			timezone: query.get('timezone') || saved.timezone || browserZone
+		};
+	}
+
+	function saveSettings(settings) {
+		localStorage.setItem('dashboard', JSON.stringify(settings));
+		var query = new URLSearchParams();
+		if (settings.country) {
+			query.set('country', settings.country);
+		}
+		if (settings.timezone) {
+			query.set('timezone', settings.timezone);
+		}
+		var search = query.toString();
+		history.replaceState(null, '', search ? '?' + search : location.pathname);
+	}
+
+	function addOptions(select, options, selected) {
+		options.forEach(function (option) {
+			var element = document.createElement('option');
+			element.value = option[0];
+			element.textContent = option[1];
+			select.appendChild(element);
+		});
+		if (selected && !options.some(function (option) { return option[0] === selected; })) {
+			addOptions(select, [[selected, selected]]);
+		}
+		select.value = selected || '';
+	}
+
+	function settings() {
+		return { country: $('country').value, timezone: $('timezone').value };
+	}
+
+	function load() {
+		var current = settings();
+		saveSettings(current);
+		var query = new URLSearchParams({ upcoming: 'true' });
+		if (current.country) {
+			query.set('country', current.country);
+		}
+		if (current.timezone) {
+			query.set('timezone', current.timezone);
+		}
+
+		$('message').textContent = 'Loading...';
+		fetch('/v1/free-games?' + query.toString()).then(function (resp) {
+			return resp.json().then(function (body) {
+				if (!resp.ok || !body.success) {
+					throw new Error(body.message || resp.statusText);
+				}
+				return body;
+			});
+		}).then(function (body) {
+			games = body.data || [];
+			$('message').textContent = body.stale ? 'The store could not be reached, showing the games fetched earlier.' : '';
+			updateStores();
+			render();
+		}).catch(function (err) {
+			$('message').textContent = 'Could not load the free games: ' + err.message;
+		});
+	}
+
+	function updateStores() {
+		var select = $('store');
+		var selected = select.value;
+		var stores = {};
+		games.forEach(function (game) { stores[game.store] = true; });
+		while (select.options.length > 1) {
+			select.remove(1);
+		}
+		addOptions(select, Object.keys(stores).sort().map(function (store) {
+			return [store, storeNames[store] || store];
+		}), stores[selected] ? selected : '');
+	}
+
+	function isUpcoming(game) {
+		return game.status === 'coming soon' || game.status === 'mystery';
+	}
+
+	function matches(game) {
+		var search = $('search').value.trim().toLowerCase();
+		if (search && (game.title + ' ' + (game.publisher || '')).toLowerCase().indexOf(search) < 0) {
+			return false;
+		}
+		if ($('store').value && game.store !== $('store').value) {
+			return false;
+		}
+		if ($('kind').value && game.offer_kind !== $('kind').value) {
+			return false;
+		}
+		return true;
+	}
+
+	function render() {
+		var current = $('current');
+		var upcoming = $('upcoming');
+		current.textContent = '';
+		upcoming.textContent = '';
+
+		games.filter(matches).forEach(function (game) {
+			(isUpcoming(game) ? upcoming : current).appendChild(card(game));
+		});
+		$('upcoming-section').hidden = !$('show-upcoming').checked || !upcoming.children.length;
+		if (!current.children.length) {
+			current.textContent = 'No free games match.';
+		}
+		tick();
+	}
+
+	function card(game) {
+		var node = $('game-card').content.firstElementChild.cloneNode(true);
+		if (isUpcoming(game)) {
+			node.classList.add('upcoming');
+		}
+		node.querySelector('.cover').href = game.url || '#';
+		var image = node.querySelector('img');
+		if (game.image_url) {
+			image.src = game.image_url;
+			image.alt = game.title;
+		}
+		var title = node.querySelector('.title');
+		title.href = game.url || '#';
+		title.textContent = game.title;
+
+		var meta = [storeNames[game.store] || game.store];
+		if (game.publisher) {
+			meta.push(game.publisher);
+		}
+		if (game.original_price) {
+			meta.push('usually ' + game.original_price);
+		}
+		node.querySelector('.meta').textContent = meta.join(' · ');
+
+		var countdown = node.querySelector('.countdown');
+		var deadline = isUpcoming(game) ? game.start_date_iso : game.end_date_iso;
+		if (deadline && game.date_precision === 'exact') {
+			countdown.dataset.deadline = deadline;
+			countdown.dataset.prefix = isUpcoming(game) ? 'Free in ' : 'Ends in ';
+		}
+		if (game.date_precision !== 'unknown') {
+			node.querySelector('.dates').textContent = game.start_date + ' – ' + game.end_date;
+		}
+		return node;
+	}
+
+	function formatCountdown(ms) {
+		var seconds = Math.floor(ms / 1000);
+		var days = Math.floor(seconds / 86400);
+		var hours = Math.floor(seconds % 86400 / 3600);
+		var minutes = Math.floor(seconds % 3600 / 60);
+		var pad = function (n) { return n < 10 ? '0' + n : '' + n; };
+		var time = pad(hours) + ':' + pad(minutes) + ':' + pad(seconds % 60);
+		return days > 0 ? days + 'd ' + time : time;
+	}
+
+	// tick updates the countdowns, and fetches the games again once one ran
+	// out, as a giveaway ended or started
+	function tick() {
+		var expired = false;
+		document.querySelectorAll('.countdown[data-deadline]').forEach(function (countdown) {
+			var left = Date.parse(countdown.dataset.deadline) - Date.now();
+			if (left <= 0) {
+				expired = true;
+				countdown.textContent = '';
+				return;
+			}
+			countdown.textContent = countdown.dataset.prefix + formatCountdown(left);
+		});
+		return expired;
+	}
+
+	function setupPush() {
+		if (!('serviceWorker' in navigator) || !('PushManager' in window)) {
+			return;
+		}
+
+		function urlBase64ToUint8Array(base64String) {
+			var padding = '='.repeat((4 - base64String.length % 4) % 4);
+			var base64 = (base64String + padding).replace(/-/g, '+').replace(/_/g, '/');
+			var raw = window.atob(base64);
+			return Uint8Array.from(raw, function (c) { return c.charCodeAt(0); });
+		}
+
+		fetch('/v1/push/vapid-public-key').then(function (resp) {
+			if (!resp.ok) {
+				return;
+			}
+			return resp.json().then(function (data) {
+				var button = $('push-button');
+				$('push').hidden = false;
+
+				button.addEventListener('click', function () {
+					navigator.serviceWorker.register('/sw.js').then(function (registration) {
+						return registration.pushManager.subscribe({
+							userVisibleOnly: true,
+							applicationServerKey: urlBase64ToUint8Array(data.public_key)
+						});
+					}).then(function (subscription) {
+						return fetch('/v1/push/subscribe', {
+							method: 'POST',
+							headers: { 'Content-Type': 'application/json' },
+							body: JSON.stringify(subscription)
+						});
+					}).then(function () {
+						button.textContent = 'Notifications enabled';
+						button.disabled = true;
+					}).catch(function (err) {
+						button.textContent = 'Could not enable notifications';
+						console.error(err);
+					});
+				});
+			});
+		});
+	}
+
+	var initial = loadSettings();
+	addOptions($('country'), Object.keys(countries).map(function (code) {
+		return [code, countries[code]];
+	}), initial.country);
+	addOptions($('timezone'), timezones.map(function (zone) { return [zone, zone]; }), initial.timezone);
+
+	$('country').addEventListener('change', load);
+	$('timezone').addEventListener('change', load);
+	['search', 'store', 'kind', 'show-upcoming'].forEach(function (id) {
+		$(id).addEventListener('input', render);
+	});
+	// The cache may still serve an ended giveaway, don't ask again every second
+	var reloadedAt = 0;
+	setInterval(function () {
+		if (tick() && Date.now() - reloadedAt > 60000) {
+			reloadedAt = Date.now();
+			load();
+		}
+	}, 1000);
+
+	load();
+	setupPush();
+})();
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// WebhookNotifier sends games to an arbitrary HTTP endpoint, either as a JSON
+// array or rendered through a user supplied Go template
+type WebhookNotifier struct {
+	URL         string
+	Method      string
+	ContentType string
+	Headers     map[string]string
+	template    *template.Template
+	tracker     *SeenTracker
+	client      *http.Client
+}
+
+// WebhookTemplateData is the data passed to webhook payload templates
+type WebhookTemplateData struct {
+	Games     []Game
+	Count     int
+	Timestamp time.Time
+}
+
+// NewWebhookNotifier creates a generic webhook notifier. headersJSON is a JSON
+// object of extra request headers and templateFile an optional path to a
+// text/template used to render the request body. Games already sent are
+// remembered by tracker.
+func NewWebhookNotifier(url, method, contentType, headersJSON, templateFile string, tracker *SeenTracker) (*WebhookNotifier, error) {
+	if method == "" {
+		method = "POST"
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	w := &WebhookNotifier{
+		URL:         url,
+		Method:      strings.ToUpper(method),
+		ContentType: contentType,
+		Headers:     map[string]string{},
+		tracker:     tracker,
+		client:      newHTTPClient(10 * time.Second),
+	}
+
+	if headersJSON != "" {
+		if err := json.Unmarshal([]byte(headersJSON), &w.Headers); err != nil {
+			return nil, fmt.Errorf("error parsing webhook headers: %v", err)
+		}
+	}
+
+	if templateFile != "" {
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading webhook template: %v", err)
+		}
+		tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing webhook template: %v", err)
+		}
+		w.template = tmpl
+	}
+
+	return w, nil
+}
+
+// Name returns the channel identifier
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// pendingGames returns the games not announced yet
+func (w *WebhookNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return w.tracker.Unseen(games), w.tracker
+}
+
+// Notify sends the games not announced yet to the webhook endpoint
+func (w *WebhookNotifier) Notify(ctx context.Context, games []Game) error {
+	return notifyUnseen(w.tracker, games, 0, func(games []Game) error {
+		return w.send(ctx, games)
+	})
+}
+
+// send makes a single request with the games
+func (w *WebhookNotifier) send(ctx context.Context, games []Game) error {
+	payload, err := w.render(games)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.Method, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", w.ContentType)
+	for name, value := range w.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// render builds the request body, defaulting to a JSON array of the games
+func (w *WebhookNotifier) render(games []Game) ([]byte, error) {
+	if w.template == nil {
+		payload, err := json.Marshal(games)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling webhook payload: %v", err)
+		}
+		return payload, nil
+	}
+
+	var buf bytes.Buffer
+	data := WebhookTemplateData{
+		Games:     games,
+		Count:     len(games),
+		Timestamp: time.Now(),
+	}
+	if err := w.template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("error rendering webhook template: %v", err)
+	}
+	return buf.Bytes(), nil
+}
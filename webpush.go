@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	webpush "github.com/SherClockHolmes/webpush-go"
+)
+
+// maxPushSubscriptions caps the subscriptions the public subscribe endpoint
+// can store, each one is posted to on every run
+const maxPushSubscriptions = 10000
+
+// errTooManyPushSubscriptions is returned by Add when the store is full
+var errTooManyPushSubscriptions = errors.New("too many push subscriptions")
+
+// PushSubscriptionStore keeps the browser push subscriptions, persisted in
+// the database, or as JSON when a path is set
+type PushSubscriptionStore struct {
+	mu            sync.Mutex
+	path          string
+	subscriptions map[string]webpush.Subscription // keyed by endpoint
+}
+
+// NewPushSubscriptionStore creates a store, loading existing subscriptions
+// from path if it exists. An empty path keeps them in memory only.
+func NewPushSubscriptionStore(path string) *PushSubscriptionStore {
+	s := &PushSubscriptionStore{
+		path:          path,
+		subscriptions: make(map[string]webpush.Subscription),
+	}
+
+	if stateDB != nil {
+		documents, err := stateDB.PushSubscriptions()
+		if err != nil {
+			log.Printf("Warning: Error loading push subscriptions: %v", err)
+			return s
+		}
+		for endpoint, document := range documents {
+			var sub webpush.Subscription
+			if err := json.Unmarshal(document, &sub); err != nil {
+				log.Printf("Warning: Error parsing push subscription %s: %v", endpoint, err)
+				continue
+			}
+			s.subscriptions[endpoint] = sub
+		}
+		return s
+	}
+	if path == "" {
+		return s
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Error reading push subscriptions file %s: %v", path, err)
+		}
+		return s
+	}
+
+	var subscriptions []webpush.Subscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		log.Printf("Warning: Error parsing push subscriptions file %s: %v", path, err)
+		return s
+	}
+	for _, sub := range subscriptions {
+		s.subscriptions[sub.Endpoint] = sub
+	}
+	return s
+}
+
+// Add stores a subscription, replacing any previous one for the same
+// endpoint. New endpoints are refused once maxPushSubscriptions are stored.
+func (s *PushSubscriptionStore) Add(sub webpush.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[sub.Endpoint]; !ok && len(s.subscriptions) >= maxPushSubscriptions {
+		return errTooManyPushSubscriptions
+	}
+	s.subscriptions[sub.Endpoint] = sub
+	if stateDB != nil {
+		document, err := json.Marshal(sub)
+		if err != nil {
+			return fmt.Errorf("error marshaling push subscription: %v", err)
+		}
+		return stateDB.PutPushSubscription(sub.Endpoint, document)
+	}
+	return s.save()
+}
+
+// Remove deletes the subscription for an endpoint
+func (s *PushSubscriptionStore) Remove(endpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, endpoint)
+	if stateDB != nil {
+		return stateDB.DeletePushSubscription(endpoint)
+	}
+	return s.save()
+}
+
+// All returns a copy of every stored subscription
+func (s *PushSubscriptionStore) All() []webpush.Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := make([]webpush.Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions
+}
+
+// save writes the subscriptions to disk, the caller must hold the lock
+func (s *PushSubscriptionStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	subscriptions := make([]webpush.Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+
+	data, err := json.MarshalIndent(subscriptions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling push subscriptions: %v", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("error writing push subscriptions file: %v", err)
+	}
+	return nil
+}
+
+// WebPushNotifier sends new free games to every subscribed browser
+type WebPushNotifier struct {
+	Store           *PushSubscriptionStore
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	Subject         string // mailto: or https: contact for the push service
+	tracker         *SeenTracker
+	client          *http.Client
+}
+
+// webPushMessage is the payload read by the service worker in /sw.js
+type webPushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Icon  string `json:"icon,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// NewWebPushNotifier creates a Web Push notifier using the given VAPID keys.
+// Games already pushed are remembered by tracker.
+func NewWebPushNotifier(store *PushSubscriptionStore, publicKey, privateKey, subject string, tracker *SeenTracker) *WebPushNotifier {
+	return &WebPushNotifier{
+		Store:           store,
+		VAPIDPublicKey:  publicKey,
+		VAPIDPrivateKey: privateKey,
+		Subject:         subject,
+		tracker:         tracker,
+		client:          newHTTPClient(10 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (p *WebPushNotifier) Name() string {
+	return "webpush"
+}
+
+// pendingGames returns the games not announced yet
+func (p *WebPushNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return p.tracker.Unseen(games), p.tracker
+}
+
+// Notify pushes an alert for each new game to all subscribers. Subscriptions
+// the push service reports as gone are removed.
+func (p *WebPushNotifier) Notify(ctx context.Context, games []Game) error {
+	unseen := p.tracker.Unseen(games)
+	if len(unseen) == 0 {
+		return nil
+	}
+
+	subscriptions := p.Store.All()
+	for _, game := range unseen {
+		body := "Free now " + onStore(game)
+		if isUpcoming(game) {
+			body = "Coming soon for free " + onStore(game)
+		}
+		if game.EndDate != "Unknown" {
+			body += " until " + game.EndDate
+		}
+
+		payload, err := json.Marshal(webPushMessage{
+			Title: game.Title,
+			Body:  body,
+			Icon:  game.ImageURL,
+			URL:   game.URL,
+		})
+		if err != nil {
+			return fmt.Errorf("error marshaling push message: %v", err)
+		}
+
+		for i := range subscriptions {
+			if err := p.send(ctx, payload, &subscriptions[i]); err != nil {
+				log.Printf("Warning: Error sending push notification: %v", err)
+			}
+		}
+
+		if err := p.tracker.MarkSeen(game); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+func (p *WebPushNotifier) send(ctx context.Context, payload []byte, sub *webpush.Subscription) error {
+	// Checked again on every send, the host may resolve elsewhere by now
+	if err := validatePushEndpoint(ctx, sub.Endpoint); err != nil {
+		return fmt.Errorf("skipping push subscription: %v", err)
+	}
+
+	resp, err := webpush.SendNotificationWithContext(ctx, payload, sub, &webpush.Options{
+		HTTPClient:      p.client,
+		Subscriber:      p.Subject,
+		VAPIDPublicKey:  p.VAPIDPublicKey,
+		VAPIDPrivateKey: p.VAPIDPrivateKey,
+		TTL:             24 * 60 * 60,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The browser unsubscribed or the subscription expired
+		return p.Store.Remove(sub.Endpoint)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// pushSubscribeHandler stores (POST) or removes (DELETE) a browser's push
+// subscription, as produced by PushSubscription.toJSON()
+func pushSubscribeHandler(w http.ResponseWriter, r *http.Request, store *PushSubscriptionStore) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "POST, DELETE")
+		httpError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var sub webpush.Subscription
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&sub); err != nil {
+		httpError(w, fmt.Sprintf("Invalid subscription: %v", err), http.StatusBadRequest)
+		return
+	}
+	if sub.Endpoint == "" {
+		httpError(w, "Invalid subscription: missing endpoint", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = store.Remove(sub.Endpoint)
+	} else {
+		if sub.Keys.Auth == "" || sub.Keys.P256dh == "" {
+			httpError(w, "Invalid subscription: missing keys", http.StatusBadRequest)
+			return
+		}
+		if err := validatePushEndpoint(r.Context(), sub.Endpoint); err != nil {
+			httpError(w, fmt.Sprintf("Invalid subscription: %v", err), http.StatusBadRequest)
+			return
+		}
+		err = store.Add(sub)
+	}
+	if errors.Is(err, errTooManyPushSubscriptions) {
+		httpError(w, "Too many push subscriptions", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating push subscriptions: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// validatePushEndpoint checks that endpoint is an https URL of a public
+// host, so subscriptions can't make the server post into its own network
+func validatePushEndpoint(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %v", err)
+	}
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("endpoint must be an https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("error resolving endpoint host: %v", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("endpoint host %s resolves to a non-public address", u.Hostname())
+		}
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// pushServiceWorker shows notifications pushed by WebPushNotifier and opens
+// the store page when one is clicked
+const pushServiceWorker = `self.addEventListener('push', function (event) {
+  const data = event.data ? event.data.json() : {};
+  event.waitUntil(self.registration.showNotification(data.title || 'Free game on Epic Games Store', {
+    body: data.body,
+    icon: data.icon,
+    data: { url: data.url }
+  }));
+});
+
+self.addEventListener('notificationclick', function (event) {
+  event.notification.close();
+  if (event.notification.data && event.notification.data.url) {
+    event.waitUntil(clients.openWindow(event.notification.data.url));
+  }
+});
+`
+
+func serviceWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Service-Worker-Allowed", "/")
+	fmt.Fprint(w, pushServiceWorker)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ZulipNotifier posts the games to a Zulip stream and topic as a bot
+type ZulipNotifier struct {
+	SiteURL  string
+	BotEmail string
+	APIKey   string
+	Stream   string
+	Topic    string
+	tracker  *SeenTracker
+	client   *http.Client
+}
+
+// NewZulipNotifier creates a Zulip notifier for the given organization. Games
+// already posted are remembered by tracker.
+func NewZulipNotifier(siteURL, botEmail, apiKey, stream, topic string, tracker *SeenTracker) *ZulipNotifier {
+	if topic == "" {
+		topic = "Epic Games free games"
+	}
+	return &ZulipNotifier{
+		SiteURL:  strings.TrimRight(siteURL, "/"),
+		BotEmail: botEmail,
+		APIKey:   apiKey,
+		Stream:   stream,
+		Topic:    topic,
+		tracker:  tracker,
+		client:   newHTTPClient(10 * time.Second),
+	}
+}
+
+// Name returns the channel identifier
+func (z *ZulipNotifier) Name() string {
+	return "zulip"
+}
+
+// pendingGames returns the games not announced yet
+func (z *ZulipNotifier) pendingGames(games []Game) ([]Game, *SeenTracker) {
+	return z.tracker.Unseen(games), z.tracker
+}
+
+// Notify posts a single message listing the games not announced yet
+func (z *ZulipNotifier) Notify(ctx context.Context, games []Game) error {
+	return notifyUnseen(z.tracker, games, 0, func(games []Game) error {
+		return z.post(ctx, games)
+	})
+}
+
+// post sends a single message listing the games
+func (z *ZulipNotifier) post(ctx context.Context, games []Game) error {
+	content, err := messageTemplates.Render(z.Name(), formatMarkdown, games)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("type", "stream")
+	form.Set("to", z.Stream)
+	form.Set("topic", z.Topic)
+	form.Set("content", content)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", z.SiteURL+"/api/v1/messages", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating Zulip request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(z.BotEmail, z.APIKey)
+
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending Zulip request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Zulip returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}